@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"context"
+	"strings"
+
 	"github.com/spf13/cobra"
-	"pokergo/internal/articles"
 	"pokergo/internal/mongo"
+	"pokergo/internal/sim"
 	"pokergo/pkg/logger"
 	"pokergo/pkg/timer"
 )
@@ -14,15 +17,15 @@ type commandApp struct {
 	logger logger.Logger
 	timer  timer.Timer
 
-	mongoColls  *mongo.Collections
-	artsAdapter articles.Adapter
+	// connectMongo connects to the database, only commands using it connect (see mongoCollections)
+	connectMongo func(ctx context.Context) (*mongo.Collections, error)
+	mongoColls   *mongo.Collections
 }
 
 func NewCommandApp(
 	lg logger.Logger,
 	tm timer.Timer,
-	artsAdap articles.Adapter,
-	mongoColls *mongo.Collections,
+	connectMongo func(ctx context.Context) (*mongo.Collections, error),
 ) *commandApp {
 	rootCmd := &cobra.Command{
 		Use:   "pokergo",
@@ -35,11 +38,10 @@ func NewCommandApp(
 	}
 
 	app := &commandApp{
-		Command:     rootCmd,
-		logger:      lg,
-		timer:       tm,
-		artsAdapter: artsAdap,
-		mongoColls:  mongoColls,
+		Command:      rootCmd,
+		logger:       lg,
+		timer:        tm,
+		connectMongo: connectMongo,
 	}
 
 	dummyCmd := &cobra.Command{
@@ -66,9 +68,67 @@ func NewCommandApp(
 		},
 	}
 
+	var dead string
+	printRange := &cobra.Command{
+		Use:   "range [notation]",
+		Short: "Prints the range as a 13x13 grid, e.g. range \"22+, A2s+, KTo+, 76s-54s\" --dead AsKd",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app.printRange(strings.Join(args, " "), dead)
+		},
+	}
+	printRange.Flags().StringVar(&dead, "dead", "", "dead cards removed from the range, e.g. AsKd")
+
+	var orgName, userName string
+	importHands := &cobra.Command{
+		Use:   "importHands [file]",
+		Short: "Imports PokerStars hand histories to the organization, e.g. importHands hands.txt --org friends --user john",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app.importHands(args[0], orgName, userName)
+		},
+	}
+	importHands.Flags().StringVar(&orgName, "org", "", "the organization the hands are imported to")
+	importHands.Flags().StringVar(&userName, "user", "", "the member of the organization who imports the hands")
+	_ = importHands.MarkFlagRequired("org")
+	_ = importHands.MarkFlagRequired("user")
+
+	var (
+		bots        []string
+		hands       int
+		seed, stack int64
+	)
+	simulate := &cobra.Command{
+		Use:   "simulate",
+		Short: "Plays hands between bots and reports bb/100 of strategies, e.g. simulate --bots tag,random,equity:60 --hands 10000",
+		Run: func(cmd *cobra.Command, args []string) {
+			app.simulate(bots, hands, seed, stack)
+		},
+	}
+	simulate.Flags().StringSliceVar(&bots, "bots", []string{"tag", "random"},
+		"strategies by seats: random, tag (tight-aggressive), equity or equity:<threshold in %>")
+	simulate.Flags().IntVar(&hands, "hands", 10000, "the number of hands")
+	simulate.Flags().Int64Var(&seed, "seed", 1, "the seed of cards and decisions of bots")
+	simulate.Flags().Int64Var(&stack, "stack", sim.DefaultStack, "stacks in big blinds at the beginning of every hand")
+
 	rootCmd.AddCommand(dummyCmd)
 	rootCmd.AddCommand(mongoIndexes)
 	rootCmd.AddCommand(fetchArticles)
+	rootCmd.AddCommand(printRange)
+	rootCmd.AddCommand(importHands)
+	rootCmd.AddCommand(simulate)
 
 	return app
 }
+
+// mongoCollections returns mongo collections, the connection is made on the first call
+func (c *commandApp) mongoCollections() *mongo.Collections {
+	if c.mongoColls == nil {
+		colls, err := c.connectMongo(c.Context())
+		if err != nil {
+			c.logger.Fatalf("cannot init mongo: %s", err.Error())
+		}
+		c.mongoColls = colls
+	}
+	return c.mongoColls
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"pokergo/internal/hands"
+	"pokergo/internal/org"
+	"pokergo/internal/users"
+	"pokergo/pkg/id"
+)
+
+// importHands imports PokerStars hands from the file to the organization as recorded by the user,
+// hands which cannot be parsed are reported, hands imported before are skipped
+func (c *commandApp) importHands(path, orgName, userName string) {
+	f, err := os.Open(path)
+	if err != nil {
+		c.logger.Fatalf("cannot open the file: %s", err.Error())
+	}
+	defer f.Close()
+
+	parsed, err := hands.ParsePokerStars(f)
+	if err != nil {
+		c.logger.Fatalf("cannot parse the file: %s", err.Error())
+	}
+
+	usersAdapter := users.NewMongoAdapter(c.mongoCollections().Users, c.logger)
+	u, err := usersAdapter.GetUserByName(c.Context(), userName)
+	if err != nil {
+		c.logger.Fatalf("cannot find the user %s: %s", userName, err.Error())
+	}
+	orgAdapter := org.NewMongoAdapter(c.mongoCollections().Org, c.timer)
+	o, err := orgAdapter.GetOrgByName(c.Context(), orgName)
+	if err != nil {
+		c.logger.Fatalf("cannot find the org %s: %s", orgName, err.Error())
+	}
+	if !o.IsMember(u.ID) {
+		c.logger.Fatalf("%s is NOT a member of %s", userName, orgName)
+	}
+
+	handsAdapter := hands.NewMongoAdapter(c.mongoCollections().Hands)
+	out := c.OutOrStdout()
+	var imported, skipped, failed int
+	for _, p := range parsed {
+		if p.Err != nil {
+			failed++
+			fmt.Fprintf(out, "hand #%s (line %d): %s\n", p.ExternalID, p.Line, p.Err.Error())
+			continue
+		}
+
+		h := p.Hand
+		h.ID = id.NewID()
+		h.Organization = o.ID
+		h.RecordedBy = u.ID
+		if err := handsAdapter.Save(c.Context(), h); err != nil {
+			if errors.Is(err, hands.ErrHandExists) {
+				skipped++
+				continue
+			}
+			c.logger.Fatalf("cannot save hand #%s: %s", p.ExternalID, err.Error())
+		}
+		imported++
+	}
+
+	fmt.Fprintf(out, "imported: %d, duplicates skipped: %d, failed: %d\n", imported, skipped, failed)
+}
@@ -3,24 +3,50 @@ package commands
 import (
 	"pokergo/internal/articles"
 	"pokergo/internal/game"
+	"pokergo/internal/hands"
 	"pokergo/internal/org"
+	"pokergo/internal/stats"
 	"pokergo/internal/users"
 )
 
 func (c *commandApp) mongoIndexes() {
-	usersAdapter := users.NewMongoAdapter(c.mongoColls.Users, c.logger)
+	usersAdapter := users.NewMongoAdapter(c.mongoCollections().Users, c.logger)
 	if err := usersAdapter.EnsureIndexes(c.Context()); err != nil {
 		c.logger.Fatalf("cannot create indexes on users collection: %s", err.Error())
 	}
-	orgAdapter := org.NewMongoAdapter(c.mongoColls.Org, c.timer)
+	revocationAdapter := users.NewMongoRevocationAdapter(c.mongoCollections().RevokedTokens)
+	if err := revocationAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("cannot create indexes on revoked tokens collection: %s", err.Error())
+	}
+	oneTimeTokenAdapter := users.NewMongoOneTimeTokenAdapter(c.mongoCollections().OneTimeTokens)
+	if err := oneTimeTokenAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("cannot create indexes on one-time tokens collection: %s", err.Error())
+	}
+	orgAdapter := org.NewMongoAdapter(c.mongoCollections().Org, c.timer)
 	if err := orgAdapter.EnsureIndexes(c.Context()); err != nil {
 		c.logger.Fatalf("cannot create indexes on organizations collection: %s", err.Error())
 	}
-	gameAdapter := game.NewMongoAdapter(c.mongoColls.Games, c.timer)
+	paymentAdapter := org.NewMongoPaymentAdapter(c.mongoCollections().Payments)
+	if err := paymentAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("cannot create indexes on payments collection: %s", err.Error())
+	}
+	inviteAdapter := org.NewMongoInviteAdapter(c.mongoCollections().Invites, c.mongoCollections().JoinRequests)
+	if err := inviteAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("cannot create indexes on invites and join requests collections: %s", err.Error())
+	}
+	gameAdapter := game.NewMongoAdapter(c.mongoCollections().Games, c.timer)
 	if err := gameAdapter.EnsureIndexes(c.Context()); err != nil {
 		c.logger.Fatalf("cannot create indexes on games collection: %s", err.Error())
 	}
-	artsAdapter := articles.NewMongoAdapter(c.mongoColls.Arts)
+	statsAdapter := stats.NewMongoAdapter(c.mongoCollections().Games)
+	if err := statsAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("cannot create stats indexes on games collection: %s", err.Error())
+	}
+	handsAdapter := hands.NewMongoAdapter(c.mongoCollections().Hands)
+	if err := handsAdapter.EnsureIndexes(c.Context()); err != nil {
+		c.logger.Fatalf("cannot create indexes on hands collection: %s", err.Error())
+	}
+	artsAdapter := articles.NewMongoAdapter(c.mongoCollections().Arts)
 	if err := artsAdapter.EnsureIndexes(c.Context()); err != nil {
 		c.logger.Fatalf("cannot create indexes on articles collection: %s", err.Error())
 	}
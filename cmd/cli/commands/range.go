@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"pokergo/pkg/poker"
+)
+
+// printRange prints the expanded range as a 13x13 grid of starting hands, partially played hands are marked with "*"
+func (c *commandApp) printRange(notation, dead string) {
+	r, err := poker.ParseRange(notation)
+	if err != nil {
+		c.logger.Fatalf("cannot parse range: %s", err.Error())
+	}
+	deadCards, err := poker.ParseCards(dead)
+	if err != nil {
+		c.logger.Fatalf("cannot parse dead cards: %s", err.Error())
+	}
+	r = r.Remove(deadCards...)
+
+	out := c.OutOrStdout()
+	grid := r.Grid()
+	for row := range grid {
+		cells := make([]string, 0, len(grid[row]))
+		for col, fraction := range grid[row] {
+			label := poker.GridLabel(row, col)
+			switch {
+			case fraction == 0:
+				label = "."
+			case fraction < 1-1e-9:
+				label += "*"
+			}
+			cells = append(cells, fmt.Sprintf("%-4s", label))
+		}
+		fmt.Fprintln(out, strings.TrimRight(strings.Join(cells, " "), " "))
+	}
+
+	weighted := r.WeightedCount()
+	fmt.Fprintf(out, "\ncombos: %d, weighted: %.2f (%.2f%% of %d)\n",
+		r.Count(), weighted, 100*weighted/poker.NumCombos, poker.NumCombos)
+}
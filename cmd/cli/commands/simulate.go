@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"pokergo/internal/sim"
+)
+
+// simulate plays hands between bots and prints bb/100 of every strategy with the 95% confidence interval
+func (c *commandApp) simulate(names []string, hands int, seed, stack int64) {
+	bots := make([]sim.Bot, 0, len(names))
+	for _, name := range names {
+		bot, err := sim.NewBot(name)
+		if err != nil {
+			c.logger.Fatalf("cannot create the bot: %s", err.Error())
+		}
+		bots = append(bots, bot)
+	}
+
+	res, err := sim.Run(sim.Config{Bots: bots, Hands: hands, Seed: seed, Stack: stack})
+	if err != nil {
+		c.logger.Fatalf("cannot simulate: %s", err.Error())
+	}
+
+	out := c.OutOrStdout()
+	fmt.Fprintf(out, "hands: %d, seed: %d\n\n", res.Hands, seed)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "strategy\tseats\twon (bb)\tbb/100\t95% CI\t")
+	for _, s := range res.Strategies {
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.2f\t±%.2f\t\n", s.Strategy, s.Seats, s.Won, s.BB100, s.CI95)
+	}
+	_ = w.Flush()
+}
@@ -20,7 +20,8 @@ func (c *commandApp) updateArticles() {
 		arts = append(arts, a...)
 	}
 
-	ids, err := c.artsAdapter.Save(c.Context(), arts)
+	artsAdapter := articles.NewMongoAdapter(c.mongoCollections().Arts)
+	ids, err := artsAdapter.Save(c.Context(), arts)
 	if err != nil {
 		c.logger.Fatalf("save article error: %s", err.Error())
 	}
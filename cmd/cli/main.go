@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"pokergo/cmd/cli/commands"
-	"pokergo/internal/articles"
 	"pokergo/internal/mongo"
 	"pokergo/pkg/env"
 	"pokergo/pkg/logger"
@@ -21,14 +20,13 @@ func main() {
 	mongoUser := env.Env("MONGO_USER", "root")
 	mongoPassword := env.Env("MONGO_PASSWORD", "password123")
 	mongoDB := env.Env("MONGO_DB", "pokergo")
-	mongoCollections, err := mongo.NewMongo(appCtx, mongoURI, mongoAuthDB, mongoUser, mongoPassword, mongoDB)
-	if err != nil {
-		log.Fatalf("cannot init mongo: %s", err.Error())
+	// commands which don't need the database (e.g. simulate, range) work without it
+	connectMongo := func(ctx context.Context) (*mongo.Collections, error) {
+		// nolint:wrapcheck // logged by the command
+		return mongo.NewMongo(ctx, mongoURI, mongoAuthDB, mongoUser, mongoPassword, mongoDB)
 	}
 
-	artsAdapter := articles.NewMongoAdapter(mongoCollections.Arts)
-
-	cmd := commands.NewCommandApp(log, utcTimer, artsAdapter, mongoCollections)
+	cmd := commands.NewCommandApp(log, utcTimer, connectMongo)
 	if err := cmd.ExecuteContext(appCtx); err != nil {
 		log.Fatal(err)
 	}
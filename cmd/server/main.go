@@ -3,19 +3,29 @@ package main
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator"
 	"pokergo/internal/articles"
+	"pokergo/internal/clock"
 	"pokergo/internal/game"
+	"pokergo/internal/hands"
+	"pokergo/internal/mail"
 	"pokergo/internal/mongo"
 	"pokergo/internal/org"
+	"pokergo/internal/stats"
+	"pokergo/internal/table"
 	"pokergo/internal/users"
 	"pokergo/internal/webapi"
 	authMux "pokergo/internal/webapi/auth"
 	gameMux "pokergo/internal/webapi/game"
+	handsMux "pokergo/internal/webapi/hands"
 	newsMux "pokergo/internal/webapi/news"
 	orgMux "pokergo/internal/webapi/org"
+	statsMux "pokergo/internal/webapi/stats"
+	tableMux "pokergo/internal/webapi/table"
+	toolsMux "pokergo/internal/webapi/tools"
 	"pokergo/pkg/env"
 	"pokergo/pkg/jwt"
 	"pokergo/pkg/logger"
@@ -38,32 +48,83 @@ func main() {
 		log.Fatalf("cannot init mongo: %s", err.Error())
 	}
 	usersAdapter := users.NewMongoAdapter(mongoCollections.Users, log)
+	revocationAdapter := users.NewMongoRevocationAdapter(mongoCollections.RevokedTokens)
+	oneTimeTokenAdapter := users.NewMongoOneTimeTokenAdapter(mongoCollections.OneTimeTokens)
 	orgAdapter := org.NewMongoAdapter(mongoCollections.Org, utcTimer)
 	gameAdapter := game.NewMongoAdapter(mongoCollections.Games, utcTimer)
 	artsAdapter := articles.NewMongoAdapter(mongoCollections.Arts)
-	gameManager := game.NewManager(gameAdapter, usersAdapter, orgAdapter)
+	statsAdapter := stats.NewMongoAdapter(mongoCollections.Games)
+	handsAdapter := hands.NewMongoAdapter(mongoCollections.Hands)
+	gameCacheTTL, err := time.ParseDuration(env.Env("GAME_CACHE_TTL", "30m"))
+	if err != nil {
+		log.Fatalf("invalid GAME_CACHE_TTL: %s", err.Error())
+	}
+	gameCacheSize, err := strconv.Atoi(env.Env("GAME_CACHE_SIZE", "1000"))
+	if err != nil {
+		log.Fatalf("invalid GAME_CACHE_SIZE: %s", err.Error())
+	}
+	gameManager := game.NewManager(gameAdapter, usersAdapter, orgAdapter, utcTimer, game.CacheConfig{
+		TTL:     gameCacheTTL,
+		MaxSize: gameCacheSize,
+	})
+	orgManager := org.NewManager(orgAdapter)
+	paymentAdapter := org.NewMongoPaymentAdapter(mongoCollections.Payments)
+	ledgerManager := org.NewLedgerManager(gameManager, paymentAdapter, utcTimer)
+
+	// Mail (MailHog listens on localhost:1025 by default)
+	mailer := mail.NewSMTPMailer(mail.SMTPConfig{
+		Host:     env.Env("SMTP_HOST", "localhost"),
+		Port:     env.Env("SMTP_PORT", "1025"),
+		Username: env.Env("SMTP_USER", ""),
+		Password: env.Env("SMTP_PASSWORD", ""),
+		From:     env.Env("MAIL_FROM", "pokergo@localhost"),
+	})
+	appURL := env.Env("APP_URL", "http://localhost:3000")
+	accountManager := users.NewAccountManager(usersAdapter, oneTimeTokenAdapter, mailer, utcTimer, appURL)
 
 	// Echo
 	jwtSecret := env.Env("JWT_SECRET", "jwt-token-123")
-	jwtInstance := jwt.NewJWT(utcTimer, []byte(jwtSecret), time.Duration(168)*time.Hour)
-	authRouter := authMux.NewMux(usersAdapter, utcTimer, jwtInstance)
-	orgRouter := orgMux.NewMux(orgAdapter, usersAdapter)
-	gameRouter := gameMux.NewMux(gameManager)
+	inviteSecret := env.Env("INVITE_SECRET", jwtSecret)
+	inviteAdapter := org.NewMongoInviteAdapter(mongoCollections.Invites, mongoCollections.JoinRequests)
+	inviteManager := org.NewInviteManager(orgAdapter, inviteAdapter, []byte(inviteSecret), utcTimer)
+	jwtInstance := jwt.NewJWT(utcTimer, []byte(jwtSecret), time.Duration(168)*time.Hour, time.Duration(720)*time.Hour)
+	authRouter := authMux.NewMux(usersAdapter, revocationAdapter, accountManager, utcTimer, jwtInstance)
+	orgRouter := orgMux.NewMux(orgAdapter, orgManager, inviteManager, usersAdapter, ledgerManager)
+	clockManager := clock.NewManager(utcTimer)
+	gameRouter := gameMux.NewMux(gameManager, clockManager)
 	newsRouter := newsMux.NewMux(artsAdapter)
+	statsRouter := statsMux.NewMux(statsAdapter, orgAdapter)
+	toolsRouter := toolsMux.NewMux()
+	tableManager := table.NewManager(utcTimer)
+	go tableManager.Run(appCtx, time.Second)
+	tableRouter := tableMux.NewMux(tableManager, orgAdapter)
+	handsRouter := handsMux.NewMux(handsAdapter, orgAdapter, gameManager, utcTimer)
 
 	isDebug := env.Env("DEBUG", "true")
 	validate := validator.New()
 	e := webapi.NewEcho(
 		validate,
 		jwtInstance,
+		revocationAdapter,
 		webapi.EchoRouters{
-			AuthRouter: authRouter,
-			OrgRouter:  orgRouter,
-			GameRouter: gameRouter,
-			NewsRouter: newsRouter,
+			AuthRouter:  authRouter,
+			OrgRouter:   orgRouter,
+			GameRouter:  gameRouter,
+			NewsRouter:  newsRouter,
+			StatsRouter: statsRouter,
+			ToolsRouter: toolsRouter,
+			TableRouter: tableRouter,
+			HandsRouter: handsRouter,
 		},
 		log,
 		isDebug == "true")
+	// the internal server listens on localhost by default, it has no authentication
+	internalAddr := env.Env("INTERNAL_ADDR", "127.0.0.1:8081")
+	internal := webapi.NewInternalEcho(gameRouter, log)
+	go func() {
+		log.Fatal(internal.Start(internalAddr))
+	}()
+
 	// Start server
 	port := env.Env("APP_PORT", "8080")
 	log.Fatal(e.Start(fmt.Sprintf(":%s", port)))
@@ -0,0 +1,225 @@
+package clock
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"pokergo/pkg/timer"
+)
+
+var (
+	ErrNoLevels       = errors.New("the clock needs at least one level")
+	ErrInvalidLevel   = errors.New("invalid level")
+	ErrPaused         = errors.New("the clock is paused")
+	ErrRunning        = errors.New("the clock is running")
+	ErrLastLevel      = errors.New("the clock is at the last level")
+	ErrClockNotExists = errors.New("the clock does not exist")
+)
+
+// Level is a blind level (or a break) of the tournament
+type Level struct {
+	SmallBlind int64 `json:"small_blind"`
+	BigBlind   int64 `json:"big_blind"`
+	Ante       int64 `json:"ante"`
+	// Duration is the time the level lasts
+	Duration time.Duration `json:"-"`
+	// Break is a pause between levels, blinds are not played
+	Break bool `json:"break"`
+}
+
+// validate checks if the level can be played
+func (l Level) validate() error {
+	if l.Duration <= 0 {
+		return fmt.Errorf("%w: duration must be positive", ErrInvalidLevel)
+	}
+	if l.Break {
+		return nil
+	}
+	if l.SmallBlind <= 0 || l.BigBlind < l.SmallBlind || l.Ante < 0 {
+		return fmt.Errorf("%w: blinds must be positive (small <= big) and ante cannot be negative", ErrInvalidLevel)
+	}
+	return nil
+}
+
+// State is a snapshot of the clock pushed to clients
+type State struct {
+	// Level is the number of the current level (starts from 1)
+	Level   int   `json:"level"`
+	Current Level `json:"current"`
+	// Next is the next level, nil if the current level is the last one
+	Next *Level `json:"next,omitempty"`
+	// Remaining is the time left in the current level in milliseconds
+	Remaining int64 `json:"remaining_ms"`
+	Paused    bool  `json:"paused"`
+	// Finished is true if the last level is over (the clock stays at the last level)
+	Finished bool      `json:"finished"`
+	At       time.Time `json:"at"`
+}
+
+// Clock counts down blind levels, the time is taken from timer.Timer (no goroutines are needed),
+// the state is computed on every State call.
+type Clock struct {
+	mux   sync.Mutex
+	timer timer.Timer
+
+	levels []Level
+	level  int
+	// elapsed is the time spent in the current level until resumedAt
+	elapsed time.Duration
+	// resumedAt is the time the clock was resumed, nil if the clock is paused
+	resumedAt *time.Time
+
+	subscribers map[chan struct{}]struct{}
+	// stopped is true if the clock has been stopped or replaced (subscribers are closed)
+	stopped bool
+}
+
+// New creates a running clock starting at the first level
+func New(tm timer.Timer, levels []Level) (*Clock, error) {
+	if len(levels) == 0 {
+		return nil, ErrNoLevels
+	}
+	for i, l := range levels {
+		if err := l.validate(); err != nil {
+			return nil, fmt.Errorf("level %d: %w", i+1, err)
+		}
+	}
+
+	now := tm.Now()
+	return &Clock{
+		timer:       tm,
+		levels:      append([]Level{}, levels...),
+		resumedAt:   &now,
+		subscribers: make(map[chan struct{}]struct{}),
+	}, nil
+}
+
+// State returns the current state of the clock
+func (c *Clock) State() State {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := c.timer.Now()
+	c.advance(now)
+
+	current := c.levels[c.level]
+	s := State{
+		Level:     c.level + 1,
+		Current:   current,
+		Remaining: (current.Duration - c.elapsed).Milliseconds(),
+		Paused:    c.resumedAt == nil,
+		At:        now,
+	}
+	if c.level+1 < len(c.levels) {
+		next := c.levels[c.level+1]
+		s.Next = &next
+	}
+	if s.Remaining <= 0 {
+		s.Remaining = 0
+		s.Finished = true
+	}
+
+	return s
+}
+
+// Pause stops the countdown
+func (c *Clock) Pause() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.resumedAt == nil {
+		return ErrPaused
+	}
+	c.advance(c.timer.Now())
+	c.resumedAt = nil
+
+	c.notify()
+	return nil
+}
+
+// Resume continues the countdown
+func (c *Clock) Resume() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.resumedAt != nil {
+		return ErrRunning
+	}
+	now := c.timer.Now()
+	c.resumedAt = &now
+
+	c.notify()
+	return nil
+}
+
+// Skip moves the clock to the beginning of the next level
+func (c *Clock) Skip() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := c.timer.Now()
+	c.advance(now)
+	if c.level+1 >= len(c.levels) {
+		return ErrLastLevel
+	}
+	c.level++
+	c.elapsed = 0
+
+	c.notify()
+	return nil
+}
+
+// Subscribe returns a channel signalled when the clock is controlled (paused, resumed, skipped).
+// Level changes caused by the time passing are not signalled, State should be polled for them.
+// The channel is closed when the clock is stopped or replaced. The returned function unsubscribes.
+func (c *Clock) Subscribe() (<-chan struct{}, func()) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if c.stopped {
+		close(ch)
+		return ch, func() {}
+	}
+	c.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		c.mux.Lock()
+		defer c.mux.Unlock()
+
+		delete(c.subscribers, ch)
+	}
+}
+
+// notify signals subscribers without blocking (must be called with mux locked)
+func (c *Clock) notify() {
+	for ch := range c.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default: // the subscriber has not consumed the previous signal yet
+		}
+	}
+}
+
+// stop closes channels of subscribers (must be called with mux locked)
+func (c *Clock) stop() {
+	for ch := range c.subscribers {
+		close(ch)
+		delete(c.subscribers, ch)
+	}
+	c.stopped = true
+}
+
+// advance moves the running clock to now, passing finished levels (must be called with mux locked)
+func (c *Clock) advance(now time.Time) {
+	if c.resumedAt != nil {
+		c.elapsed += now.Sub(*c.resumedAt)
+		c.resumedAt = &now
+	}
+	for c.level+1 < len(c.levels) && c.elapsed >= c.levels[c.level].Duration {
+		c.elapsed -= c.levels[c.level].Duration
+		c.level++
+	}
+}
@@ -0,0 +1,161 @@
+package clock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+func testLevels() []Level {
+	return []Level{
+		{SmallBlind: 10, BigBlind: 20, Duration: 20 * time.Minute},
+		{SmallBlind: 20, BigBlind: 40, Ante: 5, Duration: 20 * time.Minute},
+		{Break: true, Duration: 10 * time.Minute},
+		{SmallBlind: 50, BigBlind: 100, Ante: 10, Duration: 15 * time.Minute},
+	}
+}
+
+func Test_Clock_Countdown(t *testing.T) {
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	c, err := New(tm, testLevels())
+	if err != nil {
+		t.Fatalf("cannot create the clock: %s", err)
+	}
+
+	tm.Advance(5 * time.Minute)
+	s := c.State()
+	if s.Level != 1 || s.Remaining != (15*time.Minute).Milliseconds() || s.Next.BigBlind != 40 {
+		t.Fatalf("invalid state: %+v", s)
+	}
+
+	// levels are passed on their own, also several at once
+	tm.Advance(40 * time.Minute)
+	s = c.State()
+	if s.Level != 3 || !s.Current.Break || s.Remaining != (5*time.Minute).Milliseconds() {
+		t.Fatalf("the clock should be in the break, state: %+v", s)
+	}
+
+	tm.Advance(time.Hour)
+	s = c.State()
+	if s.Level != 4 || !s.Finished || s.Remaining != 0 || s.Next != nil {
+		t.Fatalf("the clock should stay at the finished last level, state: %+v", s)
+	}
+}
+
+func Test_Clock_Controls(t *testing.T) {
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	c, _ := New(tm, testLevels())
+	changes, unsubscribe := c.Subscribe()
+	defer unsubscribe()
+
+	tm.Advance(5 * time.Minute)
+	if err := c.Pause(); err != nil {
+		t.Fatalf("cannot pause: %s", err)
+	}
+	if err := c.Pause(); !errors.Is(err, ErrPaused) {
+		t.Fatalf("paused clock cannot be paused, err: %v", err)
+	}
+	select {
+	case <-changes:
+	default:
+		t.Fatalf("subscribers should be notified")
+	}
+
+	tm.Advance(time.Hour)
+	if s := c.State(); !s.Paused || s.Level != 1 || s.Remaining != (15*time.Minute).Milliseconds() {
+		t.Fatalf("paused clock should not count down, state: %+v", s)
+	}
+
+	if err := c.Resume(); err != nil {
+		t.Fatalf("cannot resume: %s", err)
+	}
+	tm.Advance(10 * time.Minute)
+	if s := c.State(); s.Paused || s.Remaining != (5*time.Minute).Milliseconds() {
+		t.Fatalf("resumed clock should count down, state: %+v", s)
+	}
+
+	if err := c.Skip(); err != nil {
+		t.Fatalf("cannot skip: %s", err)
+	}
+	if s := c.State(); s.Level != 2 || s.Remaining != (20*time.Minute).Milliseconds() {
+		t.Fatalf("the next level should start from the beginning, state: %+v", s)
+	}
+
+	_ = c.Skip()
+	_ = c.Skip()
+	if err := c.Skip(); !errors.Is(err, ErrLastLevel) {
+		t.Fatalf("the last level cannot be skipped, err: %v", err)
+	}
+}
+
+func Test_Clock_InvalidLevels(t *testing.T) {
+	tm := timer.NewUTCTimer()
+	if _, err := New(tm, nil); !errors.Is(err, ErrNoLevels) {
+		t.Fatalf("levels are required, err: %v", err)
+	}
+	if _, err := New(tm, []Level{{SmallBlind: 20, BigBlind: 10, Duration: time.Minute}}); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("small blind cannot be bigger than big blind, err: %v", err)
+	}
+	if _, err := New(tm, []Level{{Break: true}}); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("levels must last, err: %v", err)
+	}
+}
+
+func Test_Manager_Replace(t *testing.T) {
+	m := NewManager(timer.NewUTCTimer())
+	gameID := id.NewID()
+
+	if _, err := m.Get(gameID); !errors.Is(err, ErrClockNotExists) {
+		t.Fatalf("the clock should not exist, err: %v", err)
+	}
+
+	first, _ := m.Start(gameID, testLevels())
+	changes, unsubscribe := first.Subscribe()
+	defer unsubscribe()
+
+	second, _ := m.Start(gameID, testLevels())
+	if c, _ := m.Get(gameID); c != second {
+		t.Fatalf("the clock should be replaced")
+	}
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatalf("subscribers of the replaced clock should be closed")
+		}
+	default:
+		t.Fatalf("subscribers of the replaced clock should be notified")
+	}
+
+	m.Stop(gameID)
+	if _, err := m.Get(gameID); !errors.Is(err, ErrClockNotExists) {
+		t.Fatalf("the clock should be stopped, err: %v", err)
+	}
+}
+
+func Test_Manager_StopClosesSubscribers(t *testing.T) {
+	m := NewManager(timer.NewUTCTimer())
+	gameID := id.NewID()
+
+	c, _ := m.Start(gameID, testLevels())
+	changes, unsubscribe := c.Subscribe()
+	defer unsubscribe()
+
+	m.Stop(gameID)
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatalf("the subscriber should be closed, not signalled")
+		}
+	default:
+		t.Fatalf("the subscriber should be closed")
+	}
+
+	late, unsubscribeLate := c.Subscribe()
+	defer unsubscribeLate()
+	if _, ok := <-late; ok {
+		t.Fatalf("subscribing to a stopped clock should return a closed channel")
+	}
+}
@@ -0,0 +1,77 @@
+package clock
+
+import (
+	"sync"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+// Manager keeps clocks of running tournaments (in memory, clocks are not persisted)
+type Manager interface {
+	// Start creates a running clock of the game, the previous clock of the game is replaced
+	Start(gameID id.ID, levels []Level) (*Clock, error)
+	// Get returns the clock of the game or ErrClockNotExists
+	Get(gameID id.ID) (*Clock, error)
+	// Stop removes the clock of the game, subscribers of the clock are closed
+	Stop(gameID id.ID)
+}
+
+type manager struct {
+	timer timer.Timer
+
+	clocksMux sync.Mutex
+	clocks    map[id.ID]*Clock
+}
+
+func NewManager(timer timer.Timer) *manager {
+	return &manager{
+		timer:  timer,
+		clocks: make(map[id.ID]*Clock),
+	}
+}
+
+func (m *manager) Start(gameID id.ID, levels []Level) (*Clock, error) {
+	c, err := New(m.timer, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	m.clocksMux.Lock()
+	defer m.clocksMux.Unlock()
+
+	if previous, ok := m.clocks[gameID]; ok {
+		// let clients of the previous clock know it has been replaced
+		previous.mux.Lock()
+		previous.stop()
+		previous.mux.Unlock()
+	}
+	m.clocks[gameID] = c
+
+	return c, nil
+}
+
+func (m *manager) Get(gameID id.ID) (*Clock, error) {
+	m.clocksMux.Lock()
+	defer m.clocksMux.Unlock()
+
+	c, ok := m.clocks[gameID]
+	if !ok {
+		return nil, ErrClockNotExists
+	}
+	return c, nil
+}
+
+func (m *manager) Stop(gameID id.ID) {
+	m.clocksMux.Lock()
+	defer m.clocksMux.Unlock()
+
+	if c, ok := m.clocks[gameID]; ok {
+		c.mux.Lock()
+		c.stop()
+		c.mux.Unlock()
+	}
+	delete(m.clocks, gameID)
+}
+
+var _ Manager = (*manager)(nil)
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"fmt"
+
+	"pokergo/internal/org"
+	"pokergo/pkg/id"
+)
+
+// Role is a role of the user in the game
+type Role string
+
+const (
+	// RoleOrganizer can do everything (the game creator and members who manage games of the organization are organizers)
+	RoleOrganizer Role = "organizer"
+	// RoleBanker handles money after the game (can mark the game as settled)
+	RoleBanker Role = "banker"
+	// RolePlayer is a member of the organization who plays the game
+	RolePlayer Role = "player"
+	// RoleViewer is any other member of the organization
+	RoleViewer Role = "viewer"
+)
+
+// Action is an operation performed on the game which requires permissions
+type Action string
+
+const (
+	// ActionView allows reading the game, its history and the settlement
+	ActionView Action = "view"
+	// ActionSettle allows marking the game as settled
+	ActionSettle Action = "settle"
+	// ActionModify allows changing players, their stacks and the game state
+	ActionModify Action = "modify"
+	// ActionManageRoles allows assigning roles to users
+	ActionManageRoles Action = "manage_roles"
+)
+
+// permissions lists actions allowed for a role
+var permissions = map[Role][]Action{ // nolint:gochecknoglobals // cannot be const
+	RoleOrganizer: {ActionView, ActionSettle, ActionModify, ActionManageRoles},
+	RoleBanker:    {ActionView, ActionSettle},
+	RolePlayer:    {ActionView},
+	RoleViewer:    {ActionView},
+}
+
+// IsValid tells if the role exists
+func (r Role) IsValid() bool {
+	_, ok := permissions[r]
+	return ok
+}
+
+// Can tells if the role allows performing the action
+func (r Role) Can(action Action) bool {
+	for _, a := range permissions[r] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAssignment is a role explicitly given to the user by an organizer
+type RoleAssignment struct {
+	UserID id.ID `json:"user_id" bson:"user_id"`
+	Role   Role  `json:"role" bson:"role"`
+}
+
+// roleOf returns the role of the user in the game, only members of the organization have roles
+// (must be called with playerMux locked)
+func (g *Game) roleOf(o org.Org, uID id.ID) (Role, bool) {
+	if o.ID != g.Organization || !o.IsMember(uID) {
+		return "", false
+	}
+	if uID == g.Organizer || o.Can(uID, org.PermManageGames) {
+		return RoleOrganizer, true
+	}
+	for _, r := range g.Roles {
+		if r.UserID == uID {
+			return r.Role, true
+		}
+	}
+	for _, p := range g.Players {
+		if p.UserID != nil && *p.UserID == uID {
+			return RolePlayer, true
+		}
+	}
+	return RoleViewer, true
+}
+
+// authorize returns ErrInsufficientPermissions if the user cannot perform the action on the game
+func (g *Game) authorize(o org.Org, uID id.ID, action Action) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	role, ok := g.roleOf(o, uID)
+	if !ok {
+		return fmt.Errorf("%w: not a member of the organization", ErrInsufficientPermissions)
+	}
+	if !role.Can(action) {
+		return fmt.Errorf("%w: %s cannot %s the game", ErrInsufficientPermissions, role, action)
+	}
+
+	return nil
+}
+
+// SetRole assigns a role to the user, the user must be a member of the organization
+func (g *Game) SetRole(by id.ID, o org.Org, uID id.ID, role Role) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if !role.IsValid() {
+		return fmt.Errorf("%w: %s", ErrInvalidRole, role)
+	}
+	if o.ID != g.Organization || !o.IsMember(uID) {
+		return ErrUserNotFound
+	}
+	if uID == g.Organizer {
+		return fmt.Errorf("%w: the role of the game creator cannot be changed", ErrInvalidRole)
+	}
+
+	return g.record(by, Event{Type: EventRoleChanged, UserID: &uID, Role: role})
+}
+
+// setRole replaces the role of the user (must be called with playerMux locked)
+func (g *Game) setRole(uID id.ID, role Role) {
+	for i := range g.Roles {
+		if g.Roles[i].UserID == uID {
+			g.Roles[i].Role = role
+			return
+		}
+	}
+	g.Roles = append(g.Roles, RoleAssignment{UserID: uID, Role: role})
+}
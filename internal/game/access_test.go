@@ -0,0 +1,137 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pokergo/internal/org"
+	"pokergo/pkg/id"
+)
+
+func Test_Manager_GetGame_Permissions(t *testing.T) {
+	var (
+		organizer    = id.NewID()
+		orgAdmin     = id.NewID()
+		orgOrganizer = id.NewID()
+		banker       = id.NewID()
+		player       = id.NewID()
+		viewer       = id.NewID()
+		outsider     = id.NewID()
+		expelled     = id.NewID() // has a role, but was removed from the organization
+	)
+
+	type tc struct {
+		name    string
+		caller  id.ID
+		action  Action
+		allowed bool
+	}
+
+	tcs := []tc{
+		{"organizer views", organizer, ActionView, true},
+		{"organizer modifies", organizer, ActionModify, true},
+		{"organizer manages roles", organizer, ActionManageRoles, true},
+		{"org admin modifies", orgAdmin, ActionModify, true},
+		{"org organizer manages roles", orgOrganizer, ActionManageRoles, true},
+		{"banker views", banker, ActionView, true},
+		{"banker settles", banker, ActionSettle, true},
+		{"banker cannot modify", banker, ActionModify, false},
+		{"banker cannot manage roles", banker, ActionManageRoles, false},
+		{"player views", player, ActionView, true},
+		{"player cannot modify", player, ActionModify, false},
+		{"player cannot settle", player, ActionSettle, false},
+		{"viewer views", viewer, ActionView, true},
+		{"viewer cannot modify", viewer, ActionModify, false},
+		{"outsider cannot view", outsider, ActionView, false},
+		{"outsider cannot modify", outsider, ActionModify, false},
+		{"expelled banker cannot view", expelled, ActionView, false},
+		{"expelled banker cannot settle", expelled, ActionSettle, false},
+	}
+
+	ctx := context.Background()
+	env := newTestEnv()
+	o := org.Org{
+		ID:      id.NewID(),
+		Name:    "permissions",
+		Owner:   id.NewID(),
+		Members: []id.ID{orgAdmin, orgOrganizer, organizer, banker, player, viewer},
+		Roles:   []org.MemberRole{{UserID: orgAdmin, Role: org.RoleAdmin}, {UserID: orgOrganizer, Role: org.RoleOrganizer}},
+	}
+	env.orgAdapter.orgs[o.ID] = o
+
+	d, _ := env.gameAdapter.NewGame(ctx, organizer, o.ID, org.Settings{})
+	d.Players = []Player{{UserID: &player, UserName: "player", BuyIn: 100}}
+	d.Roles = []RoleAssignment{{UserID: banker, Role: RoleBanker}, {UserID: expelled, Role: RoleBanker}}
+	env.gameAdapter.games[d.ID] = d
+
+	paths := map[string]func() *manager{
+		"uncached": env.newManager,
+		"cached": func() *manager {
+			m := env.newManager()
+			if _, err := m.GetGame(ctx, organizer, d.ID, ActionView); err != nil {
+				t.Fatalf("cannot warm up the cache: %s", err)
+			}
+			return m
+		},
+	}
+
+	for path, newManager := range paths {
+		for _, test := range tcs {
+			t.Run(path+"/"+test.name, func(t *testing.T) {
+				m := newManager()
+				hits := m.CacheStats().Hits
+
+				g, err := m.GetGame(ctx, test.caller, d.ID, test.action)
+				if test.allowed && err != nil {
+					t.Fatalf("access should be granted, got: %s", err)
+				}
+				if !test.allowed && (!errors.Is(err, ErrInsufficientPermissions) || g != nil) {
+					t.Fatalf("access should be denied, got: %v", err)
+				}
+
+				if wasCached := m.CacheStats().Hits > hits; wasCached != (path == "cached") {
+					t.Fatalf("the game should be obtained by %s path", path)
+				}
+				if path == "uncached" && m.games.contains(d.ID) != test.allowed {
+					t.Fatalf("the game should be cached only if the access is granted")
+				}
+			})
+		}
+	}
+}
+
+func Test_Manager_SetRole(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	member := id.NewID()
+	_ = env.orgAdapter.AddToOrg(ctx, env.org.ID, member)
+
+	m := env.newManager()
+	g, err := m.CreateGame(ctx, testUser, env.org.Name)
+	if err != nil {
+		t.Fatalf("cannot create game: %s", err)
+	}
+
+	if _, err := m.GetGame(ctx, member, g.ID, ActionSettle); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Fatalf("viewer cannot settle, err: %v", err)
+	}
+	if err := m.SetRole(ctx, member, g.ID, member, RoleOrganizer); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Fatalf("viewer cannot give roles, err: %v", err)
+	}
+	if err := m.SetRole(ctx, testUser, g.ID, id.NewID(), RoleBanker); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("only members can get roles, err: %v", err)
+	}
+
+	if err := m.SetRole(ctx, testUser, g.ID, member, RoleBanker); err != nil {
+		t.Fatalf("cannot set role: %s", err)
+	}
+	if _, err := m.GetGame(ctx, member, g.ID, ActionSettle); err != nil {
+		t.Fatalf("banker should settle, err: %v", err)
+	}
+
+	// the role survives reloading the game
+	if _, err := env.newManager().GetGame(ctx, member, g.ID, ActionSettle); err != nil {
+		t.Fatalf("banker should settle, err: %v", err)
+	}
+}
@@ -4,21 +4,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"pokergo/internal/org"
 	"pokergo/pkg/id"
+	"pokergo/pkg/pointers"
 	"pokergo/pkg/timer"
 )
 
 // Adapter allows creating and updating games (persistent part)
 type Adapter interface {
-	// NewGame create a new game (organizer must exist) and returns the created object
-	NewGame(ctx context.Context, orgID, uID id.ID) (Data, error)
-	// Update updates game model in database (this is quite inefficient because the whole document is replaced)
-	Update(ctx context.Context, updated Data) error
+	// NewGame create a new cash game (organizer must exist) with settings of the organization
+	// and returns the created object
+	NewGame(ctx context.Context, uID, orgID id.ID, settings org.Settings) (Data, error)
+	// NewTournament creates a new tournament with the given configuration and returns the created object
+	NewTournament(ctx context.Context, uID, orgID id.ID, tournament Tournament) (Data, error)
+	// Update updates the game state in database and appends new events (events are never replaced).
+	// The game is updated only if its version in database is updated.Version (returns ErrVersionConflict otherwise),
+	// the version in database is increased.
+	Update(ctx context.Context, updated Data, newEvents []Event) error
 	// FindGameByID looks for a game by id
 	FindGameByID(ctx context.Context, uID id.ID) (Data, error)
+	// ListGames returns no games matching the filter, newest first, starting after lastDocID (events are not loaded)
+	ListGames(ctx context.Context, filter ListFilter, lastDocID id.ID, no int) ([]Data, error)
+}
+
+// ListFilter narrows the list of games, empty fields are ignored
+type ListFilter struct {
+	// Organizations the game must belong to one of them (required)
+	Organizations []id.ID
+	// From and To limit the game start time to [From, To)
+	From *time.Time
+	To   *time.Time
+	// Status is the current state of the game
+	Status *Status
+	// PlayerName is a name of the player who played the game
+	PlayerName string
+	// WithEvents loads events of games too (they are left out by default)
+	WithEvents bool
 }
 
 type mongoAdapter struct {
@@ -33,17 +59,50 @@ func NewMongoAdapter(
 	return &mongoAdapter{coll: coll, timer: timer}
 }
 
-func (m *mongoAdapter) EnsureIndexes(_ context.Context) error {
-	return nil // no indexes required (except the default one)
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	// ListGames sorts by _id, the sort key goes before range filters (the start time),
+	// so listing pages does not need sorting in memory
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "organization", Value: 1},
+				{Key: "_id", Value: -1},
+				{Key: "start", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "organization", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "_id", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "players.user_name", Value: 1},
+				{Key: "_id", Value: -1},
+			},
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("cannot create games indexes: %w", err)
+	}
+
+	return nil
 }
 
-func (m *mongoAdapter) NewGame(ctx context.Context, orgID, uID id.ID) (Data, error) {
+func (m *mongoAdapter) NewGame(ctx context.Context, uID, orgID id.ID, settings org.Settings) (Data, error) {
 	gameData := Data{
 		ID:           id.NewID(),
-		Organizer:    orgID,
-		Organization: uID,
+		Organizer:    uID,
+		Organization: orgID,
 		Start:        m.timer.Now(),
+		Settings:     &settings,
+		Status:       StatusOpen,
 		Players:      nil,
+		Events:       []Event{},
 	}
 
 	_, err := m.coll.InsertOne(ctx, gameData)
@@ -54,15 +113,66 @@ func (m *mongoAdapter) NewGame(ctx context.Context, orgID, uID id.ID) (Data, err
 	return gameData, nil
 }
 
-func (m *mongoAdapter) Update(ctx context.Context, updated Data) error {
+func (m *mongoAdapter) NewTournament(ctx context.Context, uID, orgID id.ID, tournament Tournament) (Data, error) {
+	gameData := Data{
+		ID:           id.NewID(),
+		Organizer:    uID,
+		Organization: orgID,
+		Start:        m.timer.Now(),
+		Type:         TypeTournament,
+		Tournament:   &tournament,
+		Status:       StatusOpen,
+		Players:      nil,
+		Events:       []Event{},
+	}
+
+	_, err := m.coll.InsertOne(ctx, gameData)
+	if err != nil {
+		return Data{}, fmt.Errorf("cannot create a new tournament in mongo: %w", err)
+	}
+
+	return gameData, nil
+}
+
+func (m *mongoAdapter) Update(ctx context.Context, updated Data, newEvents []Event) error {
 	filter := bson.M{
-		"_id": updated.ID,
+		"_id":     updated.ID,
+		"version": updated.Version,
+	}
+	if updated.Version == 0 {
+		// games created before versioning have no version field
+		filter["version"] = bson.M{
+			"$in": bson.A{0, nil},
+		}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"players":  updated.Players,
+			"expenses": updated.Expenses,
+			"rake":     updated.Rake,
+			"roles":    updated.Roles,
+			"status":   updated.Status,
+			"end":      updated.End,
+		},
+		"$inc": bson.M{
+			"version": 1,
+		},
+	}
+	if len(newEvents) > 0 {
+		update["$push"] = bson.M{
+			"events": bson.M{
+				"$each": newEvents,
+			},
+		}
 	}
 
-	_, err := m.coll.ReplaceOne(ctx, filter, updated)
+	res, err := m.coll.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return fmt.Errorf("cannot update game: %w", err)
 	}
+	if res.MatchedCount == 0 {
+		return ErrVersionConflict
+	}
 
 	return nil
 }
@@ -89,3 +199,58 @@ func (m *mongoAdapter) FindGameByID(ctx context.Context, uID id.ID) (Data, error
 }
 
 var _ Adapter = (*mongoAdapter)(nil)
+
+func (m *mongoAdapter) ListGames(ctx context.Context, filter ListFilter, lastDocID id.ID, no int) ([]Data, error) {
+	opts := &options.FindOptions{
+		Limit: pointers.Pointer(int64(no)),
+		Sort:  bson.M{"_id": -1},
+	}
+	if !filter.WithEvents {
+		opts.Projection = bson.M{"events": 0}
+	}
+
+	query := bson.M{
+		"organization": bson.M{
+			"$in": filter.Organizations,
+		},
+	}
+	if !lastDocID.IsZero() {
+		query["_id"] = bson.M{
+			"$lt": lastDocID,
+		}
+	}
+	start := bson.M{}
+	if filter.From != nil {
+		start["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		start["$lt"] = *filter.To
+	}
+	if len(start) > 0 {
+		query["start"] = start
+	}
+	if filter.Status != nil {
+		query["status"] = *filter.Status
+		if *filter.Status == StatusOpen {
+			// games created before the lifecycle was introduced have no status
+			query["status"] = bson.M{
+				"$in": bson.A{StatusOpen, nil},
+			}
+		}
+	}
+	if filter.PlayerName != "" {
+		query["players.user_name"] = filter.PlayerName
+	}
+
+	cur, err := m.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get games: %w", err)
+	}
+
+	var games []Data
+	if err := cur.All(ctx, &games); err != nil {
+		return nil, fmt.Errorf("cannot bind games: %w", err)
+	}
+
+	return games, nil
+}
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"container/list"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+// CacheConfig configures the games cache of the Manager
+type CacheConfig struct {
+	// TTL is how long a game is kept in the cache since the last access (0 means forever)
+	TTL time.Duration
+	// MaxSize is the maximal number of cached games, the least recently used game is evicted first
+	// (0 means no limit)
+	MaxSize int
+}
+
+// CacheStats are the games cache metrics
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Size      int   `json:"size"`
+}
+
+// HitRate returns the ratio of cache hits to all lookups
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cacheEntry struct {
+	game     *Game
+	lastUsed time.Time
+}
+
+// gameCache is a TTL and LRU cache of games, it's not thread-safe.
+// Games with changes not saved in the persistent storage are not evicted, they are returned
+// to be flushed by the caller (without holding its lock) and removed afterwards.
+type gameCache struct {
+	config CacheConfig
+	timer  timer.Timer
+
+	entries map[id.ID]*list.Element
+	lru     *list.List // the front is the most recently used
+	stats   CacheStats
+}
+
+func newGameCache(config CacheConfig, timer timer.Timer) *gameCache {
+	return &gameCache{
+		config:  config,
+		timer:   timer,
+		entries: make(map[id.ID]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns a cached game, expired games are evicted (expired games with unsaved changes are returned,
+// not to lose the changes by loading the game again)
+func (c *gameCache) get(gID id.ID) (*Game, bool) {
+	elem, ok := c.entries[gID]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry) // nolint:forcetypeassert // only entries are stored
+	now := c.timer.Now()
+	if c.expired(entry, now) && !entry.game.isDirty() {
+		c.stats.Misses++
+		c.evict(elem)
+		return nil, false
+	}
+
+	c.stats.Hits++
+	entry.lastUsed = now
+	c.lru.MoveToFront(elem)
+	return entry.game, true
+}
+
+// contains tells if the game is cached (doesn't change stats and the order)
+func (c *gameCache) contains(gID id.ID) bool {
+	_, ok := c.entries[gID]
+	return ok
+}
+
+// put adds a game to the cache and evicts expired and the least recently used games.
+// Games to evict with unsaved changes are returned, they should be flushed and removed.
+func (c *gameCache) put(g *Game) []*Game {
+	now := c.timer.Now()
+	if elem, ok := c.entries[g.ID]; ok {
+		elem.Value = &cacheEntry{game: g, lastUsed: now}
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[g.ID] = c.lru.PushFront(&cacheEntry{game: g, lastUsed: now})
+	}
+
+	// the back of the list is the least recently used (and the oldest) entry
+	var dirty []*Game
+	for elem := c.lru.Back(); elem != nil && elem != c.lru.Front(); {
+		prev := elem.Prev()
+		entry := elem.Value.(*cacheEntry) // nolint:forcetypeassert // only entries are stored
+		if !c.evictable(entry, now, len(dirty)) {
+			break
+		}
+		if entry.game.isDirty() {
+			dirty = append(dirty, entry.game)
+		} else {
+			c.evict(elem)
+		}
+		elem = prev
+	}
+	return dirty
+}
+
+// remove evicts the flushed game returned by put, unless it has been used or changed again
+func (c *gameCache) remove(g *Game) {
+	elem, ok := c.entries[g.ID]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*cacheEntry) // nolint:forcetypeassert // only entries are stored
+	if entry.game != g || g.isDirty() || !c.evictable(entry, c.timer.Now(), 0) {
+		return
+	}
+	c.evict(elem)
+}
+
+// forget removes the game from the cache even if it has unsaved changes (its state cannot be trusted)
+func (c *gameCache) forget(g *Game) {
+	elem, ok := c.entries[g.ID]
+	if !ok || elem.Value.(*cacheEntry).game != g { // nolint:forcetypeassert // only entries are stored
+		return
+	}
+	c.evict(elem)
+}
+
+// Stats returns current cache metrics
+func (c *gameCache) Stats() CacheStats {
+	s := c.stats
+	s.Size = c.lru.Len()
+	return s
+}
+
+func (c *gameCache) expired(entry *cacheEntry, now time.Time) bool {
+	return c.config.TTL > 0 && now.Sub(entry.lastUsed) > c.config.TTL
+}
+
+// evictable tells if the entry is expired or the cache is too big (pending is the number of entries
+// about to be evicted)
+func (c *gameCache) evictable(entry *cacheEntry, now time.Time, pending int) bool {
+	return c.expired(entry, now) || (c.config.MaxSize > 0 && c.lru.Len()-pending > c.config.MaxSize)
+}
+
+// evict removes the game from the cache
+func (c *gameCache) evict(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry) // nolint:forcetypeassert // only entries are stored
+	c.lru.Remove(elem)
+	delete(c.entries, entry.game.ID)
+	c.stats.Evictions++
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+func newCacheTestGame(tm timer.Timer) *Game {
+	return newGame(Data{ID: id.NewID(), Status: StatusOpen}, nil, tm)
+}
+
+func Test_GameCache_TTL(t *testing.T) {
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	cache := newGameCache(CacheConfig{TTL: time.Minute, MaxSize: 10}, tm)
+
+	g := newCacheTestGame(tm)
+	cache.put(g)
+
+	tm.Advance(50 * time.Second)
+	if _, ok := cache.get(g.ID); !ok {
+		t.Fatalf("the game should be cached")
+	}
+
+	// the last access refreshes the entry
+	tm.Advance(50 * time.Second)
+	if _, ok := cache.get(g.ID); !ok {
+		t.Fatalf("the game should be still cached")
+	}
+
+	tm.Advance(61 * time.Second)
+	if _, ok := cache.get(g.ID); ok {
+		t.Fatalf("the game should be expired")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 || stats.Size != 0 {
+		t.Fatalf("invalid stats: %+v", stats)
+	}
+	if rate := stats.HitRate(); rate < 0.66 || rate > 0.67 {
+		t.Fatalf("invalid hit rate: %f", rate)
+	}
+}
+
+func Test_GameCache_LRU(t *testing.T) {
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	cache := newGameCache(CacheConfig{TTL: time.Hour, MaxSize: 2}, tm)
+
+	g1, g2, g3 := newCacheTestGame(tm), newCacheTestGame(tm), newCacheTestGame(tm)
+	cache.put(g1)
+	tm.Advance(time.Second)
+	cache.put(g2)
+	tm.Advance(time.Second)
+
+	// g1 becomes the most recently used, g2 should be evicted
+	if _, ok := cache.get(g1.ID); !ok {
+		t.Fatalf("g1 should be cached")
+	}
+	cache.put(g3)
+
+	if !cache.contains(g1.ID) || cache.contains(g2.ID) || !cache.contains(g3.ID) {
+		t.Fatalf("g2 should be evicted only")
+	}
+}
+
+func Test_GameCache_DirtyGameIsNotEvicted(t *testing.T) {
+	ctx := context.Background()
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	cache := newGameCache(CacheConfig{TTL: time.Hour, MaxSize: 1}, tm)
+
+	dirty := newCacheTestGame(tm)
+	if err := dirty.AppendPlayer(ctx, testUser, nil, "a", 100); err != nil {
+		t.Fatalf("cannot append player: %s", err)
+	}
+	cache.put(dirty)
+
+	tm.Advance(time.Second)
+	toFlush := cache.put(newCacheTestGame(tm))
+	if len(toFlush) != 1 || toFlush[0] != dirty || !cache.contains(dirty.ID) {
+		t.Fatalf("dirty game should be returned to flush and stay cached, got: %v", toFlush)
+	}
+
+	// the flush failed
+	cache.remove(dirty)
+	if !cache.contains(dirty.ID) {
+		t.Fatalf("dirty game should not be evicted")
+	}
+
+	dirty.markCommitted(len(dirty.Events), dirty.Version+1)
+	cache.remove(dirty)
+	if cache.contains(dirty.ID) || cache.Stats().Evictions != 1 {
+		t.Fatalf("flushed game should be evicted, stats: %+v", cache.Stats())
+	}
+}
+
+// lockCheckingAdapter fails updates (if err is set) and checks the games cache is not locked during updates
+type lockCheckingAdapter struct {
+	Adapter
+	t       *testing.T
+	manager *manager
+	err     error
+}
+
+func (a *lockCheckingAdapter) Update(ctx context.Context, updated Data, newEvents []Event) error {
+	if !a.manager.gamesMux.TryLock() {
+		a.t.Errorf("the game is saved with the cache locked")
+	} else {
+		a.manager.gamesMux.Unlock()
+	}
+	if a.err != nil {
+		return a.err
+	}
+	return a.Adapter.Update(ctx, updated, newEvents)
+}
+
+func Test_Manager_EvictedGameIsFlushedOutsideTheLock(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	adapter := &lockCheckingAdapter{Adapter: env.gameAdapter, t: t, err: errors.New("mongo is down")}
+	m := NewManager(adapter, nil, env.orgAdapter, env.timer, CacheConfig{TTL: time.Hour, MaxSize: 1})
+	adapter.manager = m
+
+	lost, _ := m.CreateGame(ctx, testUser, env.org.Name)
+	_ = lost.AppendPlayer(ctx, testUser, nil, "a", 100)
+
+	env.timer.Advance(time.Second)
+	dirty, _ := m.CreateGame(ctx, testUser, env.org.Name)
+	if m.games.contains(lost.ID) || len(lost.Players) != 0 {
+		t.Fatalf("changes which cannot be flushed should be dropped, players: %+v", lost.Players)
+	}
+
+	_ = dirty.AppendPlayer(ctx, testUser, nil, "a", 100)
+	adapter.err = nil
+	env.timer.Advance(time.Second)
+	_, _ = m.CreateGame(ctx, testUser, env.org.Name)
+	if m.games.contains(dirty.ID) {
+		t.Fatalf("the flushed game should be evicted")
+	}
+	saved, _ := env.gameAdapter.FindGameByID(ctx, dirty.ID)
+	if len(saved.Players) != 1 {
+		t.Fatalf("changes of the evicted game should be saved, got: %+v", saved.Players)
+	}
+}
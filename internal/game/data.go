@@ -3,6 +3,7 @@ package game
 import (
 	"time"
 
+	"pokergo/internal/org"
 	"pokergo/pkg/id"
 )
 
@@ -11,5 +12,25 @@ type Data struct {
 	Organizer    id.ID     `bson:"organizer"`
 	Organization id.ID     `bson:"organization"`
 	Start        time.Time `bson:"start"`
-	Players      []Player  `bson:"players"`
+	// Type is empty for games created before tournaments were introduced (cash games)
+	Type Type `bson:"type,omitempty"`
+	// Tournament is the configuration of the tournament (tournaments only)
+	Tournament *Tournament `bson:"tournament,omitempty"`
+	// Settings are settings of the organization when the cash game was created
+	// (nil for tournaments and games created before settings were introduced)
+	Settings *org.Settings `bson:"settings,omitempty"`
+	// End is set when the game is closed or cancelled
+	End     *time.Time `bson:"end,omitempty"`
+	Status  Status     `bson:"status"`
+	Players []Player   `bson:"players"`
+	// Expenses are shared costs of the game settled together with chips
+	Expenses []Expense `bson:"expenses,omitempty"`
+	// Rake is taken from the pot by the house (cash games only)
+	Rake *Rake `bson:"rake,omitempty"`
+	// Roles are roles given explicitly, see Game.roleOf for default roles
+	Roles []RoleAssignment `bson:"roles"`
+	// Events is the list of all changes made to the game, Players are rebuilt from it
+	Events []Event `bson:"events"`
+	// Version is increased on every update, it allows detecting concurrent changes
+	Version int64 `bson:"version"`
 }
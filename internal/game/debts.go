@@ -0,0 +1,54 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"pokergo/internal/org"
+	"pokergo/pkg/id"
+)
+
+// debtsPageSize is the number of games loaded at once by Debts
+const debtsPageSize = 100
+
+// Debts returns transfers settling every game of the organization which passes Verify, cancelled games are skipped.
+// Debts of settled games are returned too: the ledger counts only payments recorded in it, so a payment made
+// before the game was settled must still have the debt it pays off.
+// Games with expenses split by hours are counted once they end (the split changes while they last).
+func (m *manager) Debts(ctx context.Context, orgID id.ID) ([]org.Debt, error) {
+	// expenses split by hours are computed from events
+	filter := ListFilter{Organizations: []id.ID{orgID}, WithEvents: true}
+
+	var debts []org.Debt
+	var lastDocID id.ID
+	for {
+		games, err := m.gameAdapter.ListGames(ctx, filter, lastDocID, debtsPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list games: %w", err)
+		}
+
+		for _, d := range games {
+			if d.status() == StatusCancelled || d.End == nil && d.splitsByHours() {
+				continue
+			}
+			transfers, err := newGame(d, m.usersAdapter, m.timer).Settlement()
+			if err != nil {
+				if errors.Is(err, ErrGameNotFinished) || errors.Is(err, ErrStackInconsistent) {
+					continue
+				}
+				return nil, fmt.Errorf("cannot settle the game %s: %w", d.ID.Hex(), err)
+			}
+			for _, t := range transfers {
+				debts = append(debts, org.Debt{GameID: d.ID, From: t.From, To: t.To, Amount: t.Amount})
+			}
+		}
+
+		if len(games) < debtsPageSize {
+			return debts, nil
+		}
+		lastDocID = games[len(games)-1].ID
+	}
+}
+
+var _ org.DebtSource = (*manager)(nil)
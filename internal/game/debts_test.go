@@ -0,0 +1,198 @@
+package game
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"pokergo/internal/org"
+	"pokergo/pkg/id"
+)
+
+// memoryPaymentAdapter is an in-memory org.PaymentAdapter
+type memoryPaymentAdapter struct {
+	payments []org.Payment
+}
+
+func (m *memoryPaymentAdapter) AddPayment(_ context.Context, payment org.Payment) error {
+	for _, p := range m.payments {
+		if p.Organization == payment.Organization && p.Seq == payment.Seq {
+			return org.ErrPaymentConflict
+		}
+	}
+	m.payments = append(m.payments, payment)
+	return nil
+}
+
+func (m *memoryPaymentAdapter) ListPayments(_ context.Context, orgID id.ID) ([]org.Payment, error) {
+	var res []org.Payment
+	for _, p := range m.payments {
+		if p.Organization == orgID {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func Test_Manager_Debts(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+
+	// play creates a game with players, the final stack is not set if it is negative
+	type player struct {
+		name         string
+		buyIn, stack int64
+	}
+	play := func(players []player, final func(g *Game) error) *Game {
+		g, err := m.CreateGame(ctx, testUser, env.org.Name)
+		if err != nil {
+			t.Fatalf("cannot create game: %s", err)
+		}
+		for _, p := range players {
+			if err := g.AppendPlayer(ctx, testUser, nil, p.name, p.buyIn); err != nil {
+				t.Fatalf("cannot append player: %s", err)
+			}
+			if p.stack >= 0 {
+				if err := g.SetFinishStack(testUser, p.name, p.stack); err != nil {
+					t.Fatalf("cannot set finish stack: %s", err)
+				}
+			}
+		}
+		if final != nil {
+			if err := final(g); err != nil {
+				t.Fatalf("cannot finish the game: %s", err)
+			}
+		}
+		if err := m.Commit(ctx, g); err != nil {
+			t.Fatalf("cannot commit: %s", err)
+		}
+		return g
+	}
+	closeGame := func(g *Game) error { return g.Close(testUser) }
+
+	closed := play([]player{{"a", 100, 0}, {"b", 100, 250}, {"c", 100, 50}}, closeGame)
+	// verified, but not closed yet
+	finished := play([]player{{"a", 200, 300}, {"b", 200, 100}}, nil)
+	// not verified (c has no final stack)
+	play([]player{{"a", 100, 0}, {"c", 100, -1}}, nil)
+	settled := play([]player{{"a", 100, 0}, {"c", 100, 200}}, func(g *Game) error {
+		if err := g.Close(testUser); err != nil {
+			return err
+		}
+		return g.Settle(testUser)
+	})
+	play([]player{{"b", 100, 0}, {"c", 100, 200}}, func(g *Game) error {
+		return g.Cancel(testUser)
+	})
+
+	debts, err := m.Debts(ctx, env.org.ID)
+	if err != nil {
+		t.Fatalf("cannot get debts: %s", err)
+	}
+
+	// balances of debts of every game reconcile with Report
+	balances := make(map[string]map[string]int64)
+	for _, d := range debts {
+		game := d.GameID.Hex()
+		if balances[game] == nil {
+			balances[game] = make(map[string]int64)
+		}
+		balances[game][d.From] += d.Amount
+		balances[game][d.To] -= d.Amount
+	}
+	if len(balances) != 3 {
+		t.Fatalf("only debts of verified games which are not cancelled are expected, got %+v", debts)
+	}
+	for _, g := range []*Game{closed, finished, settled} {
+		for name, amount := range g.Report() {
+			if balances[g.ID.Hex()][name] != amount {
+				t.Errorf("game %s: %s has the balance %d, the report says %d", g.ID.Hex(), name, balances[g.ID.Hex()][name], amount)
+			}
+		}
+	}
+}
+
+func Test_Manager_DebtsOfSettledGameStayInLedger(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+	ledger := org.NewLedgerManager(m, &memoryPaymentAdapter{}, env.timer)
+
+	g, _ := m.CreateGame(ctx, testUser, env.org.Name)
+	_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+	_ = g.AppendPlayer(ctx, testUser, nil, "b", 100)
+	_ = g.SetFinishStack(testUser, "a", 0)
+	_ = g.SetFinishStack(testUser, "b", 200)
+	if err := g.Close(testUser); err != nil {
+		t.Fatalf("cannot close the game: %s", err)
+	}
+	if err := m.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	if _, err := ledger.Pay(ctx, env.org, testUser, "", "a", "b", 40); err != nil {
+		t.Fatalf("cannot pay: %s", err)
+	}
+	if err := g.Settle(testUser); err != nil {
+		t.Fatalf("cannot settle the game: %s", err)
+	}
+	if err := m.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	l, err := ledger.Ledger(ctx, env.org.ID)
+	if err != nil {
+		t.Fatalf("cannot get the ledger: %s", err)
+	}
+	if l.Owes("a", "b") != 60 || l.Owes("b", "a") != 0 {
+		t.Errorf("the payment should still pay off the debt of the settled game, got %+v", l.Balances)
+	}
+}
+
+func Test_Manager_DebtsSplitByHours(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+
+	// b joins an hour later, both play till the end of the game
+	play := func(close bool) *Game {
+		g, _ := m.CreateGame(ctx, testUser, env.org.Name)
+		_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+		env.timer.Advance(time.Hour)
+		_ = g.AppendPlayer(ctx, testUser, nil, "b", 100)
+		if err := g.AddExpense(testUser, Expense{Description: "pizza", Amount: 30, Payer: "a", Split: SplitHours}); err != nil {
+			t.Fatalf("cannot add the expense: %s", err)
+		}
+		env.timer.Advance(2 * time.Hour)
+		_ = g.SetFinishStack(testUser, "a", 100)
+		_ = g.SetFinishStack(testUser, "b", 100)
+		if close {
+			if err := g.Close(testUser); err != nil {
+				t.Fatalf("cannot close the game: %s", err)
+			}
+		}
+		if err := m.Commit(ctx, g); err != nil {
+			t.Fatalf("cannot commit: %s", err)
+		}
+		return g
+	}
+	closed := play(true)
+	// verified, but the split may still change
+	play(false)
+
+	env.timer.Advance(time.Hour)
+	debts, err := m.Debts(ctx, env.org.ID)
+	if err != nil {
+		t.Fatalf("cannot get debts: %s", err)
+	}
+	// a played 3 hours, b played 2 hours
+	expected := []org.Debt{{GameID: closed.ID, From: "b", To: "a", Amount: 12}}
+	if !reflect.DeepEqual(debts, expected) {
+		t.Fatalf("expected debts %+v, got %+v", expected, debts)
+	}
+	if report := closed.Report(); report["b"] != 12 {
+		t.Fatalf("debts should match the report, got %+v", report)
+	}
+}
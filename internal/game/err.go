@@ -9,11 +9,28 @@ import (
 var (
 	ErrUserNotFound = errors.New("user not exists")
 	ErrOrgNotFound  = errors.New("org not exists")
+	ErrUserExists   = errors.New("user already exists")
 
 	ErrGameNotFinished   = errors.New("some players have not their final stack set")
 	ErrStackInconsistent = errors.New("the sum of final stacks is differ than the sum of buy ins")
+	ErrInvalidExpense    = errors.New("invalid expense")
+
+	ErrGameNotModifiable = errors.New("the game cannot be modified")
+	ErrInvalidTransition = errors.New("invalid game state transition")
+	ErrNothingToUndo     = errors.New("there is nothing to undo")
+	ErrVersionConflict   = errors.New("the game was changed by someone else")
+
+	ErrWrongGameType         = errors.New("wrong game type")
+	ErrInvalidTournament     = errors.New("invalid tournament configuration")
+	ErrPeriodClosed          = errors.New("the period is closed")
+	ErrRebuyLimit            = errors.New("the player has reached the rebuys limit")
+	ErrPlayerEliminated      = errors.New("the player is eliminated")
+	ErrTournamentFinished    = errors.New("the tournament is decided")
+	ErrTournamentNotFinished = errors.New("the tournament is not decided yet")
+	ErrPayoutExceedsPool     = errors.New("payouts exceed the prize pool")
 
 	ErrInsufficientPermissions = errors.New("insufficient permissions to manage game")
+	ErrInvalidRole             = errors.New("invalid role")
 
 	ErrGameNotExists = mongo.ErrNoDocuments
 )
@@ -0,0 +1,410 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/multierr"
+	"pokergo/pkg/id"
+)
+
+type EventType string
+
+const (
+	// EventPlayerJoined a new player joined the game (Amount is the start stack)
+	EventPlayerJoined EventType = "player_joined"
+	// EventReBuyIn a player re-bought from the bank
+	EventReBuyIn EventType = "re_buy_in"
+	// EventTransfer a player (UserName) re-bought from another player (FromName)
+	EventTransfer EventType = "transfer"
+	// EventFinishStack a player finished the game with Amount stack
+	EventFinishStack EventType = "finish_stack"
+	// EventStatusChanged the game changed its state to Status
+	EventStatusChanged EventType = "status_changed"
+	// EventUndo the event Target was reverted
+	EventUndo EventType = "undo"
+	// EventRoleChanged the user UserID got the Role
+	EventRoleChanged EventType = "role_changed"
+	// EventAddOn a tournament player (UserName) bought the add-on for Amount
+	EventAddOn EventType = "add_on"
+	// EventEliminated a tournament player (UserName) busted out
+	EventEliminated EventType = "eliminated"
+	// EventExpenseAdded a player (Expense.Payer) paid the Expense shared by players
+	EventExpenseAdded EventType = "expense_added"
+	// EventRakeSet the house took the rake (Amount) collected by a player (UserName), 0 removes the rake
+	EventRakeSet EventType = "rake_set"
+	// EventImported the state imported from a game created before events were introduced
+	EventImported EventType = "imported"
+)
+
+// isUndoable tells if the event can be reverted with Undo (only changes made to players can be)
+func (t EventType) isUndoable() bool {
+	switch t {
+	case EventPlayerJoined, EventReBuyIn, EventTransfer, EventFinishStack, EventAddOn, EventEliminated,
+		EventExpenseAdded, EventRakeSet:
+		return true
+	case EventStatusChanged, EventRoleChanged, EventUndo, EventImported:
+		return false
+	}
+	return false
+}
+
+// Event is a single change made to the game, the game state (players) is rebuilt from the list of events
+type Event struct {
+	// Seq is a number of the event in the game (starts from 1)
+	Seq  int64     `json:"seq" bson:"seq"`
+	Type EventType `json:"type" bson:"type"`
+	At   time.Time `json:"at" bson:"at"`
+	// By is a user who made the change
+	By id.ID `json:"by" bson:"by"`
+
+	UserID   *id.ID   `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	UserName string   `json:"user_name,omitempty" bson:"user_name,omitempty"`
+	FromName string   `json:"from_name,omitempty" bson:"from_name,omitempty"`
+	Amount   int64    `json:"amount,omitempty" bson:"amount,omitempty"`
+	Status   Status   `json:"status,omitempty" bson:"status,omitempty"`
+	Target   int64    `json:"target,omitempty" bson:"target,omitempty"`
+	Role     Role     `json:"role,omitempty" bson:"role,omitempty"`
+	Players  []Player `json:"-" bson:"players,omitempty"`
+	Expense  *Expense `json:"expense,omitempty" bson:"expense,omitempty"`
+}
+
+// HistoryEntry is an Event with the name of the user who made it
+type HistoryEntry struct {
+	Event
+	ByName string `json:"by_name"`
+	// Undone is true if the event was reverted
+	Undone bool `json:"undone"`
+}
+
+// record applies the event to the game state and appends it to the list of events
+// (must be called with playerMux locked)
+func (g *Game) record(by id.ID, e Event) error {
+	e.Seq = int64(len(g.Events)) + 1
+	e.At = g.timer.Now()
+	e.By = by
+
+	if err := g.apply(e); err != nil {
+		return err
+	}
+
+	g.Events = append(g.Events, e)
+	return nil
+}
+
+// apply changes the game state, the state is not changed if the event is invalid
+func (g *Game) apply(e Event) error {
+	switch e.Type {
+	case EventPlayerJoined:
+		if _, err := g.findPlayer(e.UserName); err == nil {
+			return ErrUserExists
+		}
+		p := Player{
+			UserID:            e.UserID,
+			UserName:          e.UserName,
+			BuyIn:             e.Amount,
+			AdditionalIncomes: []inGameTransaction{},
+		}
+		if g.Tournament != nil {
+			p.Fee = g.Tournament.Fee
+		}
+		g.Players = append(g.Players, p)
+	case EventReBuyIn:
+		p, err := g.findPlayer(e.UserName)
+		if err != nil {
+			return err
+		}
+		p.BuyIn += e.Amount
+		p.Rebuys++
+		if g.gameType() == TypeTournament {
+			p.Eliminated = 0
+		}
+	case EventAddOn:
+		p, err := g.findPlayer(e.UserName)
+		if err != nil {
+			return err
+		}
+		p.BuyIn += e.Amount
+		p.AddOn = true
+	case EventEliminated:
+		p, err := g.findPlayer(e.UserName)
+		if err != nil {
+			return err
+		}
+		p.Eliminated = g.lastElimination() + 1
+	case EventTransfer:
+		buyer, err := g.findPlayer(e.UserName)
+		if err != nil {
+			return err
+		}
+		seller, err := g.findPlayer(e.FromName)
+		if err != nil {
+			return err
+		}
+		// add cash to seller
+		seller.AdditionalIncomes = append(seller.AdditionalIncomes, inGameTransaction{
+			Amount:   e.Amount,
+			Reason:   "re-buy-in from another player",
+			From:     buyer.UserID,
+			FromName: buyer.UserName,
+		})
+		// and to the buyer as a start cash
+		buyer.BuyIn += e.Amount
+		buyer.Rebuys++
+	case EventFinishStack:
+		p, err := g.findPlayer(e.UserName)
+		if err != nil {
+			return err
+		}
+		stack := e.Amount
+		p.BuyOut = &stack
+		if g.status() == StatusOpen {
+			g.Status = StatusFinishing
+		}
+	case EventExpenseAdded:
+		return g.applyExpense(e)
+	case EventRakeSet:
+		return g.applyRake(e)
+	case EventStatusChanged:
+		g.Status = e.Status
+		switch e.Status {
+		case StatusClosed, StatusCancelled:
+			end := e.At
+			g.End = &end
+		case StatusOpen, StatusFinishing:
+			g.End = nil
+		case StatusSettled:
+		}
+	case EventRoleChanged:
+		g.setRole(*e.UserID, e.Role)
+	case EventImported:
+		g.Players = clonePlayers(e.Players)
+		if e.Status != "" {
+			g.Status = e.Status
+		}
+	case EventUndo:
+		// handled by replay
+	default:
+		return fmt.Errorf("unknown event type: %s", e.Type)
+	}
+
+	return nil
+}
+
+// replay rebuilds the game state from the list of events skipping reverted ones
+func (g *Game) replay() error {
+	undone := g.undone()
+
+	g.Players = nil
+	g.Roles = nil
+	g.Expenses = nil
+	g.Rake = nil
+	g.Status = StatusOpen
+	g.End = nil
+	for _, e := range g.Events {
+		if undone[e.Seq] {
+			continue
+		}
+		if err := g.apply(e); err != nil {
+			return fmt.Errorf("cannot replay event %d (%s): %w", e.Seq, e.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// rollback drops events recorded after the first n events, rebuilds the state and returns cause
+// (must be called with playerMux locked)
+func (g *Game) rollback(n int, cause error) error {
+	if len(g.Events) == n {
+		return cause
+	}
+
+	g.Events = g.Events[:n]
+	if err := g.replay(); err != nil {
+		return fmt.Errorf("%w (cannot roll back: %s)", cause, err.Error())
+	}
+	return cause
+}
+
+// undone returns a set of reverted events
+func (g *Game) undone() map[int64]bool {
+	undone := make(map[int64]bool)
+	for _, e := range g.Events {
+		if e.Type == EventUndo {
+			undone[e.Target] = true
+		}
+	}
+	return undone
+}
+
+// Undo reverts the last change made to players (joins, re-buy-ins, transfers, finish stacks, expenses and the rake)
+func (g *Game) Undo(by id.ID) (Event, error) {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkModifiable(); err != nil {
+		return Event{}, err
+	}
+
+	undone := g.undone()
+	for i := len(g.Events) - 1; i >= 0; i-- {
+		e := g.Events[i]
+		if undone[e.Seq] || !e.Type.isUndoable() {
+			continue
+		}
+
+		g.Events = append(g.Events, Event{
+			Seq:    int64(len(g.Events)) + 1,
+			Type:   EventUndo,
+			At:     g.timer.Now(),
+			By:     by,
+			Target: e.Seq,
+		})
+		if err := g.replay(); err != nil {
+			g.Events = g.Events[:len(g.Events)-1]
+			return Event{}, multierr.Append(err, g.replay())
+		}
+
+		g.gameLogger.Infof("event %d (%s) of game %s reverted", e.Seq, e.Type, g.ID.Hex())
+		return e, nil
+	}
+
+	return Event{}, ErrNothingToUndo
+}
+
+// History returns the timeline of the game
+func (g *Game) History(ctx context.Context) ([]HistoryEntry, error) {
+	g.playerMux.Lock()
+	events := make([]Event, len(g.Events))
+	copy(events, g.Events)
+	undone := g.undone()
+	g.playerMux.Unlock()
+
+	var ids []id.ID
+	for _, e := range events {
+		ids = append(ids, e.By)
+	}
+	names, err := g.usersAdapter.UserDetails(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get users details: %w", err)
+	}
+
+	res := make([]HistoryEntry, 0, len(events))
+	for _, e := range events {
+		res = append(res, HistoryEntry{
+			Event:  e,
+			ByName: names[e.By].Username,
+			Undone: undone[e.Seq],
+		})
+	}
+
+	return res, nil
+}
+
+// pending returns a copy of the game data and events not saved yet in the persistent storage
+func (g *Game) pending() (Data, []Event) {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	events := make([]Event, len(g.Events)-g.committed)
+	copy(events, g.Events[g.committed:])
+	return g.Data, events
+}
+
+// rebase replaces the game state with latest (loaded from the persistent storage)
+// and applies not saved events on it. Returns ErrVersionConflict if the events cannot be applied,
+// in such case not saved events are dropped (the game is in the latest state).
+func (g *Game) rebase(latest Data) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	pending := make([]Event, len(g.Events)-g.committed)
+	copy(pending, g.Events[g.committed:])
+
+	fresh := newGame(latest, g.usersAdapter, g.timer)
+	g.Data, g.committed = fresh.Data, fresh.committed
+
+	seqs := make(map[int64]int64, len(pending)) // old seq -> new seq
+	for _, e := range pending {
+		if err := g.rebaseEvent(e, seqs); err != nil {
+			fresh = newGame(latest, g.usersAdapter, g.timer)
+			g.Data, g.committed = fresh.Data, fresh.committed
+			return fmt.Errorf("%w: cannot apply event %s: %s", ErrVersionConflict, e.Type, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (g *Game) rebaseEvent(e Event, seqs map[int64]int64) error {
+	if e.Type == EventImported {
+		// the latest state is imported on its own (if needed)
+		return nil
+	}
+
+	oldSeq := e.Seq
+	e.Seq = int64(len(g.Events)) + 1
+	seqs[oldSeq] = e.Seq
+
+	if e.Type.isUndoable() || e.Type == EventUndo {
+		if err := g.checkModifiable(); err != nil {
+			return err
+		}
+	}
+
+	if e.Type != EventUndo {
+		if err := g.apply(e); err != nil {
+			return err
+		}
+		g.Events = append(g.Events, e)
+		return nil
+	}
+
+	if newSeq, ok := seqs[e.Target]; ok {
+		e.Target = newSeq
+	}
+	if g.undone()[e.Target] {
+		return ErrNothingToUndo
+	}
+	g.Events = append(g.Events, e)
+	return g.replay()
+}
+
+// discard drops events not saved in the persistent storage and rebuilds the state
+func (g *Game) discard() error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	n := g.committed
+	// the event importing players of a legacy game is created on loading, it is not a change
+	if n == 0 && len(g.Events) > 0 && g.Events[0].Type == EventImported {
+		n = 1
+	}
+	if len(g.Events) <= n {
+		return nil
+	}
+
+	g.Events = g.Events[:n]
+	return g.replay()
+}
+
+// isDirty tells if the game has changes not saved in the persistent storage
+func (g *Game) isDirty() bool {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	return len(g.Events) > g.committed
+}
+
+// markCommitted marks n first events as saved in the persistent storage in the given version
+func (g *Game) markCommitted(n int, version int64) {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if n > g.committed {
+		g.committed = n
+	}
+	g.Version = version
+}
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_Events_UndoRebuildsState(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGame()
+
+	steps := []func() error{
+		func() error { return g.AppendPlayer(ctx, testUser, nil, "a", 100) },
+		func() error { return g.AppendPlayer(ctx, testUser, nil, "b", 100) },
+		func() error { return g.ReBuyIn(testUser, "a", 50) },
+		func() error { return g.ReBuyInFromPlayer(testUser, "b", "a", 30) },
+	}
+
+	var snapshots [][]Player
+	for _, step := range steps {
+		snapshots = append(snapshots, clonePlayers(g.Players))
+		if err := step(); err != nil {
+			t.Fatalf("cannot perform step: %s", err)
+		}
+	}
+
+	if err := g.SetFinishStack(testUser, "a", 250); err != nil {
+		t.Fatalf("cannot set finish stack: %s", err)
+	}
+	if _, err := g.Undo(testUser); err != nil {
+		t.Fatalf("cannot undo: %s", err)
+	}
+	if g.Players[0].BuyOut != nil {
+		t.Fatalf("finish stack should be reverted")
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if _, err := g.Undo(testUser); err != nil {
+			t.Fatalf("cannot undo: %s", err)
+		}
+		if !reflect.DeepEqual(clonePlayers(g.Players), snapshots[i]) {
+			t.Fatalf("state after undo differs, is: %+v, should be: %+v", g.Players, snapshots[i])
+		}
+	}
+
+	if _, err := g.Undo(testUser); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("all events are reverted, err: %v", err)
+	}
+	// all the changes are in the history, nothing is removed
+	if len(g.Events) != 2*(len(steps)+1) {
+		t.Fatalf("events should be append-only, got %d events", len(g.Events))
+	}
+}
+
+func Test_Events_InvalidEventIsNotRecorded(t *testing.T) {
+	g := newTestGame()
+
+	if err := g.ReBuyIn(testUser, "nobody", 100); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got: %v", err)
+	}
+	if err := g.AppendPlayer(context.Background(), testUser, nil, "a", 100); err != nil {
+		t.Fatalf("cannot append player: %s", err)
+	}
+	if err := g.AppendPlayer(context.Background(), testUser, nil, "a", 100); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("expected ErrUserExists, got: %v", err)
+	}
+
+	if len(g.Events) != 1 {
+		t.Fatalf("only valid events should be recorded, got: %+v", g.Events)
+	}
+}
+
+func Test_Events_LegacyGameIsImported(t *testing.T) {
+	g := newTestGame(Player{UserName: "a", BuyIn: 100, AdditionalIncomes: []inGameTransaction{}})
+	if err := g.ReBuyIn(testUser, "a", 100); err != nil {
+		t.Fatalf("cannot re-buy-in: %s", err)
+	}
+	if _, err := g.Undo(testUser); err != nil {
+		t.Fatalf("cannot undo: %s", err)
+	}
+
+	if len(g.Players) != 1 || g.Players[0].BuyIn != 100 {
+		t.Fatalf("imported players should be kept, got: %+v", g.Players)
+	}
+	if _, err := g.Undo(testUser); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("imported state cannot be reverted, err: %v", err)
+	}
+}
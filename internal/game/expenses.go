@@ -0,0 +1,316 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"pokergo/pkg/id"
+)
+
+// SplitRule tells how an expense is shared between players
+type SplitRule string
+
+const (
+	// SplitEqual every participant pays the same part
+	SplitEqual SplitRule = "equal"
+	// SplitHours participants pay proportionally to the time they played
+	SplitHours SplitRule = "hours"
+	// SplitShares participants pay proportionally to their custom shares
+	SplitShares SplitRule = "shares"
+)
+
+// Share is a custom share of the expense (a weight, for example 2 of 2:1:1)
+type Share struct {
+	Player string `json:"player" bson:"player"`
+	Weight int64  `json:"weight" bson:"weight"`
+}
+
+// Expense is a cost of the game (pizza, a room fee, a dealer tip) paid by a player and shared by players
+type Expense struct {
+	// ID is the number of the event which added the expense
+	ID          int64     `json:"id" bson:"id"`
+	Description string    `json:"description" bson:"description"`
+	Payer       string    `json:"payer" bson:"payer"`
+	Amount      int64     `json:"amount" bson:"amount"`
+	Split       SplitRule `json:"split" bson:"split"`
+	// Participants share the expense (SplitEqual and SplitHours), all players if empty
+	Participants []string `json:"participants,omitempty" bson:"participants,omitempty"`
+	// Shares are custom shares of players (SplitShares only)
+	Shares []Share `json:"shares,omitempty" bson:"shares,omitempty"`
+}
+
+// Rake is the amount taken from the pot by the house, the player CollectedBy keeps it
+type Rake struct {
+	Amount      int64  `json:"amount" bson:"amount"`
+	CollectedBy string `json:"collected_by" bson:"collected_by"`
+}
+
+// AddExpense records the expense, it is settled together with chips
+func (g *Game) AddExpense(by id.ID, expense Expense) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+
+	if err := g.record(by, Event{Type: EventExpenseAdded, Expense: &expense}); err != nil {
+		return err
+	}
+
+	g.gameLogger.Infof("expense %q (%d) paid by %s split by %s", expense.Description, expense.Amount,
+		expense.Payer, expense.Split)
+	return nil
+}
+
+// SetRake sets the rake of the cash game collected by the player, 0 removes the rake
+func (g *Game) SetRake(by id.ID, amount int64, collectedBy string) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkType(TypeCash); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+
+	if err := g.record(by, Event{Type: EventRakeSet, UserName: collectedBy, Amount: amount}); err != nil {
+		return err
+	}
+
+	g.gameLogger.Infof("rake %d collected by %s", amount, collectedBy)
+	return nil
+}
+
+// RakeDue returns the rake of the game by the rake policy of the organization (0 if there is no policy),
+// re-buy-ins from other players do not bring new money to the pot
+func (g *Game) RakeDue() int64 {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if g.Settings == nil {
+		return 0
+	}
+	var buyIns int64
+	for _, p := range g.Players {
+		buyIns += p.BuyIn
+		for _, a := range p.AdditionalIncomes {
+			buyIns -= a.Amount
+		}
+	}
+	return g.Settings.Rake.Amount(buyIns)
+}
+
+// applyExpense validates the expense and adds it to the game
+func (g *Game) applyExpense(e Event) error {
+	if e.Expense == nil {
+		return fmt.Errorf("%w: the expense is missing", ErrInvalidExpense)
+	}
+	x := *e.Expense
+	if err := g.checkExpense(x); err != nil {
+		return err
+	}
+
+	x.ID = e.Seq
+	x.Participants = append([]string(nil), x.Participants...)
+	x.Shares = append([]Share(nil), x.Shares...)
+	g.Expenses = append(g.Expenses, x)
+	return nil
+}
+
+// checkExpense checks the amount, the split rule and that the payer and participants are players of the game
+func (g *Game) checkExpense(x Expense) error {
+	if x.Amount <= 0 {
+		return fmt.Errorf("%w: the amount must be positive", ErrInvalidExpense)
+	}
+	if _, err := g.findPlayer(x.Payer); err != nil {
+		return fmt.Errorf("%w: the payer %s is not a player", ErrInvalidExpense, x.Payer)
+	}
+
+	var names []string
+	switch x.Split {
+	case SplitEqual, SplitHours:
+		if len(x.Shares) > 0 {
+			return fmt.Errorf("%w: shares are allowed for the %s split only", ErrInvalidExpense, SplitShares)
+		}
+		names = x.Participants
+	case SplitShares:
+		if len(x.Shares) == 0 || len(x.Participants) > 0 {
+			return fmt.Errorf("%w: the %s split needs shares instead of participants", ErrInvalidExpense, SplitShares)
+		}
+		var total int64
+		for _, s := range x.Shares {
+			if s.Weight < 0 {
+				return fmt.Errorf("%w: the share of %s is negative", ErrInvalidExpense, s.Player)
+			}
+			total += s.Weight
+			names = append(names, s.Player)
+		}
+		if total == 0 {
+			return fmt.Errorf("%w: shares sum up to 0", ErrInvalidExpense)
+		}
+	default:
+		return fmt.Errorf("%w: unknown split rule %q", ErrInvalidExpense, x.Split)
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, err := g.findPlayer(name); err != nil {
+			return fmt.Errorf("%w: %s is not a player", ErrInvalidExpense, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidExpense, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// applyRake sets the rake, the collector must be a player
+func (g *Game) applyRake(e Event) error {
+	if e.Amount < 0 {
+		return fmt.Errorf("%w: the rake cannot be negative", ErrInvalidExpense)
+	}
+	if e.Amount == 0 {
+		g.Rake = nil
+		return nil
+	}
+	if _, err := g.findPlayer(e.UserName); err != nil {
+		return err
+	}
+	g.Rake = &Rake{Amount: e.Amount, CollectedBy: e.UserName}
+	return nil
+}
+
+// rake returns the rake amount (0 if there is no rake)
+func (d Data) rake() int64 {
+	if d.Rake == nil {
+		return 0
+	}
+	return d.Rake.Amount
+}
+
+// splitsByHours tells if any expense is split by the time played
+func (d Data) splitsByHours() bool {
+	for _, x := range d.Expenses {
+		if x.Split == SplitHours {
+			return true
+		}
+	}
+	return false
+}
+
+// split returns parts of the expense paid by players, parts sum up to the expense amount
+// (must be called with playerMux locked)
+func (g *Game) split(x Expense) (map[string]int64, error) {
+	if err := g.checkExpense(x); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	var weights []int64
+	switch x.Split {
+	case SplitShares:
+		for _, s := range x.Shares {
+			names = append(names, s.Player)
+			weights = append(weights, s.Weight)
+		}
+	case SplitEqual, SplitHours:
+		names = x.Participants
+		if len(names) == 0 {
+			for _, p := range g.Players {
+				names = append(names, p.UserName)
+			}
+		}
+		played := g.played()
+		var total time.Duration
+		for _, name := range names {
+			w := int64(1)
+			if x.Split == SplitHours {
+				w = int64(played[name] / time.Second)
+				total += played[name]
+			}
+			weights = append(weights, w)
+		}
+		if x.Split == SplitHours && total < time.Second {
+			return nil, fmt.Errorf("%w: participants of %q have not played", ErrInvalidExpense, x.Description)
+		}
+	}
+
+	return divide(x.Amount, names, weights), nil
+}
+
+// played returns the time every player spent in the game: from joining till the final stack is set
+// (till the end of the game or now if the stack is not set yet, players of imported games joined at the start)
+// (must be called with playerMux locked)
+func (g *Game) played() map[string]time.Duration {
+	joined := make(map[string]time.Time)
+	left := make(map[string]time.Time)
+	undone := g.undone()
+	for _, e := range g.Events {
+		if undone[e.Seq] {
+			continue
+		}
+		switch e.Type { // nolint:exhaustive // other events do not change the time
+		case EventPlayerJoined:
+			joined[e.UserName] = e.At
+		case EventFinishStack:
+			left[e.UserName] = e.At
+		case EventImported:
+			for _, p := range e.Players {
+				joined[p.UserName] = g.Start
+			}
+		}
+	}
+
+	end := g.timer.Now()
+	if g.End != nil {
+		end = *g.End
+	}
+	res := make(map[string]time.Duration, len(joined))
+	for name, from := range joined {
+		to, ok := left[name]
+		if !ok {
+			to = end
+		}
+		if to.After(from) {
+			res[name] = to.Sub(from)
+		}
+	}
+	return res
+}
+
+// divide splits the amount proportionally to weights (the total weight must be positive),
+// the remainder is given to players with the biggest fractional parts
+func divide(amount int64, names []string, weights []int64) map[string]int64 {
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+
+	type part struct {
+		name      string
+		remainder int64
+	}
+	res := make(map[string]int64, len(names))
+	parts := make([]part, 0, len(names))
+	left := amount
+	for i, name := range names {
+		res[name] = amount * weights[i] / total
+		left -= res[name]
+		parts = append(parts, part{name: name, remainder: amount * weights[i] % total})
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		if parts[i].remainder != parts[j].remainder {
+			return parts[i].remainder > parts[j].remainder
+		}
+		return parts[i].name < parts[j].name
+	})
+	for i := 0; left > 0; i++ {
+		res[parts[i].name]++
+		left--
+	}
+	return res
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+// newTimedGame is an empty game which time is controlled by the returned timer
+func newTimedGame() (*Game, *timer.FakeTimer) {
+	t := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	return newGame(Data{ID: id.NewID(), Status: StatusOpen, Start: t.Now()}, nil, t), t
+}
+
+func Test_Expenses_SettledWithChips(t *testing.T) {
+	ctx := context.Background()
+	g, clock := newTimedGame()
+
+	steps := []func() error{
+		func() error { return g.AppendPlayer(ctx, testUser, nil, "a", 1000) },
+		func() error { return g.AppendPlayer(ctx, testUser, nil, "b", 1000) },
+		func() error { clock.Advance(time.Hour); return g.AppendPlayer(ctx, testUser, nil, "c", 1000) },
+		func() error {
+			return g.AddExpense(testUser, Expense{Description: "pizza", Payer: "a", Amount: 100, Split: SplitEqual})
+		},
+		func() error {
+			return g.AddExpense(testUser, Expense{
+				Description: "tip", Payer: "c", Amount: 60, Split: SplitShares,
+				Shares: []Share{{Player: "a", Weight: 2}, {Player: "c", Weight: 1}},
+			})
+		},
+		func() error { clock.Advance(time.Hour); return g.SetFinishStack(testUser, "c", 900) },
+		func() error { clock.Advance(time.Hour); return g.SetFinishStack(testUser, "a", 1500) },
+		func() error { return g.SetFinishStack(testUser, "b", 500) },
+		func() error {
+			return g.AddExpense(testUser, Expense{
+				Description: "room", Payer: "b", Amount: 500, Split: SplitHours, Participants: []string{"a", "c"},
+			})
+		},
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d failed: %s", i, err)
+		}
+	}
+
+	if err := g.Verify(); !errors.Is(err, ErrStackInconsistent) {
+		t.Fatalf("the rake is missing, got %v", err)
+	}
+	if err := g.SetRake(testUser, 100, "b"); err != nil {
+		t.Fatalf("cannot set the rake: %s", err)
+	}
+	if err := g.Verify(); err != nil {
+		t.Fatalf("the game should be verified: %s", err)
+	}
+
+	// chips: a -500, b 500, c 100; rake: b -100
+	// pizza: a -100, everybody 33/33/34; tip: c -60, a 40, c 20
+	// room: b -500, a played 3h (375), c 1h (125)
+	expected := map[string]int64{
+		"a": -500 - 100 + 34 + 40 + 375,
+		"b": 500 - 100 + 33 - 500,
+		"c": 100 + 33 - 60 + 20 + 125,
+	}
+	report := g.Report()
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("unexpected report: %v, expected: %v", report, expected)
+	}
+
+	transfers, err := g.Settlement()
+	if err != nil {
+		t.Fatalf("cannot settle: %s", err)
+	}
+	for _, tr := range transfers {
+		report[tr.From] -= tr.Amount
+		report[tr.To] += tr.Amount
+	}
+	for name, b := range report {
+		if b != 0 {
+			t.Errorf("%s is not settled: %d", name, b)
+		}
+	}
+}
+
+func Test_Expenses_Undo(t *testing.T) {
+	g := newTestGame(Player{UserName: "a", BuyIn: 100}, Player{UserName: "b", BuyIn: 100})
+
+	if err := g.AddExpense(testUser, Expense{Payer: "a", Amount: 50, Split: SplitEqual}); err != nil {
+		t.Fatalf("cannot add the expense: %s", err)
+	}
+	if err := g.SetRake(testUser, 10, "a"); err != nil {
+		t.Fatalf("cannot set the rake: %s", err)
+	}
+	if len(g.Expenses) != 1 || g.Expenses[0].ID != 2 || g.Rake == nil {
+		t.Fatalf("unexpected state: %+v, %+v", g.Expenses, g.Rake)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Undo(testUser); err != nil {
+			t.Fatalf("cannot undo: %s", err)
+		}
+	}
+	if len(g.Expenses) != 0 || g.Rake != nil {
+		t.Fatalf("expenses and the rake should be reverted: %+v, %+v", g.Expenses, g.Rake)
+	}
+}
+
+func Test_Expenses_Invalid(t *testing.T) {
+	g := newTestGame(Player{UserName: "a", BuyIn: 100}, Player{UserName: "b", BuyIn: 100})
+	events := len(g.Events)
+
+	invalid := map[string]Expense{
+		"no amount":        {Payer: "a", Split: SplitEqual},
+		"unknown payer":    {Payer: "x", Amount: 10, Split: SplitEqual},
+		"unknown rule":     {Payer: "a", Amount: 10, Split: "random"},
+		"unknown player":   {Payer: "a", Amount: 10, Split: SplitEqual, Participants: []string{"a", "x"}},
+		"listed twice":     {Payer: "a", Amount: 10, Split: SplitHours, Participants: []string{"a", "a"}},
+		"no shares":        {Payer: "a", Amount: 10, Split: SplitShares},
+		"zero shares":      {Payer: "a", Amount: 10, Split: SplitShares, Shares: []Share{{Player: "a"}}},
+		"shares not split": {Payer: "a", Amount: 10, Split: SplitEqual, Shares: []Share{{Player: "a", Weight: 1}}},
+	}
+	for name, x := range invalid {
+		if err := g.AddExpense(testUser, x); !errors.Is(err, ErrInvalidExpense) {
+			t.Errorf("%s: expected ErrInvalidExpense, got %v", name, err)
+		}
+	}
+	if err := g.SetRake(testUser, 10, "x"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	if len(g.Events) != events {
+		t.Errorf("invalid changes should not be recorded, got %d events", len(g.Events)-events)
+	}
+}
+
+func Test_Divide(t *testing.T) {
+	parts := divide(100, []string{"a", "b", "c"}, []int64{1, 1, 1})
+	if !reflect.DeepEqual(parts, map[string]int64{"a": 34, "b": 33, "c": 33}) {
+		t.Errorf("unexpected parts: %v", parts)
+	}
+	parts = divide(10, []string{"a", "b", "c"}, []int64{1, 0, 2})
+	if !reflect.DeepEqual(parts, map[string]int64{"a": 3, "b": 0, "c": 7}) {
+		t.Errorf("unexpected parts: %v", parts)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"pokergo/internal/users"
 	"pokergo/pkg/id"
 	"pokergo/pkg/logger"
+	"pokergo/pkg/timer"
 )
 
 // Game is internal struct for keeping game state
@@ -17,112 +18,196 @@ type Game struct {
 	playerMux    sync.Mutex
 	gameLogger   logger.Logger
 	usersAdapter users.Adapter
+	timer        timer.Timer
+
+	// committed is the number of events saved in the persistent storage
+	committed int
+	// commitMux serializes changes with their commits, so a commit saves changes of a single caller
+	commitMux sync.Mutex
+}
+
+// newGame creates a Game from data loaded from the persistent storage
+func newGame(d Data, usersAdapter users.Adapter, timer timer.Timer) *Game {
+	g := &Game{
+		Data:         d,
+		playerMux:    sync.Mutex{},
+		gameLogger:   logger.NewLogger(),
+		usersAdapter: usersAdapter,
+		timer:        timer,
+		committed:    len(d.Events),
+	}
+
+	// games created before events were introduced have players only
+	if len(g.Events) == 0 && len(g.Players) > 0 {
+		g.Events = append(g.Events, Event{
+			Seq:     1,
+			Type:    EventImported,
+			At:      g.Start,
+			By:      g.Organizer,
+			Status:  g.Status,
+			Players: clonePlayers(g.Players),
+		})
+	}
+
+	return g
 }
 
 // AppendPlayer appends a new player to the game
-func (g *Game) AppendPlayer(ctx context.Context, uID *id.ID, name string, startStack int64) error {
+func (g *Game) AppendPlayer(ctx context.Context, by id.ID, uID *id.ID, name string, startStack int64) error {
 	g.playerMux.Lock()
 	defer g.playerMux.Unlock()
 
-	for _, p := range g.Players {
-		if p.UserName == name {
-			return fmt.Errorf("user already exists")
-		}
+	if err := g.checkType(TypeCash); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+
+	e, err := g.joinEvent(ctx, uID, name, startStack)
+	if err != nil {
+		return err
+	}
+	if err := g.record(by, e); err != nil {
+		return err
 	}
 
-	player := Player{
-		BuyIn:             startStack,
-		AdditionalIncomes: []inGameTransaction{},
+	g.gameLogger.Infof("adding new player to the game(anonymous: %t, uID: %s, name: %s startStack: %d)",
+		uID == nil, fmt.Sprint(uID), e.UserName, startStack)
+
+	return nil
+}
+
+// joinEvent returns the event of joining the game by the player (registered users have their names)
+func (g *Game) joinEvent(ctx context.Context, uID *id.ID, name string, buyIn int64) (Event, error) {
+	e := Event{
+		Type:     EventPlayerJoined,
+		UserName: name,
+		Amount:   buyIn,
 	}
 	if uID != nil {
 		u, err := g.usersAdapter.GetUserByID(ctx, *uID)
 		if err != nil {
-			return fmt.Errorf("cannot find user: %w", err)
+			return Event{}, fmt.Errorf("cannot find user: %w", err)
 		}
-		player.UserID = &u.ID
-		player.UserName = u.Username
-	} else {
-		player.UserName = name
+		e.UserID = &u.ID
+		e.UserName = u.Username
 	}
-
-	g.Players = append(g.Players, player)
-
-	g.gameLogger.Infof("adding new player to the game(anonymous: %b, uID: %s, name: %s startStack: %d)",
-		uID == nil, fmt.Sprint(uID), player.UserName, startStack)
-
-	return nil
+	return e, nil
 }
 
-func (g *Game) SetFinishStack(name string, stack int64) error {
+func (g *Game) SetFinishStack(by id.ID, name string, stack int64) error {
 	g.playerMux.Lock()
 	defer g.playerMux.Unlock()
 
-	if u, err := g.findPlayer(name); err == nil {
-		u.BuyOut = &stack
-		g.gameLogger.Infof("user %s finishes the game with %d stack", name, stack)
-		return nil
+	if err := g.checkType(TypeCash); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
 	}
 
-	g.gameLogger.Errorf("user %s not exists", name)
-	return ErrUserNotFound
+	if err := g.record(by, Event{Type: EventFinishStack, UserName: name, Amount: stack}); err != nil {
+		g.gameLogger.Errorf("user %s not exists", name)
+		return err
+	}
+
+	g.gameLogger.Infof("user %s finishes the game with %d stack", name, stack)
+	return nil
 }
 
 // ReBuyIn is a re-buy-in from the bank (just increase starting stack)
-func (g *Game) ReBuyIn(player string, amount int64) error {
+func (g *Game) ReBuyIn(by id.ID, player string, amount int64) error {
 	g.playerMux.Lock()
 	defer g.playerMux.Unlock()
 
-	p, err := g.findPlayer(player)
-	if err != nil {
-		g.gameLogger.Errorf("user %s not exists", p.UserName)
-		return ErrUserNotFound
+	if err := g.checkType(TypeCash); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
 	}
 
-	g.gameLogger.Infof("user %s re-bought for %d", p.UserName, amount)
+	if err := g.checkRebuyLimit(player); err != nil {
+		return err
+	}
 
-	p.BuyIn += amount
+	if err := g.record(by, Event{Type: EventReBuyIn, UserName: player, Amount: amount}); err != nil {
+		g.gameLogger.Errorf("user %s not exists", player)
+		return err
+	}
+
+	g.gameLogger.Infof("user %s re-bought for %d", player, amount)
 	return nil
 }
 
 // ReBuyInFromPlayer is a re-buy-in but paid by another player
-func (g *Game) ReBuyInFromPlayer(buyer, seller string, amount int64) error {
+func (g *Game) ReBuyInFromPlayer(by id.ID, buyer, seller string, amount int64) error {
 	g.playerMux.Lock()
 	defer g.playerMux.Unlock()
 
-	buyerPlayer, err := g.findPlayer(buyer)
-	if err != nil {
-		g.gameLogger.Errorf("user (buyer) %s not exists", buyerPlayer.UserName)
+	if err := g.checkType(TypeCash); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
 		return err
 	}
 
-	sellerPlayer, err := g.findPlayer(seller)
-	if err != nil {
-		g.gameLogger.Errorf("user (seller) %s not exists", sellerPlayer.UserName)
+	if err := g.checkRebuyLimit(buyer); err != nil {
 		return err
 	}
 
-	// add cash to seller
-	sellerPlayer.AdditionalIncomes = append(sellerPlayer.AdditionalIncomes, inGameTransaction{
+	e := Event{
+		Type:     EventTransfer,
+		UserName: buyer,
+		FromName: seller,
 		Amount:   amount,
-		Reason:   "re-buy-in from another player",
-		From:     buyerPlayer.UserID,
-		FromName: buyerPlayer.UserName,
-	})
+	}
+	if err := g.record(by, e); err != nil {
+		g.gameLogger.Errorf("transaction %s --%d--> %s failed: %s", seller, amount, buyer, err)
+		return err
+	}
 
-	g.gameLogger.Infof("transaction done: %s --%d--> %s",
-		sellerPlayer.UserName, buyerPlayer.UserName, amount)
+	g.gameLogger.Infof("transaction done: %s --%d--> %s", seller, amount, buyer)
+	return nil
+}
 
-	// and to the buyer as a start cash
-	buyerPlayer.BuyIn += amount
+// checkRebuyLimit returns ErrRebuyLimit if the player has reached the rebuys limit of the organization
+// (must be called with playerMux locked)
+func (g *Game) checkRebuyLimit(name string) error {
+	if g.Settings == nil || g.Settings.MaxRebuys == 0 {
+		return nil
+	}
+	p, err := g.findPlayer(name)
+	if err != nil {
+		return err
+	}
+	if p.Rebuys >= g.Settings.MaxRebuys {
+		return fmt.Errorf("%w: %d rebuys are allowed", ErrRebuyLimit, g.Settings.MaxRebuys)
+	}
 	return nil
 }
 
+// DefaultBuyIn returns the default buy-in of the organization, false if there is no default
+func (g *Game) DefaultBuyIn() (int64, bool) {
+	if g.Settings == nil || g.Settings.DefaultBuyIn == 0 {
+		return 0, false
+	}
+	return g.Settings.DefaultBuyIn, true
+}
+
 func (g *Game) Verify() error {
 	g.playerMux.Lock()
 	defer g.playerMux.Unlock()
 
+	return g.verify()
+}
+
+// verify is Verify without locking playerMux
+func (g *Game) verify() error {
 	// 1. All players have finishStack set
-	// 2. sum(buy-ins) == sum(take-outs)
+	// 2. sum(buy-ins) == sum(take-outs) + rake
+	// 3. All expenses can be split between players
 	var buyIns int64
 	var buyOuts int64
 	for _, p := range g.Players {
@@ -140,13 +225,22 @@ func (g *Game) Verify() error {
 		}
 	}
 
-	if buyOuts != buyIns {
+	if buyOuts+g.rake() != buyIns {
 		return ErrStackInconsistent
 	}
 
+	for _, x := range g.Expenses {
+		if _, err := g.split(x); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Report returns the balance of every player (positive - the player owes money, negative - gets money)
+// including the rake (owed to its collector) and expenses (owed to payers). Invalid expenses are skipped,
+// they fail Verify.
 func (g *Game) Report() map[string]int64 {
 	g.playerMux.Lock()
 	defer g.playerMux.Unlock()
@@ -162,6 +256,20 @@ func (g *Game) Report() map[string]int64 {
 		res[p.UserName] = p.BuyIn - incomes
 	}
 
+	if g.Rake != nil {
+		res[g.Rake.CollectedBy] -= g.Rake.Amount
+	}
+	for _, x := range g.Expenses {
+		parts, err := g.split(x)
+		if err != nil {
+			continue
+		}
+		res[x.Payer] -= x.Amount
+		for name, part := range parts {
+			res[name] += part
+		}
+	}
+
 	return res
 }
 
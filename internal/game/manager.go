@@ -9,43 +9,104 @@ import (
 	"pokergo/internal/org"
 	"pokergo/internal/users"
 	"pokergo/pkg/id"
-	"pokergo/pkg/logger"
+	"pokergo/pkg/timer"
 )
 
 type Manager interface {
-	// CreateGame creates an empty game
+	// CreateGame creates an empty cash game with settings of the organization (the default buy-in,
+	// the rebuys limit, the rake policy...)
 	CreateGame(ctx context.Context, uID id.ID, orgName string) (*Game, error)
+	// CreateTournament creates a tournament without players
+	CreateTournament(ctx context.Context, uID id.ID, orgName string, tournament Tournament) (*Game, error)
 	// GetGame returns a game from cache or creates a new instance (if game exists).
-	// Changes made to obtained Game are not saved, must be committed via Commit
-	GetGame(ctx context.Context, callerID, id id.ID) (*Game, error)
-	// Commit saves changes made to game in the persistent storage
-	Commit(ctx context.Context, callerID, gID id.ID) error
+	// The caller must be allowed to perform the action on the game (checked on every call).
+	// Changes made to obtained Game are not saved, must be made via Perform (or committed via Commit)
+	GetGame(ctx context.Context, callerID, id id.ID, action Action) (*Game, error)
+	// SetRole gives the role in the game to the user (the caller must be an organizer)
+	SetRole(ctx context.Context, callerID, gID, uID id.ID, role Role) error
+	// Commit saves changes made to game in the persistent storage.
+	// If the game was changed concurrently, it's reloaded and the changes are applied on the latest version.
+	// Returns ErrVersionConflict if the changes cannot be applied.
+	Commit(ctx context.Context, g *Game) error
+	// Perform changes the game with f and commits the changes (see Commit), changes of the game are performed
+	// one at a time. The changes are dropped if f or the commit fails.
+	Perform(ctx context.Context, g *Game, f func(g *Game) error) error
+	// Refresh reloads the game from the persistent storage (not saved changes are kept)
+	Refresh(ctx context.Context, g *Game) error
+	// ListGames returns games of organizations the caller is a member of (or of orgName only if set),
+	// see Adapter.ListGames for the filter and pagination
+	ListGames(ctx context.Context, callerID id.ID, orgName string, filter ListFilter,
+		lastDocID id.ID, no int) ([]Data, error)
+	// CacheStats returns metrics of the games cache
+	CacheStats() CacheStats
+	// Subscribe returns events of the game committed after the event afterSeq and events committed later on
+	// (by any server instance). The caller must be allowed to view the game. The subscription must be closed.
+	Subscribe(ctx context.Context, callerID, gID id.ID, afterSeq int64) (*Subscription, error)
 }
 
+// maxCommitRetries is the number of attempts to apply changes on the reloaded game
+const maxCommitRetries = 3
+
 type manager struct {
 	gameAdapter  Adapter
 	usersAdapter users.Adapter
 	orgAdapter   org.Adapter
+	timer        timer.Timer
 
 	gamesMux sync.Mutex
-	games    map[id.ID]*Game // TODO(pmaterna): This should be TTL or something
+	games    *gameCache
+
+	broker *broker
 }
 
 func NewManager(
 	gameAdapter Adapter,
 	usersAdapter users.Adapter,
 	orgAdapter org.Adapter,
+	timer timer.Timer,
+	cacheConfig CacheConfig,
 ) *manager {
-	return &manager{
+	m := &manager{
 		gameAdapter:  gameAdapter,
 		usersAdapter: usersAdapter,
 		orgAdapter:   orgAdapter,
+		timer:        timer,
 		gamesMux:     sync.Mutex{},
-		games:        make(map[id.ID]*Game),
 	}
+	m.broker = newBroker(m.committedEvents, pollInterval)
+	m.games = newGameCache(cacheConfig, timer)
+
+	return m
 }
 
 func (m *manager) CreateGame(ctx context.Context, uID id.ID, orgName string) (*Game, error) {
+	return m.create(ctx, uID, orgName, func(o org.Org) (Data, error) {
+		return m.gameAdapter.NewGame(ctx, uID, o.ID, o.Settings) // nolint:wrapcheck // wrapped by create
+	})
+}
+
+func (m *manager) CreateTournament(
+	ctx context.Context,
+	uID id.ID,
+	orgName string,
+	tournament Tournament,
+) (*Game, error) {
+	if err := tournament.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.create(ctx, uID, orgName, func(o org.Org) (Data, error) {
+		return m.gameAdapter.NewTournament(ctx, uID, o.ID, tournament) // nolint:wrapcheck // wrapped by create
+	})
+}
+
+// create creates a game in the organization (the user must be its member) and puts it in the cache
+func (m *manager) create(
+	ctx context.Context,
+	uID id.ID,
+	orgName string,
+	newData func(o org.Org) (Data, error),
+) (*Game, error) {
 	o, err := m.orgAdapter.GetOrgByName(ctx, orgName)
 	if err != nil {
 		if errors.Is(err, org.ErrOrgNotExists) {
@@ -53,72 +114,258 @@ func (m *manager) CreateGame(ctx context.Context, uID id.ID, orgName string) (*G
 		}
 		return nil, fmt.Errorf("cannot find org: %w", err)
 	}
+	if !o.IsMember(uID) {
+		return nil, ErrInsufficientPermissions
+	}
 
-	gameData, err := m.gameAdapter.NewGame(ctx, uID, o.ID)
+	gameData, err := newData(o)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create a new game: %w", err)
 	}
 
-	game := Game{
-		Data:         gameData,
-		playerMux:    sync.Mutex{},
-		gameLogger:   logger.NewLogger(),
-		usersAdapter: m.usersAdapter,
-	}
+	game := newGame(gameData, m.usersAdapter, m.timer)
 
 	m.gamesMux.Lock()
-	defer m.gamesMux.Unlock()
-	if _, exists := m.games[game.ID]; exists {
+	if m.games.contains(game.ID) {
+		m.gamesMux.Unlock()
 		return nil, fmt.Errorf("game exists in the cache")
 	}
-	m.games[game.ID] = &game
+	evicted := m.games.put(game)
+	m.gamesMux.Unlock()
 
-	return &game, nil
+	m.flushEvicted(ctx, evicted)
+	return game, nil
 }
 
-func (m *manager) GetGame(ctx context.Context, callerID, id id.ID) (*Game, error) {
-	m.gamesMux.Lock()
-	defer m.gamesMux.Unlock()
+func (m *manager) GetGame(ctx context.Context, callerID, id id.ID, action Action) (*Game, error) {
+	// permissions are checked on every access (the org members may change while the game is cached)
+	return m.loadGame(ctx, id, func(g *Game) error {
+		o, err := m.orgAdapter.GetOrgByID(ctx, g.Organization)
+		if err != nil {
+			return ErrOrgNotFound
+		}
+		return g.authorize(o, callerID, action)
+	})
+}
 
-	g, ok := m.games[id]
+func (m *manager) SetRole(ctx context.Context, callerID, gID, uID id.ID, role Role) error {
+	g, err := m.GetGame(ctx, callerID, gID, ActionManageRoles)
+	if err != nil {
+		return err
+	}
+
+	o, err := m.orgAdapter.GetOrgByID(ctx, g.Organization)
+	if err != nil {
+		return ErrOrgNotFound
+	}
+	return m.Perform(ctx, g, func(g *Game) error {
+		return g.SetRole(callerID, o, uID, role)
+	})
+}
+
+func (m *manager) ListGames(
+	ctx context.Context,
+	callerID id.ID,
+	orgName string,
+	filter ListFilter,
+	lastDocID id.ID,
+	no int,
+) ([]Data, error) {
+	filter.Organizations = nil
+	if orgName != "" {
+		o, err := m.orgAdapter.GetOrgByName(ctx, orgName)
+		if err != nil {
+			if errors.Is(err, org.ErrOrgNotExists) {
+				return nil, ErrOrgNotFound
+			}
+			return nil, fmt.Errorf("cannot find org: %w", err)
+		}
+		if !o.IsMember(callerID) {
+			return nil, ErrInsufficientPermissions
+		}
+		filter.Organizations = append(filter.Organizations, o.ID)
+	} else {
+		orgs, err := m.orgAdapter.ListUserOrg(ctx, callerID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list user organizations: %w", err)
+		}
+		for _, o := range orgs {
+			filter.Organizations = append(filter.Organizations, o.ID)
+		}
+	}
+
+	if len(filter.Organizations) == 0 {
+		return nil, nil
+	}
+
+	games, err := m.gameAdapter.ListGames(ctx, filter, lastDocID, no)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list games: %w", err)
+	}
+
+	return games, nil
+}
+
+// loadGame returns a game from cache or loads it from the persistent storage.
+// The game is authorized before it's returned, loaded games are cached only if authorized.
+func (m *manager) loadGame(ctx context.Context, gID id.ID, authorize func(g *Game) error) (*Game, error) {
+	m.gamesMux.Lock()
+	g, ok := m.games.get(gID)
+	m.gamesMux.Unlock()
 	if ok {
+		if err := authorize(g); err != nil {
+			return nil, err
+		}
 		return g, nil
 	}
 
-	d, err := m.gameAdapter.FindGameByID(ctx, id)
+	d, err := m.gameAdapter.FindGameByID(ctx, gID)
 	if err != nil {
 		return nil, ErrGameNotExists
 	}
+	g = newGame(d, m.usersAdapter, m.timer)
+	if err := authorize(g); err != nil {
+		return nil, err
+	}
+
+	m.gamesMux.Lock()
+	if m.games.contains(gID) {
+		// loaded concurrently by another request
+		if cached, ok := m.games.get(gID); ok {
+			m.gamesMux.Unlock()
+			return cached, nil
+		}
+	}
+	evicted := m.games.put(g)
+	m.gamesMux.Unlock()
+
+	m.flushEvicted(ctx, evicted)
+	return g, nil
+}
+
+// flushEvicted saves changes of games evicted from the cache and removes them from the cache
+// (changes which cannot be saved are dropped)
+func (m *manager) flushEvicted(ctx context.Context, games []*Game) {
+	for _, g := range games {
+		if err := m.Commit(ctx, g); err != nil {
+			g.gameLogger.Errorf("cannot flush game %s before eviction: %s", g.ID.Hex(), err)
+		}
+
+		m.gamesMux.Lock()
+		m.games.remove(g)
+		m.gamesMux.Unlock()
+	}
+}
 
-	o, err := m.orgAdapter.GetOrgByID(ctx, d.Organization)
+func (m *manager) Commit(ctx context.Context, g *Game) error {
+	g.commitMux.Lock()
+	defer g.commitMux.Unlock()
+
+	return m.save(ctx, g)
+}
+
+func (m *manager) Perform(ctx context.Context, g *Game, f func(g *Game) error) error {
+	g.commitMux.Lock()
+	defer g.commitMux.Unlock()
+
+	if err := f(g); err != nil {
+		m.discard(g)
+		return err
+	}
+	return m.save(ctx, g)
+}
+
+func (m *manager) Subscribe(ctx context.Context, callerID, gID id.ID, afterSeq int64) (*Subscription, error) {
+	if _, err := m.GetGame(ctx, callerID, gID, ActionView); err != nil {
+		return nil, err
+	}
+
+	// the cached game may miss events committed by other server instances
+	committed, err := m.committedEvents(ctx, gID)
 	if err != nil {
-		return nil, ErrOrgNotFound
+		return nil, err
 	}
-	if !o.IsMember(callerID) {
-		return nil, ErrInsufficientPermissions
+	var backlog []Event
+	var lastSeq int64
+	for _, e := range committed {
+		if e.Seq > afterSeq {
+			backlog = append(backlog, e)
+		}
+		lastSeq = e.Seq
 	}
 
-	g = &Game{
-		Data:         d,
-		playerMux:    sync.Mutex{},
-		gameLogger:   logger.NewLogger(),
-		usersAdapter: m.usersAdapter,
+	events, unsubscribe := m.broker.subscribe(gID, lastSeq)
+	return &Subscription{
+		Backlog: backlog,
+		Events:  events,
+		close:   unsubscribe,
+	}, nil
+}
+
+// committedEvents loads events of the game from the persistent storage
+func (m *manager) committedEvents(ctx context.Context, gID id.ID) ([]Event, error) {
+	data, err := m.gameAdapter.FindGameByID(ctx, gID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the game: %w", err)
 	}
+	return data.Events, nil
+}
 
-	m.games[g.ID] = g
+func (m *manager) CacheStats() CacheStats {
+	m.gamesMux.Lock()
+	defer m.gamesMux.Unlock()
 
-	return g, nil
+	return m.games.Stats()
+}
+
+func (m *manager) Refresh(ctx context.Context, g *Game) error {
+	latest, err := m.gameAdapter.FindGameByID(ctx, g.ID)
+	if err != nil {
+		return fmt.Errorf("cannot reload the game: %w", err)
+	}
+
+	return g.rebase(latest)
 }
 
-func (m *manager) Commit(ctx context.Context, callerID, gID id.ID) error {
-	g, err := m.GetGame(ctx, callerID, gID)
+// save writes not saved changes of the game to the persistent storage, the changes are dropped if they cannot
+// be saved (they would be written with the next commit otherwise, so a retried change would be applied twice)
+func (m *manager) save(ctx context.Context, g *Game) error {
+	err := m.write(ctx, g)
 	if err != nil {
-		return fmt.Errorf("cannot obtain the game: %w", err)
+		m.discard(g)
 	}
+	return err
+}
 
-	if err := m.gameAdapter.Update(ctx, g.Data); err != nil {
-		return fmt.Errorf("cannot update the game: %w", err)
+// discard drops not saved changes of the game, the game is evicted from the cache if its state cannot be restored
+func (m *manager) discard(g *Game) {
+	if err := g.discard(); err != nil {
+		g.gameLogger.Errorf("cannot discard changes of game %s, evicting it: %s", g.ID.Hex(), err)
+		m.gamesMux.Lock()
+		m.games.forget(g)
+		m.gamesMux.Unlock()
 	}
+}
 
-	return nil
+// write saves not saved events of the game, retrying on concurrent changes
+func (m *manager) write(ctx context.Context, g *Game) error {
+	for attempt := 0; ; attempt++ {
+		data, events := g.pending()
+		err := m.gameAdapter.Update(ctx, data, events)
+		if err == nil {
+			g.markCommitted(len(data.Events), data.Version+1)
+			if len(events) > 0 {
+				m.broker.publish(g.ID, events)
+			}
+			return nil
+		}
+		if !errors.Is(err, ErrVersionConflict) || attempt == maxCommitRetries {
+			return fmt.Errorf("cannot update the game: %w", err)
+		}
+
+		g.gameLogger.Infof("game %s was changed concurrently, reloading (attempt %d)", g.ID.Hex(), attempt+1)
+		if err := m.Refresh(ctx, g); err != nil {
+			return err
+		}
+	}
 }
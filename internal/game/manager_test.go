@@ -0,0 +1,495 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"pokergo/internal/org"
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+// memoryAdapter is an in-memory Adapter shared by "replicas" in tests
+type memoryAdapter struct {
+	mux   sync.Mutex
+	games map[id.ID]Data
+	timer timer.Timer
+}
+
+func newMemoryAdapter(tm timer.Timer) *memoryAdapter {
+	return &memoryAdapter{games: make(map[id.ID]Data), timer: tm}
+}
+
+func (m *memoryAdapter) NewGame(_ context.Context, uID, orgID id.ID, settings org.Settings) (Data, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	d := Data{
+		ID:           id.NewID(),
+		Organizer:    uID,
+		Organization: orgID,
+		Start:        m.timer.Now(),
+		Settings:     &settings,
+		Status:       StatusOpen,
+		Events:       []Event{},
+	}
+	m.games[d.ID] = d
+	return d, nil
+}
+
+func (m *memoryAdapter) NewTournament(_ context.Context, uID, orgID id.ID, tournament Tournament) (Data, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	d := Data{
+		ID:           id.NewID(),
+		Organizer:    uID,
+		Organization: orgID,
+		Start:        m.timer.Now(),
+		Type:         TypeTournament,
+		Tournament:   &tournament,
+		Status:       StatusOpen,
+		Events:       []Event{},
+	}
+	m.games[d.ID] = d
+	return d, nil
+}
+
+func (m *memoryAdapter) Update(_ context.Context, updated Data, newEvents []Event) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	stored, ok := m.games[updated.ID]
+	if !ok {
+		return ErrGameNotExists
+	}
+	if stored.Version != updated.Version {
+		return ErrVersionConflict
+	}
+
+	stored.Players = clonePlayers(updated.Players)
+	stored.Expenses = append([]Expense{}, updated.Expenses...)
+	stored.Rake = updated.Rake
+	stored.Roles = append([]RoleAssignment{}, updated.Roles...)
+	stored.Status = updated.Status
+	stored.End = updated.End
+	stored.Events = append(append([]Event{}, stored.Events...), newEvents...)
+	stored.Version++
+	m.games[updated.ID] = stored
+	return nil
+}
+
+func (m *memoryAdapter) FindGameByID(_ context.Context, gID id.ID) (Data, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	d, ok := m.games[gID]
+	if !ok {
+		return Data{}, ErrGameNotExists
+	}
+	d.Players = clonePlayers(d.Players)
+	d.Events = append([]Event{}, d.Events...)
+	return d, nil
+}
+
+func (m *memoryAdapter) ListGames(_ context.Context, filter ListFilter, lastDocID id.ID, no int) ([]Data, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	var res []Data
+	for _, d := range m.games {
+		inOrg := false
+		for _, o := range filter.Organizations {
+			inOrg = inOrg || d.Organization == o
+		}
+		if inOrg && (lastDocID.IsZero() || d.ID.Hex() < lastDocID.Hex()) {
+			if !filter.WithEvents {
+				// like the projection of the mongo adapter
+				d.Events = nil
+			}
+			res = append(res, d)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID.Hex() > res[j].ID.Hex()
+	})
+	if len(res) > no {
+		res = res[:no]
+	}
+	return res, nil
+}
+
+// memoryOrgAdapter is an in-memory org.Adapter
+type memoryOrgAdapter struct {
+	orgs map[id.ID]org.Org
+}
+
+func newMemoryOrgAdapter(orgs ...org.Org) *memoryOrgAdapter {
+	a := &memoryOrgAdapter{orgs: make(map[id.ID]org.Org)}
+	for _, o := range orgs {
+		a.orgs[o.ID] = o
+	}
+	return a
+}
+
+func (m *memoryOrgAdapter) GetOrgByID(_ context.Context, oID id.ID) (org.Org, error) {
+	o, ok := m.orgs[oID]
+	if !ok {
+		return org.Org{}, org.ErrOrgNotExists
+	}
+	return o, nil
+}
+
+func (m *memoryOrgAdapter) GetOrgByName(_ context.Context, name string) (org.Org, error) {
+	for _, o := range m.orgs {
+		if o.Name == name {
+			return o, nil
+		}
+	}
+	return org.Org{}, org.ErrOrgNotExists
+}
+
+func (m *memoryOrgAdapter) CreateOrg(_ context.Context, owner id.ID, orgName string) (org.Org, error) {
+	o := org.Org{ID: id.NewID(), Name: orgName, Owner: owner, Members: []id.ID{owner}}
+	m.orgs[o.ID] = o
+	return o, nil
+}
+
+func (m *memoryOrgAdapter) AddToOrg(_ context.Context, orgID id.ID, who id.ID) error {
+	o := m.orgs[orgID]
+	o.Members = append(o.Members, who)
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryOrgAdapter) RemoveFromOrg(_ context.Context, orgID id.ID, who id.ID) error {
+	o := m.orgs[orgID]
+	var members []id.ID
+	for _, member := range o.Members {
+		if member != who {
+			members = append(members, member)
+		}
+	}
+	o.Members = members
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryOrgAdapter) SetRole(_ context.Context, orgID id.ID, who id.ID, role org.Role) error {
+	o := m.orgs[orgID]
+	if !o.IsMember(who) {
+		return org.ErrOrgChanged
+	}
+	var roles []org.MemberRole
+	for _, r := range o.Roles {
+		if r.UserID != who {
+			roles = append(roles, r)
+		}
+	}
+	if role != org.RoleMember {
+		roles = append(roles, org.MemberRole{UserID: who, Role: role})
+	}
+	o.Roles = roles
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryOrgAdapter) TransferOwnership(_ context.Context, orgID id.ID, from, to id.ID) error {
+	o := m.orgs[orgID]
+	if o.Owner != from || !o.IsMember(to) {
+		return org.ErrOrgChanged
+	}
+	o.Owner = to
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryOrgAdapter) SetSettings(_ context.Context, orgID id.ID, settings org.Settings) error {
+	o := m.orgs[orgID]
+	o.Settings = settings
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryOrgAdapter) ListUserOrg(_ context.Context, userID id.ID) ([]org.Org, error) {
+	var res []org.Org
+	for _, o := range m.orgs {
+		if o.IsMember(userID) {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+type testEnv struct {
+	timer       *timer.FakeTimer
+	gameAdapter *memoryAdapter
+	orgAdapter  *memoryOrgAdapter
+	org         org.Org
+}
+
+func newTestEnv() *testEnv {
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	o := org.Org{ID: id.NewID(), Name: "org", Owner: testUser, Members: []id.ID{testUser}}
+	return &testEnv{
+		timer:       tm,
+		gameAdapter: newMemoryAdapter(tm),
+		orgAdapter:  newMemoryOrgAdapter(o),
+		org:         o,
+	}
+}
+
+func (e *testEnv) newManager() *manager {
+	return NewManager(e.gameAdapter, nil, e.orgAdapter, e.timer, CacheConfig{TTL: time.Hour, MaxSize: 100})
+}
+
+func Test_Manager_ConcurrentCommitsAreMerged(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	replicaA, replicaB := env.newManager(), env.newManager()
+
+	created, err := replicaA.CreateGame(ctx, testUser, env.org.Name)
+	if err != nil {
+		t.Fatalf("cannot create game: %s", err)
+	}
+
+	gA, err := replicaA.GetGame(ctx, testUser, created.ID, ActionModify)
+	if err != nil {
+		t.Fatalf("cannot get game: %s", err)
+	}
+	gB, err := replicaB.GetGame(ctx, testUser, created.ID, ActionModify)
+	if err != nil {
+		t.Fatalf("cannot get game: %s", err)
+	}
+
+	if err := gA.AppendPlayer(ctx, testUser, nil, "a", 100); err != nil {
+		t.Fatalf("cannot append player: %s", err)
+	}
+	if err := replicaA.Commit(ctx, gA); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	// replica B has a stale copy of the game
+	if err := gB.AppendPlayer(ctx, testUser, nil, "b", 100); err != nil {
+		t.Fatalf("cannot append player: %s", err)
+	}
+	if err := replicaB.Commit(ctx, gB); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	stored, _ := env.gameAdapter.FindGameByID(ctx, created.ID)
+	if stored.Version != 2 || len(stored.Players) != 2 || len(stored.Events) != 2 {
+		t.Fatalf("both changes should be stored, got: %+v", stored)
+	}
+	if gB.Version != 2 || len(gB.Players) != 2 {
+		t.Fatalf("replica B should be up to date, got: %+v", gB.Data)
+	}
+}
+
+func Test_Manager_ConflictingCommitIsRejected(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	replicaA, replicaB := env.newManager(), env.newManager()
+
+	created, _ := replicaA.CreateGame(ctx, testUser, env.org.Name)
+	gA, _ := replicaA.GetGame(ctx, testUser, created.ID, ActionModify)
+	gB, _ := replicaB.GetGame(ctx, testUser, created.ID, ActionModify)
+
+	_ = gA.AppendPlayer(ctx, testUser, nil, "a", 100)
+	if err := replicaA.Commit(ctx, gA); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	_ = gB.AppendPlayer(ctx, testUser, nil, "a", 200)
+	if err := replicaB.Commit(ctx, gB); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected version conflict, got: %v", err)
+	}
+
+	stored, _ := env.gameAdapter.FindGameByID(ctx, created.ID)
+	if stored.Version != 1 || len(stored.Players) != 1 || stored.Players[0].BuyIn != 100 {
+		t.Fatalf("the conflicting change should not be stored, got: %+v", stored)
+	}
+	if gB.Version != 1 || gB.isDirty() || gB.Players[0].BuyIn != 100 {
+		t.Fatalf("the conflicting change should be dropped, got: %+v", gB.Data)
+	}
+}
+
+// failingAdapter fails the given number of updates
+type failingAdapter struct {
+	Adapter
+	fails int
+}
+
+func (a *failingAdapter) Update(ctx context.Context, updated Data, newEvents []Event) error {
+	if a.fails > 0 {
+		a.fails--
+		return errors.New("mongo is down")
+	}
+	return a.Adapter.Update(ctx, updated, newEvents)
+}
+
+func Test_Manager_FailedCommitIsDropped(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	adapter := &failingAdapter{Adapter: env.gameAdapter}
+	m := NewManager(adapter, nil, env.orgAdapter, env.timer, CacheConfig{TTL: time.Hour, MaxSize: 100})
+
+	g, _ := m.CreateGame(ctx, testUser, env.org.Name)
+	_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+	if err := m.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	adapter.fails = 1
+	_ = g.AppendPlayer(ctx, testUser, nil, "b", 100)
+	if err := m.Commit(ctx, g); err == nil {
+		t.Fatalf("the commit should fail")
+	}
+	if g.isDirty() || len(g.Players) != 1 || len(g.Events) != 1 {
+		t.Fatalf("the failed change should be dropped, got: %+v", g.Data)
+	}
+
+	// the client retries the change
+	if err := g.AppendPlayer(ctx, testUser, nil, "b", 100); err != nil {
+		t.Fatalf("cannot append player: %s", err)
+	}
+	if err := m.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+	stored, _ := env.gameAdapter.FindGameByID(ctx, g.ID)
+	if len(stored.Players) != 2 || len(stored.Events) != 2 {
+		t.Fatalf("the change should be stored once, got: %+v", stored)
+	}
+}
+
+func Test_Manager_PerformHoldsCommits(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+	g, _ := m.CreateGame(ctx, testUser, env.org.Name)
+
+	changed, release := make(chan struct{}), make(chan struct{})
+	performed := make(chan error, 1)
+	go func() {
+		performed <- m.Perform(ctx, g, func(g *Game) error {
+			if err := g.AppendPlayer(ctx, testUser, nil, "a", 100); err != nil {
+				return err
+			}
+			close(changed)
+			<-release
+			return nil
+		})
+	}()
+	<-changed
+
+	committed := make(chan error, 1)
+	go func() {
+		committed <- m.Commit(ctx, g)
+	}()
+	select {
+	case <-committed:
+		t.Fatalf("the commit should wait for the change being performed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-performed; err != nil {
+		t.Fatalf("cannot perform: %s", err)
+	}
+	if err := <-committed; err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+	stored, _ := env.gameAdapter.FindGameByID(ctx, g.ID)
+	if len(stored.Players) != 1 || len(stored.Events) != 1 {
+		t.Fatalf("the change should be committed once, got: %+v", stored)
+	}
+}
+
+func Test_Manager_FailedChangeIsDropped(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+	g, _ := m.CreateGame(ctx, testUser, env.org.Name)
+
+	err := m.Perform(ctx, g, func(g *Game) error {
+		_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+		return ErrInsufficientPermissions
+	})
+	if !errors.Is(err, ErrInsufficientPermissions) || g.isDirty() || len(g.Players) != 0 {
+		t.Fatalf("the change should be dropped, err: %v, players: %+v", err, g.Players)
+	}
+}
+
+func Test_Manager_ListGames(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+
+	var created []id.ID
+	for i := 0; i < 5; i++ {
+		g, err := m.CreateGame(ctx, testUser, env.org.Name)
+		if err != nil {
+			t.Fatalf("cannot create game: %s", err)
+		}
+		created = append(created, g.ID)
+	}
+	other, _ := env.orgAdapter.CreateOrg(ctx, id.NewID(), "other")
+	_, _ = env.gameAdapter.NewGame(ctx, other.Owner, other.ID, org.Settings{})
+
+	firstPage, err := m.ListGames(ctx, testUser, "", ListFilter{}, id.ZeroID, 3)
+	if err != nil {
+		t.Fatalf("cannot list games: %s", err)
+	}
+	if len(firstPage) != 3 || firstPage[0].ID != created[4] {
+		t.Fatalf("the newest games should be returned first, got: %+v", firstPage)
+	}
+
+	secondPage, _ := m.ListGames(ctx, testUser, env.org.Name, ListFilter{}, firstPage[2].ID, 3)
+	if len(secondPage) != 2 || secondPage[1].ID != created[0] {
+		t.Fatalf("the rest of games should be returned, got: %+v", secondPage)
+	}
+
+	if _, err := m.ListGames(ctx, testUser, "other", ListFilter{}, id.ZeroID, 3); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Fatalf("games of other organizations cannot be listed, err: %v", err)
+	}
+}
+
+func Test_Manager_CreateGameAppliesSettings(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	settings := org.Settings{DefaultBuyIn: 100, MaxRebuys: 1, Rake: org.RakePolicy{Fixed: 10, Percent: 10}}
+	_ = env.orgAdapter.SetSettings(ctx, env.org.ID, settings)
+	m := env.newManager()
+
+	g, err := m.CreateGame(ctx, testUser, env.org.Name)
+	if err != nil {
+		t.Fatalf("cannot create game: %s", err)
+	}
+	if buyIn, ok := g.DefaultBuyIn(); !ok || buyIn != 100 {
+		t.Fatalf("expected the default buy-in of the organization, got %d", buyIn)
+	}
+
+	_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+	_ = g.AppendPlayer(ctx, testUser, nil, "b", 100)
+	if err := g.ReBuyIn(testUser, "a", 100); err != nil {
+		t.Fatalf("cannot re-buy-in: %s", err)
+	}
+	if err := g.ReBuyIn(testUser, "a", 100); !errors.Is(err, ErrRebuyLimit) {
+		t.Errorf("expected ErrRebuyLimit, got %v", err)
+	}
+	if err := g.ReBuyInFromPlayer(testUser, "b", "a", 50); err != nil {
+		t.Fatalf("cannot re-buy-in from the player: %s", err)
+	}
+	if err := g.ReBuyInFromPlayer(testUser, "b", "a", 50); !errors.Is(err, ErrRebuyLimit) {
+		t.Errorf("expected ErrRebuyLimit, got %v", err)
+	}
+
+	// the transfer does not bring new money to the table
+	if rake := g.RakeDue(); rake != 10+30 {
+		t.Errorf("expected the rake 40 (10 + 10%% of 300), got %d", rake)
+	}
+}
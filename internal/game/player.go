@@ -15,6 +15,15 @@ type Player struct {
 
 	// AdditionalIncomes is a list of inGameTransaction
 	AdditionalIncomes []inGameTransaction `bson:"additional_incomes"`
+
+	// Fee is the tournament fee paid by the player (not a part of BuyIn)
+	Fee int64 `bson:"fee,omitempty"`
+	// Rebuys is the number of rebuys (re-buy-ins and transfers in cash games)
+	Rebuys int `bson:"rebuys,omitempty"`
+	// AddOn is true if the player bought the tournament add-on
+	AddOn bool `bson:"add_on,omitempty"`
+	// Eliminated is the order of elimination from the tournament, 0 if the player is still playing
+	Eliminated int `bson:"eliminated,omitempty"`
 }
 
 // inGameTransaction special transaction between players
@@ -26,3 +35,15 @@ type inGameTransaction struct {
 	From     *id.ID `bson:"from_id,omitempty"`
 	FromName string `bson:"from_name"`
 }
+
+// clonePlayers returns a deep copy of players
+func clonePlayers(players []Player) []Player {
+	res := make([]Player, 0, len(players))
+	for _, p := range players {
+		incomes := make([]inGameTransaction, len(p.AdditionalIncomes))
+		copy(incomes, p.AdditionalIncomes)
+		p.AdditionalIncomes = incomes
+		res = append(res, p)
+	}
+	return res
+}
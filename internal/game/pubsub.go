@@ -0,0 +1,153 @@
+package game
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/logger"
+)
+
+// subscriptionBuffer is the number of events waiting for a subscriber,
+// slower subscribers are disconnected (they should subscribe again)
+const subscriptionBuffer = 64
+
+// pollInterval is how often games with subscribers are reloaded from the persistent storage,
+// so events committed by other server instances are published too
+const pollInterval = 2 * time.Second
+
+// Subscription receives committed events of the game
+type Subscription struct {
+	// Backlog are committed events newer than the one requested on subscribing
+	Backlog []Event
+	// Events receives events committed after Backlog, in order and without gaps. Events committed by other
+	// server instances arrive with a delay (see pollInterval).
+	// The channel is closed when the subscriber is too slow or the subscription is closed.
+	Events <-chan Event
+
+	close func()
+}
+
+// Close stops receiving events
+func (s *Subscription) Close() {
+	s.close()
+}
+
+// subscriber receives events of the game
+type subscriber struct {
+	ch chan Event
+	// lastSeq is the sequence number of the last event sent to the subscriber
+	lastSeq int64
+}
+
+// broker publishes committed events to subscribers of the game. Events committed by this Manager are published
+// at once, games with subscribers are polled for events committed by other server instances.
+type broker struct {
+	mux         sync.Mutex
+	subscribers map[id.ID]map[*subscriber]struct{}
+	// pollers stop polling of games
+	pollers map[id.ID]chan struct{}
+
+	// load returns committed events of the game (nil disables polling)
+	load     func(ctx context.Context, gameID id.ID) ([]Event, error)
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func newBroker(load func(ctx context.Context, gameID id.ID) ([]Event, error), interval time.Duration) *broker {
+	return &broker{
+		subscribers: make(map[id.ID]map[*subscriber]struct{}),
+		pollers:     make(map[id.ID]chan struct{}),
+		load:        load,
+		interval:    interval,
+		logger:      logger.NewLogger(),
+	}
+}
+
+// subscribe returns a channel receiving events of the game after the event lastSeq and the function closing it
+func (b *broker) subscribe(gameID id.ID, lastSeq int64) (<-chan Event, func()) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	s := &subscriber{ch: make(chan Event, subscriptionBuffer), lastSeq: lastSeq}
+	if b.subscribers[gameID] == nil {
+		b.subscribers[gameID] = make(map[*subscriber]struct{})
+		if b.load != nil {
+			stop := make(chan struct{})
+			b.pollers[gameID] = stop
+			go b.poll(gameID, stop)
+		}
+	}
+	b.subscribers[gameID][s] = struct{}{}
+
+	return s.ch, func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+
+		b.remove(gameID, s)
+	}
+}
+
+// publish sends events to subscribers of the game, slow subscribers are removed.
+// Subscribers receive events in order, events after a gap are skipped (the gap is filled by polling).
+func (b *broker) publish(gameID id.ID, events []Event) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for s := range b.subscribers[gameID] {
+	send:
+		for _, e := range events {
+			if e.Seq != s.lastSeq+1 {
+				continue
+			}
+			select {
+			case s.ch <- e:
+				s.lastSeq = e.Seq
+			default:
+				b.remove(gameID, s)
+				break send
+			}
+		}
+	}
+}
+
+// poll publishes events of the game loaded from the persistent storage until stop is closed
+func (b *broker) poll(gameID id.ID, stop <-chan struct{}) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.interval)
+		events, err := b.load(ctx, gameID)
+		cancel()
+		if err != nil {
+			b.logger.Errorf("cannot poll events of game %s: %s", gameID.Hex(), err)
+			continue
+		}
+		b.publish(gameID, events)
+	}
+}
+
+// remove closes the subscriber channel and stops polling the game without subscribers
+// (must be called with mux locked)
+func (b *broker) remove(gameID id.ID, s *subscriber) {
+	if _, ok := b.subscribers[gameID][s]; !ok {
+		return
+	}
+	close(s.ch)
+	delete(b.subscribers[gameID], s)
+	if len(b.subscribers[gameID]) == 0 {
+		delete(b.subscribers, gameID)
+		if stop, ok := b.pollers[gameID]; ok {
+			close(stop)
+			delete(b.pollers, gameID)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+)
+
+func Test_Manager_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	m := env.newManager()
+
+	created, _ := m.CreateGame(ctx, testUser, env.org.Name)
+	g, _ := m.GetGame(ctx, testUser, created.ID, ActionModify)
+	_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+	_ = g.AppendPlayer(ctx, testUser, nil, "b", 100)
+	if err := m.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	if _, err := m.Subscribe(ctx, id.NewID(), g.ID, 0); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Fatalf("only members can subscribe, err: %v", err)
+	}
+
+	// reconnecting client has seen the first event
+	sub, err := m.Subscribe(ctx, testUser, g.ID, 1)
+	if err != nil {
+		t.Fatalf("cannot subscribe: %s", err)
+	}
+	if len(sub.Backlog) != 1 || sub.Backlog[0].Seq != 2 {
+		t.Fatalf("missed events should be returned, got: %+v", sub.Backlog)
+	}
+
+	// not committed changes are not published
+	_ = g.ReBuyIn(testUser, "a", 50)
+	select {
+	case e := <-sub.Events:
+		t.Fatalf("not committed event published: %+v", e)
+	default:
+	}
+
+	if err := m.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+	select {
+	case e := <-sub.Events:
+		if e.Seq != 3 || e.Type != EventReBuyIn {
+			t.Fatalf("invalid event published: %+v", e)
+		}
+	default:
+		t.Fatalf("committed event should be published")
+	}
+
+	sub.Close()
+	if _, ok := <-sub.Events; ok {
+		t.Fatalf("closed subscription should not receive events")
+	}
+}
+
+func Test_Broker_SlowSubscriberIsRemoved(t *testing.T) {
+	b := newBroker(nil, 0)
+	gameID := id.NewID()
+	events, unsubscribe := b.subscribe(gameID, 0)
+	defer unsubscribe()
+
+	published := make([]Event, subscriptionBuffer+1)
+	for i := range published {
+		published[i].Seq = int64(i) + 1
+	}
+	b.publish(gameID, published)
+
+	received := 0
+	for range events {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Fatalf("the buffer should be delivered before closing, got: %d", received)
+	}
+}
+
+func Test_Broker_EventsAreSentInOrder(t *testing.T) {
+	b := newBroker(nil, 0)
+	gameID := id.NewID()
+	events, unsubscribe := b.subscribe(gameID, 1)
+	defer unsubscribe()
+
+	b.publish(gameID, []Event{{Seq: 3}})
+	b.publish(gameID, []Event{{Seq: 1}, {Seq: 2}, {Seq: 3}})
+	b.publish(gameID, []Event{{Seq: 3}, {Seq: 4}})
+
+	for _, want := range []int64{2, 3, 4} {
+		if e := <-events; e.Seq != want {
+			t.Fatalf("expected event %d, got %d", want, e.Seq)
+		}
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %+v", e)
+	default:
+	}
+}
+
+func Test_Manager_SubscribeToOtherInstance(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	replicaA, replicaB := env.newManager(), env.newManager()
+	replicaA.broker.interval = 10 * time.Millisecond
+
+	created, _ := replicaA.CreateGame(ctx, testUser, env.org.Name)
+	sub, err := replicaA.Subscribe(ctx, testUser, created.ID, 0)
+	if err != nil {
+		t.Fatalf("cannot subscribe: %s", err)
+	}
+	defer sub.Close()
+
+	g, _ := replicaB.GetGame(ctx, testUser, created.ID, ActionModify)
+	_ = g.AppendPlayer(ctx, testUser, nil, "a", 100)
+	if err := replicaB.Commit(ctx, g); err != nil {
+		t.Fatalf("cannot commit: %s", err)
+	}
+
+	select {
+	case e := <-sub.Events:
+		if e.Type != EventPlayerJoined {
+			t.Fatalf("invalid event published: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("events committed by other instances should be published")
+	}
+}
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+// exactSettlementLimit is the maximal number of players with a non-zero balance
+// for which the optimal (minimal) number of transfers is searched.
+// For bigger games a greedy algorithm is used (at most n-1 transfers).
+const exactSettlementLimit = 16
+
+// Transfer is a single payment required to settle the game: From pays To the Amount
+type Transfer struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int64  `json:"amount"`
+}
+
+type balance struct {
+	name   string
+	amount int64 // positive - the player owes money, negative - the player gets money
+}
+
+// Settlement returns a list of transfers that settles the game using as few payments as possible.
+// The game must pass Verify, balances are taken from Report (so in-game transactions are included).
+func (g *Game) Settlement() ([]Transfer, error) {
+	if err := g.Verify(); err != nil {
+		return nil, fmt.Errorf("the game cannot be settled: %w", err)
+	}
+
+	return settle(g.Report()), nil
+}
+
+// settle creates a minimal list of transfers for given balances (positive balance means debt).
+// Sum of all balances must be equal to 0.
+func settle(report map[string]int64) []Transfer {
+	balances := make([]balance, 0, len(report))
+	for name, amount := range report {
+		if amount != 0 {
+			balances = append(balances, balance{name: name, amount: amount})
+		}
+	}
+	// map iteration order is random, keep the result stable
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].name < balances[j].name
+	})
+
+	if len(balances) > exactSettlementLimit {
+		return settleGroup(balances)
+	}
+
+	var transfers []Transfer
+	for _, group := range zeroSumGroups(balances) {
+		transfers = append(transfers, settleGroup(group)...)
+	}
+
+	return transfers
+}
+
+// zeroSumGroups splits balances into the maximal number of disjoint groups which sum up to 0.
+// Every group of k players can be settled with k-1 transfers, so maximizing the number
+// of groups minimizes the number of transfers.
+func zeroSumGroups(balances []balance) [][]balance {
+	n := len(balances)
+	if n == 0 {
+		return nil
+	}
+
+	full := 1<<n - 1
+	sums := make([]int64, full+1)
+	groups := make([]int, full+1) // max number of zero-sum groups the mask can be split into
+	for mask := 1; mask <= full; mask++ {
+		lowest := lowestBit(mask)
+		sums[mask] = sums[mask&^(1<<lowest)] + balances[lowest].amount
+
+		best := 0
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 && groups[mask&^(1<<i)] > best {
+				best = groups[mask&^(1<<i)]
+			}
+		}
+		if sums[mask] == 0 {
+			best++
+		}
+		groups[mask] = best
+	}
+
+	// go back from the full mask, every zero-sum mask on the way closes a group
+	var result [][]balance
+	var current []balance
+	for mask := full; mask != 0; {
+		gain := 0
+		if sums[mask] == 0 {
+			gain = 1
+		}
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 && groups[mask&^(1<<i)]+gain == groups[mask] {
+				current = append(current, balances[i])
+				mask &^= 1 << i
+				break
+			}
+		}
+		if sums[mask] == 0 {
+			result = append(result, current)
+			current = nil
+		}
+	}
+
+	return result
+}
+
+// settleGroup settles a zero-sum group with at most len(group)-1 transfers,
+// always matching the biggest debtor with the biggest creditor.
+func settleGroup(group []balance) []Transfer {
+	debtors := make([]balance, 0, len(group))
+	creditors := make([]balance, 0, len(group))
+	for _, b := range group {
+		if b.amount > 0 {
+			debtors = append(debtors, b)
+		} else if b.amount < 0 {
+			creditors = append(creditors, balance{name: b.name, amount: -b.amount})
+		}
+	}
+	byAmount := func(s []balance) func(i, j int) bool {
+		return func(i, j int) bool {
+			if s[i].amount == s[j].amount {
+				return s[i].name < s[j].name
+			}
+			return s[i].amount > s[j].amount
+		}
+	}
+	sort.Slice(debtors, byAmount(debtors))
+	sort.Slice(creditors, byAmount(creditors))
+
+	var transfers []Transfer
+	for d, c := 0, 0; d < len(debtors) && c < len(creditors); {
+		amount := debtors[d].amount
+		if creditors[c].amount < amount {
+			amount = creditors[c].amount
+		}
+
+		transfers = append(transfers, Transfer{
+			From:   debtors[d].name,
+			To:     creditors[c].name,
+			Amount: amount,
+		})
+
+		debtors[d].amount -= amount
+		creditors[c].amount -= amount
+		if debtors[d].amount == 0 {
+			d++
+		}
+		if creditors[c].amount == 0 {
+			c++
+		}
+	}
+
+	return transfers
+}
+
+func lowestBit(mask int) int {
+	i := 0
+	for mask&1 == 0 {
+		mask >>= 1
+		i++
+	}
+	return i
+}
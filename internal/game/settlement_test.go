@@ -0,0 +1,159 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// randomGame is a finished game which passes Verify, generated for property-based tests
+type randomGame struct {
+	players []Player
+}
+
+func (randomGame) Generate(r *rand.Rand, _ int) reflect.Value {
+	n := 1 + r.Intn(12)
+	players := make([]Player, n)
+	var pot int64
+	for i := range players {
+		players[i] = Player{
+			UserName:          fmt.Sprintf("player-%d", i),
+			BuyIn:             int64(r.Intn(10) * 100),
+			AdditionalIncomes: []inGameTransaction{},
+		}
+		pot += players[i].BuyIn
+	}
+
+	// some re-buy-ins paid by another players
+	for t := r.Intn(5); t > 0 && n > 1; t-- {
+		buyer, seller := r.Intn(n), r.Intn(n)
+		if buyer == seller {
+			continue
+		}
+		amount := int64(1 + r.Intn(300))
+		players[buyer].BuyIn += amount
+		players[seller].AdditionalIncomes = append(players[seller].AdditionalIncomes, inGameTransaction{
+			Amount:   amount,
+			FromName: players[buyer].UserName,
+		})
+		pot += amount
+	}
+
+	// distribute the pot randomly between players (minus additional incomes)
+	var incomes int64
+	for _, p := range players {
+		for _, a := range p.AdditionalIncomes {
+			incomes += a.Amount
+		}
+	}
+	left := pot - incomes
+	for i := range players {
+		stack := int64(0)
+		if i == n-1 {
+			stack = left
+		} else if left > 0 {
+			stack = r.Int63n(left + 1)
+		}
+		left -= stack
+		players[i].BuyOut = &stack
+	}
+
+	return reflect.ValueOf(randomGame{players: players})
+}
+
+func Test_Settlement_ZeroesAllBalances(t *testing.T) {
+	property := func(rg randomGame) bool {
+		g := &Game{Data: Data{Players: rg.players}}
+		if err := g.Verify(); err != nil {
+			t.Logf("generated game is not valid: %s", err)
+			return false
+		}
+
+		transfers, err := g.Settlement()
+		if err != nil {
+			t.Logf("cannot settle: %s", err)
+			return false
+		}
+
+		balances := g.Report()
+		for _, tr := range transfers {
+			if tr.Amount <= 0 || tr.From == tr.To {
+				t.Logf("invalid transfer: %+v", tr)
+				return false
+			}
+			balances[tr.From] -= tr.Amount
+			balances[tr.To] += tr.Amount
+		}
+
+		nonZero := 0
+		for name, b := range g.Report() {
+			if balances[name] != 0 {
+				t.Logf("balance of %s is not zeroed: %d", name, balances[name])
+				return false
+			}
+			if b != 0 {
+				nonZero++
+			}
+		}
+
+		return nonZero == 0 && len(transfers) == 0 || len(transfers) < nonZero
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Settlement_NotWorseThanGreedy(t *testing.T) {
+	property := func(rg randomGame) bool {
+		g := &Game{Data: Data{Players: rg.players}}
+		transfers, err := g.Settlement()
+		if err != nil {
+			return false
+		}
+
+		var balances []balance
+		for name, amount := range g.Report() {
+			balances = append(balances, balance{name: name, amount: amount})
+		}
+
+		return len(transfers) <= len(settleGroup(balances))
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Settlement_MinimalTransfers(t *testing.T) {
+	// a->c and b->d are independent, greedy would need 3 transfers
+	transfers := settle(map[string]int64{
+		"a": 70,
+		"b": 30,
+		"c": -30,
+		"d": -70,
+		"e": 0,
+	})
+	if len(transfers) != 2 {
+		t.Fatalf("expected 2 transfers, got: %+v", transfers)
+	}
+
+	transfers = settle(map[string]int64{
+		"a": 50,
+		"b": 40,
+		"c": -45,
+		"d": -45,
+	})
+	if len(transfers) != 3 {
+		t.Fatalf("expected 3 transfers, got: %+v", transfers)
+	}
+}
+
+func Test_Settlement_GameNotFinished(t *testing.T) {
+	g := &Game{Data: Data{Players: []Player{{UserName: "a", BuyIn: 100}}}}
+	if _, err := g.Settlement(); err == nil {
+		t.Fatalf("settlement of unfinished game should fail")
+	}
+}
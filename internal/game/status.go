@@ -0,0 +1,132 @@
+package game
+
+import (
+	"fmt"
+
+	"pokergo/pkg/id"
+)
+
+// Status is a state of the game lifecycle:
+//
+//	open -> finishing -> closed -> settled
+//	  \________\___________\-> cancelled
+//
+// Closed game may be reopened (it becomes finishing again), settled and cancelled games are final.
+type Status string
+
+const (
+	// StatusOpen the game is being played, players may join and re-buy
+	StatusOpen Status = "open"
+	// StatusFinishing at least one player has finished the game (has his final stack set)
+	StatusFinishing Status = "finishing"
+	// StatusClosed the game is verified and finished, it cannot be changed
+	StatusClosed Status = "closed"
+	// StatusSettled all the money has been transferred between players
+	StatusSettled Status = "settled"
+	// StatusCancelled the game has been cancelled and will be never settled
+	StatusCancelled Status = "cancelled"
+)
+
+// transitions lists states which can be reached from the given state
+var transitions = map[Status][]Status{ // nolint:gochecknoglobals // cannot be const
+	StatusOpen:      {StatusFinishing, StatusClosed, StatusCancelled},
+	StatusFinishing: {StatusClosed, StatusCancelled},
+	StatusClosed:    {StatusFinishing, StatusSettled, StatusCancelled},
+	StatusSettled:   {},
+	StatusCancelled: {},
+}
+
+// IsModifiable tells if players and their stacks can be changed in this state
+func (s Status) IsModifiable() bool {
+	return s == StatusOpen || s == StatusFinishing
+}
+
+// CanBecome tells if the game in state s can be moved to the state next
+func (s Status) CanBecome(next Status) bool {
+	for _, t := range transitions[s] {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+// status returns the current state of the game (games created before the lifecycle was introduced are open)
+func (d Data) status() Status {
+	if d.Status == "" {
+		return StatusOpen
+	}
+	return d.Status
+}
+
+// checkModifiable returns an error if the game cannot be changed anymore (must be called with playerMux locked)
+func (g *Game) checkModifiable() error {
+	if s := g.status(); !s.IsModifiable() {
+		return fmt.Errorf("%w: the game is %s", ErrGameNotModifiable, s)
+	}
+	return nil
+}
+
+// moveTo changes the game state (must be called with playerMux locked)
+func (g *Game) moveTo(by id.ID, next Status) error {
+	current := g.status()
+	if !current.CanBecome(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current, next)
+	}
+
+	g.gameLogger.Infof("game %s changes its state: %s -> %s", g.ID.Hex(), current, next)
+	return g.record(by, Event{Type: EventStatusChanged, Status: next})
+}
+
+// Close finishes the game, the game must pass Verify. After closing the game cannot be changed.
+// Final stacks of tournament players are set to their prizes (the stacks are not changed if the game
+// cannot be closed).
+func (g *Game) Close(by id.ID) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	recorded := len(g.Events)
+	if g.gameType() == TypeTournament {
+		if current := g.status(); !current.CanBecome(StatusClosed) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current, StatusClosed)
+		}
+		if err := g.recordPrizes(by); err != nil {
+			return g.rollback(recorded, fmt.Errorf("cannot close the tournament: %w", err))
+		}
+	}
+
+	if err := g.verify(); err != nil {
+		return g.rollback(recorded, fmt.Errorf("cannot close the game: %w", err))
+	}
+
+	return g.moveTo(by, StatusClosed)
+}
+
+// Reopen allows changing the closed game again
+func (g *Game) Reopen(by id.ID) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if g.status() != StatusClosed {
+		return fmt.Errorf("%w: only closed game can be reopened", ErrInvalidTransition)
+	}
+
+	return g.moveTo(by, StatusFinishing)
+}
+
+// Cancel cancels the game, cancelled game is never settled
+func (g *Game) Cancel(by id.ID) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	return g.moveTo(by, StatusCancelled)
+}
+
+// Settle marks the closed game as settled (all the transfers from Settlement were made),
+// the ledger of the organization still counts its debts until they are paid off by ledger payments
+func (g *Game) Settle(by id.ID) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	return g.moveTo(by, StatusSettled)
+}
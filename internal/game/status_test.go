@@ -0,0 +1,83 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+var testUser = id.NewID()
+
+func newTestGame(players ...Player) *Game {
+	return newGame(Data{ID: id.NewID(), Status: StatusOpen, Players: players}, nil, timer.NewUTCTimer())
+}
+
+func Test_Status_Lifecycle(t *testing.T) {
+	g := newTestGame(Player{UserName: "a", BuyIn: 100}, Player{UserName: "b", BuyIn: 100})
+
+	if err := g.Close(testUser); !errors.Is(err, ErrGameNotFinished) {
+		t.Fatalf("unfinished game should not be closed, err: %v", err)
+	}
+
+	if err := g.SetFinishStack(testUser, "a", 150); err != nil {
+		t.Fatalf("cannot set finish stack: %s", err)
+	}
+	if g.Status != StatusFinishing {
+		t.Fatalf("the game should be finishing, is: %s", g.Status)
+	}
+	if err := g.SetFinishStack(testUser, "b", 50); err != nil {
+		t.Fatalf("cannot set finish stack: %s", err)
+	}
+
+	if err := g.Close(testUser); err != nil {
+		t.Fatalf("cannot close the game: %s", err)
+	}
+	if g.Status != StatusClosed || g.End == nil {
+		t.Fatalf("the game should be closed with end time set, is: %s, %v", g.Status, g.End)
+	}
+
+	if err := g.ReBuyIn(testUser, "a", 100); !errors.Is(err, ErrGameNotModifiable) {
+		t.Fatalf("closed game should not be modifiable, err: %v", err)
+	}
+	if err := g.AppendPlayer(context.Background(), testUser, nil, "c", 100); !errors.Is(err, ErrGameNotModifiable) {
+		t.Fatalf("closed game should not be modifiable, err: %v", err)
+	}
+
+	if err := g.Reopen(testUser); err != nil {
+		t.Fatalf("cannot reopen the game: %s", err)
+	}
+	if g.Status != StatusFinishing || g.End != nil {
+		t.Fatalf("the game should be finishing without end time, is: %s, %v", g.Status, g.End)
+	}
+	if err := g.ReBuyIn(testUser, "a", 100); err != nil {
+		t.Fatalf("cannot re-buy-in in reopened game: %s", err)
+	}
+}
+
+func Test_Status_Transitions(t *testing.T) {
+	tcs := []struct {
+		from Status
+		to   Status
+		ok   bool
+	}{
+		{StatusOpen, StatusFinishing, true},
+		{StatusOpen, StatusClosed, true},
+		{StatusOpen, StatusSettled, false},
+		{StatusOpen, StatusCancelled, true},
+		{StatusFinishing, StatusOpen, false},
+		{StatusFinishing, StatusClosed, true},
+		{StatusClosed, StatusFinishing, true},
+		{StatusClosed, StatusSettled, true},
+		{StatusSettled, StatusCancelled, false},
+		{StatusCancelled, StatusOpen, false},
+	}
+
+	for _, tc := range tcs {
+		if tc.from.CanBecome(tc.to) != tc.ok {
+			t.Errorf("%s -> %s should be %v", tc.from, tc.to, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"pokergo/pkg/id"
+)
+
+// Type is a kind of the game
+type Type string
+
+const (
+	// TypeCash players buy in for any amount and leave with their final stack
+	TypeCash Type = "cash"
+	// TypeTournament players pay a fixed buy-in and the prize pool is paid out by finishing places
+	TypeTournament Type = "tournament"
+)
+
+// gameType returns the kind of the game (games created before tournaments were introduced are cash games)
+func (d Data) gameType() Type {
+	if d.Type == "" {
+		return TypeCash
+	}
+	return d.Type
+}
+
+// checkType returns ErrWrongGameType if the operation is not available for the game
+func (g *Game) checkType(t Type) error {
+	if current := g.gameType(); current != t {
+		return fmt.Errorf("%w: the operation is not available for %s games", ErrWrongGameType, current)
+	}
+	return nil
+}
+
+// PayoutKind tells how Payouts.Places are interpreted
+type PayoutKind string
+
+const (
+	// PayoutPercentage places get whole percents of the prize pool (must sum up to 100)
+	PayoutPercentage PayoutKind = "percentage"
+	// PayoutFixed places get fixed amounts in subunits (must not exceed the prize pool)
+	PayoutFixed PayoutKind = "fixed"
+)
+
+// Payouts is a payout structure, Places[0] is the prize for the winner.
+// Whatever is left in the prize pool (rounding, unpaid places) goes to the winner.
+type Payouts struct {
+	Kind   PayoutKind `json:"kind" bson:"kind"`
+	Places []int64    `json:"places" bson:"places"`
+}
+
+// prizes splits the pool between players finishing on first places (players is the number of players)
+func (p Payouts) prizes(pool int64, players int) ([]int64, error) {
+	places := len(p.Places)
+	if players < places {
+		places = players
+	}
+
+	prizes := make([]int64, places)
+	var paid int64
+	for i := range prizes {
+		switch p.Kind {
+		case PayoutPercentage:
+			prizes[i] = pool * p.Places[i] / 100
+		case PayoutFixed:
+			prizes[i] = p.Places[i]
+		}
+		paid += prizes[i]
+	}
+	if paid > pool {
+		return nil, fmt.Errorf("%w: %d > %d", ErrPayoutExceedsPool, paid, pool)
+	}
+	if places > 0 {
+		prizes[0] += pool - paid
+	}
+
+	return prizes, nil
+}
+
+// Period is a time window relative to the tournament start, [From, To)
+type Period struct {
+	From time.Duration `json:"from" bson:"from"`
+	To   time.Duration `json:"to" bson:"to"`
+}
+
+// contains tells if now is in the period of the tournament started at start (empty period contains nothing)
+func (p Period) contains(start, now time.Time) bool {
+	return !now.Before(start.Add(p.From)) && now.Before(start.Add(p.To))
+}
+
+// Tournament is a configuration of the tournament, it cannot be changed after the tournament is created
+type Tournament struct {
+	// BuyIn is paid to the prize pool by every player
+	BuyIn int64 `json:"buy_in" bson:"buy_in"`
+	// Fee is paid by every player to the organizer (it is not a part of the prize pool)
+	Fee int64 `json:"fee" bson:"fee"`
+
+	// Rebuy is the period when players may rebuy for RebuyAmount (also after being eliminated)
+	Rebuy       Period `json:"rebuy" bson:"rebuy"`
+	RebuyAmount int64  `json:"rebuy_amount" bson:"rebuy_amount"`
+	// MaxRebuys limits the number of rebuys of a player, 0 means no limit
+	MaxRebuys int `json:"max_rebuys" bson:"max_rebuys"`
+
+	// AddOn is the period when every player still in the tournament may buy an add-on (once) for AddOnAmount
+	AddOn       Period `json:"add_on" bson:"add_on"`
+	AddOnAmount int64  `json:"add_on_amount" bson:"add_on_amount"`
+
+	Payouts Payouts `json:"payouts" bson:"payouts"`
+}
+
+// Validate checks if the tournament can be played with the configuration
+func (t Tournament) Validate() error {
+	if t.BuyIn <= 0 || t.Fee < 0 {
+		return fmt.Errorf("%w: the buy-in must be positive and the fee cannot be negative", ErrInvalidTournament)
+	}
+	if t.Rebuy.To > t.Rebuy.From && t.RebuyAmount <= 0 {
+		return fmt.Errorf("%w: the rebuy amount must be positive", ErrInvalidTournament)
+	}
+	if t.AddOn.To > t.AddOn.From && t.AddOnAmount <= 0 {
+		return fmt.Errorf("%w: the add-on amount must be positive", ErrInvalidTournament)
+	}
+	if t.MaxRebuys < 0 {
+		return fmt.Errorf("%w: the rebuys limit cannot be negative", ErrInvalidTournament)
+	}
+
+	if len(t.Payouts.Places) == 0 {
+		return fmt.Errorf("%w: at least one place must be paid", ErrInvalidTournament)
+	}
+	var sum int64
+	for _, p := range t.Payouts.Places {
+		if p <= 0 {
+			return fmt.Errorf("%w: prizes must be positive", ErrInvalidTournament)
+		}
+		sum += p
+	}
+	switch t.Payouts.Kind {
+	case PayoutPercentage:
+		if sum != 100 {
+			return fmt.Errorf("%w: percentages sum up to %d, not 100", ErrInvalidTournament, sum)
+		}
+	case PayoutFixed:
+	default:
+		return fmt.Errorf("%w: unknown payout kind %s", ErrInvalidTournament, t.Payouts.Kind)
+	}
+
+	return nil
+}
+
+// Prize is money won by the player finishing on the Place
+type Prize struct {
+	Place    int    `json:"place"`
+	UserName string `json:"user_name"`
+	Amount   int64  `json:"amount"`
+}
+
+// Register registers a new player in the tournament, the player pays the buy-in and the fee
+func (g *Game) Register(ctx context.Context, by id.ID, uID *id.ID, name string) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkType(TypeTournament); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+	if g.isDecided() {
+		return ErrTournamentFinished
+	}
+
+	e, err := g.joinEvent(ctx, uID, name, g.Tournament.BuyIn)
+	if err != nil {
+		return err
+	}
+	if err := g.record(by, e); err != nil {
+		return err
+	}
+
+	g.gameLogger.Infof("player %s registered in tournament %s", e.UserName, g.ID.Hex())
+	return nil
+}
+
+// Rebuy buys the player in again (in the rebuy period only), eliminated player gets back to the tournament
+func (g *Game) Rebuy(by id.ID, name string) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkType(TypeTournament); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+	if !g.Tournament.Rebuy.contains(g.Start, g.timer.Now()) {
+		return fmt.Errorf("%w: rebuys are not allowed now", ErrPeriodClosed)
+	}
+
+	p, err := g.findPlayer(name)
+	if err != nil {
+		return err
+	}
+	if g.Tournament.MaxRebuys > 0 && p.Rebuys >= g.Tournament.MaxRebuys {
+		return ErrRebuyLimit
+	}
+
+	if err := g.record(by, Event{Type: EventReBuyIn, UserName: name, Amount: g.Tournament.RebuyAmount}); err != nil {
+		return err
+	}
+
+	g.gameLogger.Infof("player %s rebought in tournament %s", name, g.ID.Hex())
+	return nil
+}
+
+// AddOn buys the add-on for the player (once, in the add-on period only)
+func (g *Game) AddOn(by id.ID, name string) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkType(TypeTournament); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+	if !g.Tournament.AddOn.contains(g.Start, g.timer.Now()) {
+		return fmt.Errorf("%w: add-ons are not allowed now", ErrPeriodClosed)
+	}
+
+	p, err := g.findPlayer(name)
+	if err != nil {
+		return err
+	}
+	if p.Eliminated != 0 {
+		return ErrPlayerEliminated
+	}
+	if p.AddOn {
+		return fmt.Errorf("%w: the player has already bought the add-on", ErrUserExists)
+	}
+
+	if err := g.record(by, Event{Type: EventAddOn, UserName: name, Amount: g.Tournament.AddOnAmount}); err != nil {
+		return err
+	}
+
+	g.gameLogger.Infof("player %s bought the add-on in tournament %s", name, g.ID.Hex())
+	return nil
+}
+
+// Eliminate records the player has busted out of the tournament (the finishing place is set by the order)
+func (g *Game) Eliminate(by id.ID, name string) error {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	if err := g.checkType(TypeTournament); err != nil {
+		return err
+	}
+	if err := g.checkModifiable(); err != nil {
+		return err
+	}
+
+	p, err := g.findPlayer(name)
+	if err != nil {
+		return err
+	}
+	if p.Eliminated != 0 {
+		return ErrPlayerEliminated
+	}
+	if g.isDecided() || len(g.Players) < 2 {
+		return ErrTournamentFinished
+	}
+
+	if err := g.record(by, Event{Type: EventEliminated, UserName: name}); err != nil {
+		return err
+	}
+
+	g.gameLogger.Infof("player %s eliminated from tournament %s", name, g.ID.Hex())
+	return nil
+}
+
+// PrizePool is the sum of buy-ins, rebuys and add-ons (without fees)
+func (g *Game) PrizePool() int64 {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	return g.prizePool()
+}
+
+// prizePool is PrizePool without locking playerMux
+func (g *Game) prizePool() int64 {
+	var pool int64
+	for _, p := range g.Players {
+		pool += p.BuyIn
+	}
+	return pool
+}
+
+// Prizes returns prizes of paid places, the tournament must be decided (one player left)
+func (g *Game) Prizes() ([]Prize, error) {
+	g.playerMux.Lock()
+	defer g.playerMux.Unlock()
+
+	return g.prizes()
+}
+
+// prizes is Prizes without locking playerMux
+func (g *Game) prizes() ([]Prize, error) {
+	if err := g.checkType(TypeTournament); err != nil {
+		return nil, err
+	}
+	if !g.isDecided() {
+		return nil, ErrTournamentNotFinished
+	}
+
+	amounts, err := g.Tournament.Payouts.prizes(g.prizePool(), len(g.Players))
+	if err != nil {
+		return nil, err
+	}
+
+	standings := g.standings()
+	res := make([]Prize, 0, len(amounts))
+	for i, amount := range amounts {
+		res = append(res, Prize{Place: i + 1, UserName: standings[i].UserName, Amount: amount})
+	}
+	return res, nil
+}
+
+// standings returns players sorted by finishing places (players still in the tournament first)
+func (g *Game) standings() []Player {
+	res := make([]Player, len(g.Players))
+	copy(res, g.Players)
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Eliminated == 0 || res[j].Eliminated == 0 {
+			return res[i].Eliminated == 0 && res[j].Eliminated != 0
+		}
+		return res[i].Eliminated > res[j].Eliminated
+	})
+	return res
+}
+
+// isDecided tells if only one player is left in the tournament
+func (g *Game) isDecided() bool {
+	remaining := 0
+	for _, p := range g.Players {
+		if p.Eliminated == 0 {
+			remaining++
+		}
+	}
+	return remaining == 1 && len(g.Players) > 1
+}
+
+// recordPrizes sets final stacks of tournament players to their prizes (must be called with playerMux locked)
+func (g *Game) recordPrizes(by id.ID) error {
+	prizes, err := g.prizes()
+	if err != nil {
+		return err
+	}
+
+	won := make(map[string]int64, len(prizes))
+	for _, p := range prizes {
+		won[p.UserName] = p.Amount
+	}
+	for _, p := range g.standings() {
+		if err := g.record(by, Event{Type: EventFinishStack, UserName: p.UserName, Amount: won[p.UserName]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lastElimination returns the elimination order of the player eliminated most recently
+func (g *Game) lastElimination() int {
+	last := 0
+	for _, p := range g.Players {
+		if p.Eliminated > last {
+			last = p.Eliminated
+		}
+	}
+	return last
+}
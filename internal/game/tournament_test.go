@@ -0,0 +1,221 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+func newTestTournament(tm timer.Timer, t Tournament) *Game {
+	return newGame(Data{
+		ID:         id.NewID(),
+		Start:      tm.Now(),
+		Type:       TypeTournament,
+		Tournament: &t,
+		Status:     StatusOpen,
+	}, nil, tm)
+}
+
+func Test_Payouts_Prizes(t *testing.T) {
+	type tc struct {
+		name    string
+		payouts Payouts
+		pool    int64
+		players int
+		prizes  []int64
+		err     error
+	}
+
+	tcs := []tc{
+		{"percentage", Payouts{PayoutPercentage, []int64{50, 30, 20}}, 1000, 9, []int64{500, 300, 200}, nil},
+		{"rounding goes to the winner", Payouts{PayoutPercentage, []int64{50, 30, 20}}, 1001, 9, []int64{501, 300, 200}, nil},
+		{"unpaid places go to the winner", Payouts{PayoutPercentage, []int64{50, 30, 20}}, 1000, 2, []int64{700, 300}, nil},
+		{"fixed", Payouts{PayoutFixed, []int64{600, 400}}, 1000, 5, []int64{600, 400}, nil},
+		{"fixed below the pool", Payouts{PayoutFixed, []int64{600, 200}}, 1000, 5, []int64{800, 200}, nil},
+		{"fixed above the pool", Payouts{PayoutFixed, []int64{600, 500}}, 1000, 5, nil, ErrPayoutExceedsPool},
+	}
+
+	for _, test := range tcs {
+		t.Run(test.name, func(t *testing.T) {
+			prizes, err := test.payouts.prizes(test.pool, test.players)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got: %v", test.err, err)
+			}
+			if test.err == nil && !reflect.DeepEqual(prizes, test.prizes) {
+				t.Fatalf("expected prizes %v, got: %v", test.prizes, prizes)
+			}
+		})
+	}
+}
+
+func Test_Tournament_Validate(t *testing.T) {
+	valid := Tournament{BuyIn: 100, Fee: 10, Payouts: Payouts{PayoutPercentage, []int64{70, 30}}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("the tournament should be valid, err: %s", err)
+	}
+
+	invalid := map[string]func(t *Tournament){
+		"no buy-in":              func(t *Tournament) { t.BuyIn = 0 },
+		"negative fee":           func(t *Tournament) { t.Fee = -1 },
+		"no payouts":             func(t *Tournament) { t.Payouts.Places = nil },
+		"percentages not 100":    func(t *Tournament) { t.Payouts.Places = []int64{70, 20} },
+		"unknown payout kind":    func(t *Tournament) { t.Payouts.Kind = "other" },
+		"rebuys without amount":  func(t *Tournament) { t.Rebuy = Period{From: 0, To: time.Hour} },
+		"add-ons without amount": func(t *Tournament) { t.AddOn = Period{From: 0, To: time.Hour} },
+	}
+	for name, change := range invalid {
+		t.Run(name, func(t *testing.T) {
+			tournament := valid
+			tournament.Payouts.Places = append([]int64{}, valid.Payouts.Places...)
+			change(&tournament)
+			if err := tournament.Validate(); !errors.Is(err, ErrInvalidTournament) {
+				t.Fatalf("the tournament should be invalid, err: %v", err)
+			}
+		})
+	}
+}
+
+func Test_Tournament_Flow(t *testing.T) {
+	ctx := context.Background()
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	g := newTestTournament(tm, Tournament{
+		BuyIn:       100,
+		Fee:         10,
+		Rebuy:       Period{From: 0, To: time.Hour},
+		RebuyAmount: 100,
+		MaxRebuys:   1,
+		AddOn:       Period{From: time.Hour, To: 75 * time.Minute},
+		AddOnAmount: 50,
+		Payouts:     Payouts{PayoutPercentage, []int64{70, 30}},
+	})
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := g.Register(ctx, testUser, nil, name); err != nil {
+			t.Fatalf("cannot register %s: %s", name, err)
+		}
+	}
+	if err := g.AppendPlayer(ctx, testUser, nil, "d", 100); !errors.Is(err, ErrWrongGameType) {
+		t.Fatalf("players cannot join tournaments as cash games, err: %v", err)
+	}
+
+	// c busts out and comes back with a rebuy
+	if err := g.Eliminate(testUser, "c"); err != nil {
+		t.Fatalf("cannot eliminate: %s", err)
+	}
+	if err := g.Rebuy(testUser, "c"); err != nil {
+		t.Fatalf("cannot rebuy: %s", err)
+	}
+	if err := g.Rebuy(testUser, "c"); !errors.Is(err, ErrRebuyLimit) {
+		t.Fatalf("the rebuys limit should be reached, err: %v", err)
+	}
+	if err := g.AddOn(testUser, "a"); !errors.Is(err, ErrPeriodClosed) {
+		t.Fatalf("add-ons should not be allowed yet, err: %v", err)
+	}
+
+	tm.Advance(time.Hour)
+	if err := g.Rebuy(testUser, "a"); !errors.Is(err, ErrPeriodClosed) {
+		t.Fatalf("rebuys should not be allowed anymore, err: %v", err)
+	}
+	if err := g.AddOn(testUser, "a"); err != nil {
+		t.Fatalf("cannot buy the add-on: %s", err)
+	}
+
+	if _, err := g.Prizes(); !errors.Is(err, ErrTournamentNotFinished) {
+		t.Fatalf("prizes should not be known yet, err: %v", err)
+	}
+	if err := g.Eliminate(testUser, "b"); err != nil {
+		t.Fatalf("cannot eliminate: %s", err)
+	}
+	if err := g.Eliminate(testUser, "c"); err != nil {
+		t.Fatalf("cannot eliminate: %s", err)
+	}
+	if err := g.Eliminate(testUser, "a"); !errors.Is(err, ErrTournamentFinished) {
+		t.Fatalf("the winner cannot be eliminated, err: %v", err)
+	}
+
+	// pool = 3 buy-ins + rebuy + add-on
+	if pool := g.PrizePool(); pool != 450 {
+		t.Fatalf("invalid prize pool: %d", pool)
+	}
+	prizes, err := g.Prizes()
+	if err != nil {
+		t.Fatalf("cannot compute prizes: %s", err)
+	}
+	expected := []Prize{{1, "a", 315}, {2, "c", 135}}
+	if !reflect.DeepEqual(prizes, expected) {
+		t.Fatalf("expected prizes %v, got: %v", expected, prizes)
+	}
+
+	if err := g.Close(testUser); err != nil {
+		t.Fatalf("cannot close the tournament: %s", err)
+	}
+	report := g.Report()
+	if report["a"] != -165 || report["b"] != 100 || report["c"] != 65 {
+		t.Fatalf("invalid report: %v", report)
+	}
+	for _, p := range g.Players {
+		if p.Fee != 10 {
+			t.Fatalf("every player should pay the fee, got: %+v", p)
+		}
+	}
+}
+
+func Test_Tournament_UndoElimination(t *testing.T) {
+	ctx := context.Background()
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	g := newTestTournament(tm, Tournament{BuyIn: 100, Payouts: Payouts{PayoutFixed, []int64{200}}})
+
+	_ = g.Register(ctx, testUser, nil, "a")
+	_ = g.Register(ctx, testUser, nil, "b")
+	if err := g.Eliminate(testUser, "b"); err != nil {
+		t.Fatalf("cannot eliminate: %s", err)
+	}
+	if _, err := g.Undo(testUser); err != nil {
+		t.Fatalf("cannot undo: %s", err)
+	}
+	if err := g.Close(testUser); !errors.Is(err, ErrTournamentNotFinished) {
+		t.Fatalf("undecided tournament cannot be closed, err: %v", err)
+	}
+}
+
+func Test_Tournament_FailedCloseKeepsStacks(t *testing.T) {
+	ctx := context.Background()
+	tm := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	g := newTestTournament(tm, Tournament{BuyIn: 100, Payouts: Payouts{PayoutFixed, []int64{200}}})
+
+	_ = g.Register(ctx, testUser, nil, "a")
+	_ = g.Register(ctx, testUser, nil, "b")
+	_ = g.Eliminate(testUser, "b")
+	// nobody has played for a second yet, so the expense cannot be split and the game cannot be verified
+	if err := g.AddExpense(testUser, Expense{Description: "pizza", Amount: 20, Payer: "a", Split: SplitHours}); err != nil {
+		t.Fatalf("cannot add the expense: %s", err)
+	}
+	events := len(g.Events)
+
+	if err := g.Close(testUser); !errors.Is(err, ErrInvalidExpense) {
+		t.Fatalf("the game should not be verified, err: %v", err)
+	}
+	if len(g.Events) != events || g.Status != StatusOpen {
+		t.Fatalf("prizes should not be recorded, events: %d, status: %s", len(g.Events), g.Status)
+	}
+	for _, p := range g.Players {
+		if p.BuyOut != nil {
+			t.Fatalf("final stacks should not be set: %+v", p)
+		}
+	}
+
+	if _, err := g.Undo(testUser); err != nil {
+		t.Fatalf("cannot undo the expense: %s", err)
+	}
+	if err := g.Close(testUser); err != nil {
+		t.Fatalf("cannot close the tournament: %s", err)
+	}
+	if report := g.Report(); report["a"] != -100 || report["b"] != 100 {
+		t.Fatalf("invalid report: %v", report)
+	}
+}
@@ -0,0 +1,129 @@
+package hands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"pokergo/pkg/id"
+	"pokergo/pkg/pointers"
+)
+
+// Adapter stores hand histories
+type Adapter interface {
+	// Save stores a new hand, it returns ErrHandExists if the hand with the same source and external id
+	// has already been saved in the organization
+	Save(ctx context.Context, hand Hand) error
+	// FindHandByID returns the hand or ErrHandNotExists
+	FindHandByID(ctx context.Context, handID id.ID) (Hand, error)
+	// ListHands returns no hands of the organization (of the game if gameID is not nil), newest first,
+	// starting after lastDocID
+	ListHands(ctx context.Context, orgID id.ID, gameID *id.ID, lastDocID id.ID, no int) ([]Hand, error)
+}
+
+type mongoAdapter struct {
+	coll *mongo.Collection
+}
+
+func NewMongoAdapter(coll *mongo.Collection) *mongoAdapter {
+	return &mongoAdapter{coll: coll}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "organization", Value: 1},
+				{Key: "_id", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "game_id", Value: 1},
+				{Key: "_id", Value: -1},
+			},
+			Options: &options.IndexOptions{Sparse: pointers.Pointer(true)},
+		},
+		{
+			Keys: bson.D{
+				{Key: "organization", Value: 1},
+				{Key: "source", Value: 1},
+				{Key: "external_id", Value: 1},
+			},
+			Options: &options.IndexOptions{
+				Unique:                  pointers.Pointer(true),
+				PartialFilterExpression: bson.M{"external_id": bson.M{"$exists": true}},
+			},
+		},
+	}
+
+	_, err := m.coll.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("cannot create hands indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) Save(ctx context.Context, hand Hand) error {
+	if _, err := m.coll.InsertOne(ctx, hand); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrHandExists
+		}
+		return fmt.Errorf("cannot save the hand in mongo: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoAdapter) FindHandByID(ctx context.Context, handID id.ID) (Hand, error) {
+	res := m.coll.FindOne(ctx, bson.M{"_id": handID})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Hand{}, ErrHandNotExists
+		}
+		return Hand{}, fmt.Errorf("cannot perform query: %w", err)
+	}
+
+	var hand Hand
+	if err := res.Decode(&hand); err != nil {
+		return Hand{}, fmt.Errorf("cannot decode the hand: %w", err)
+	}
+
+	return hand, nil
+}
+
+func (m *mongoAdapter) ListHands(ctx context.Context, orgID id.ID, gameID *id.ID, lastDocID id.ID, no int) ([]Hand, error) {
+	opts := &options.FindOptions{
+		Limit: pointers.Pointer(int64(no)),
+		Sort:  bson.M{"_id": -1},
+	}
+
+	query := bson.M{
+		"organization": orgID,
+	}
+	if gameID != nil {
+		query["game_id"] = *gameID
+	}
+	if !lastDocID.IsZero() {
+		query["_id"] = bson.M{
+			"$lt": lastDocID,
+		}
+	}
+
+	cur, err := m.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get hands: %w", err)
+	}
+
+	var hands []Hand
+	if err := cur.All(ctx, &hands); err != nil {
+		return nil, fmt.Errorf("cannot bind hands: %w", err)
+	}
+
+	return hands, nil
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
@@ -0,0 +1,188 @@
+package hands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/poker"
+)
+
+var (
+	ErrInvalidHand   = errors.New("invalid hand history")
+	ErrHandNotExists = errors.New("the hand does not exist")
+	ErrHandExists    = errors.New("the hand has already been saved")
+)
+
+// MaxSeats is the maximal number of players in the hand
+const MaxSeats = 10
+
+// Street is a betting round of the hand
+type Street string
+
+const (
+	Preflop Street = "preflop"
+	Flop    Street = "flop"
+	Turn    Street = "turn"
+	River   Street = "river"
+)
+
+// streets are betting rounds in the order they are played
+var streets = []Street{Preflop, Flop, Turn, River} // nolint:gochecknoglobals // cannot be const
+
+// boardCards returns the number of board cards visible in the street (-1 for unknown streets)
+func (s Street) boardCards() int {
+	switch s {
+	case Preflop:
+		return 0
+	case Flop:
+		return 3
+	case Turn:
+		return 4
+	case River:
+		return 5
+	}
+	return -1
+}
+
+// ActionType is a decision of the player or a forced bet
+type ActionType string
+
+const (
+	ActionAnte       ActionType = "ante"
+	ActionSmallBlind ActionType = "small_blind"
+	ActionBigBlind   ActionType = "big_blind"
+	ActionFold       ActionType = "fold"
+	ActionCheck      ActionType = "check"
+	ActionCall       ActionType = "call"
+	ActionBet        ActionType = "bet"
+	ActionRaise      ActionType = "raise"
+)
+
+// Seat is a player dealt into the hand
+type Seat struct {
+	Seat     int    `bson:"seat" json:"seat"`
+	UserName string `bson:"user_name" json:"user_name"`
+	// UserID may be nil for anonymous players
+	UserID *id.ID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	// Stack is the stack at the beginning of the hand
+	Stack int64 `bson:"stack" json:"stack"`
+	// Cards are hole cards, e.g. "AsKd", empty if unknown
+	Cards string `bson:"cards,omitempty" json:"cards,omitempty"`
+}
+
+// Action is a single action in the hand
+type Action struct {
+	Street Street     `bson:"street" json:"street"`
+	Seat   int        `bson:"seat" json:"seat"`
+	Type   ActionType `bson:"type" json:"type"`
+	// Amount are chips put into the pot by the action (0 for folds and checks),
+	// e.g. a raise from 20 to 60 puts 40 if the player has already bet 20 in the street
+	Amount int64 `bson:"amount" json:"amount"`
+}
+
+// Award is a part of the pot won by the player
+type Award struct {
+	Seat   int   `bson:"seat" json:"seat"`
+	Amount int64 `bson:"amount" json:"amount"`
+}
+
+// Pot is the main pot or a side pot with its winners
+type Pot struct {
+	Amount  int64   `bson:"amount" json:"amount"`
+	Winners []Award `bson:"winners" json:"winners"`
+}
+
+// Hand is a structured hand history
+type Hand struct {
+	ID           id.ID `bson:"_id" json:"id"` // nolint:tagliatelle // mongo-id
+	Organization id.ID `bson:"organization" json:"organization"`
+	// GameID is the live game the hand was played in (optional)
+	GameID     *id.ID    `bson:"game_id,omitempty" json:"game_id,omitempty"`
+	RecordedBy id.ID     `bson:"recorded_by" json:"recorded_by"`
+	PlayedAt   time.Time `bson:"played_at" json:"played_at"`
+	// Note is a comment of the recording player, e.g. why the hand is notable
+	Note string `bson:"note,omitempty" json:"note,omitempty"`
+	// Source is the site the hand was imported from (e.g. SourcePokerStars), empty for live hands
+	Source string `bson:"source,omitempty" json:"source,omitempty"`
+	// ExternalID is the id of the hand in the source, a hand is imported once to the organization
+	ExternalID string `bson:"external_id,omitempty" json:"external_id,omitempty"`
+	// Currency is set if amounts are money in minor units (e.g. USD cents), empty for chips
+	Currency string `bson:"currency,omitempty" json:"currency,omitempty"`
+
+	SmallBlind int64 `bson:"small_blind" json:"small_blind"`
+	BigBlind   int64 `bson:"big_blind" json:"big_blind"`
+	Ante       int64 `bson:"ante,omitempty" json:"ante,omitempty"`
+	// Button is the seat of the dealer button
+	Button int    `bson:"button" json:"button"`
+	Seats  []Seat `bson:"seats" json:"seats"`
+	// Board are community cards, e.g. "AhKd2c7s"
+	Board   string   `bson:"board" json:"board"`
+	Actions []Action `bson:"actions" json:"actions"`
+	Pots    []Pot    `bson:"pots" json:"pots"`
+	// Returned are uncalled bets given back to players (they are not a part of pots)
+	Returned []Award `bson:"returned,omitempty" json:"returned,omitempty"`
+	// Rake is the amount taken from pots by the house
+	Rake int64 `bson:"rake,omitempty" json:"rake,omitempty"`
+}
+
+// Validate checks if the hand could have been played: cards, the order of actions and the pot distribution
+func (h Hand) Validate() error {
+	if len(h.Seats) < 2 || len(h.Seats) > MaxSeats {
+		return fmt.Errorf("%w: the hand must have from 2 to %d players", ErrInvalidHand, MaxSeats)
+	}
+	if h.BigBlind <= 0 || h.SmallBlind < 0 || h.Ante < 0 || h.Rake < 0 {
+		return fmt.Errorf("%w: the big blind must be positive, the small blind, ante and rake cannot be negative", ErrInvalidHand)
+	}
+
+	seats, names := make(map[int]bool), make(map[string]bool)
+	var used poker.CardSet
+	for _, s := range h.Seats {
+		switch {
+		case s.Seat < 0 || s.Seat >= MaxSeats:
+			return fmt.Errorf("%w: seat %d does not exist", ErrInvalidHand, s.Seat)
+		case seats[s.Seat]:
+			return fmt.Errorf("%w: seat %d is taken twice", ErrInvalidHand, s.Seat)
+		case s.UserName == "" || names[s.UserName]:
+			return fmt.Errorf("%w: names of players must be unique and not empty", ErrInvalidHand)
+		case s.Stack <= 0:
+			return fmt.Errorf("%w: the stack of %s must be positive", ErrInvalidHand, s.UserName)
+		}
+		seats[s.Seat], names[s.UserName] = true, true
+
+		cards, err := poker.ParseCards(s.Cards)
+		if err != nil || len(cards) != 0 && len(cards) != 2 {
+			return fmt.Errorf("%w: %s must have 2 hole cards or none", ErrInvalidHand, s.UserName)
+		}
+		if used, err = addCards(used, cards); err != nil {
+			return err
+		}
+	}
+	if h.Button < 0 || h.Button >= MaxSeats {
+		// the button may be at an empty seat (a dead button)
+		return fmt.Errorf("%w: seat %d of the button does not exist", ErrInvalidHand, h.Button)
+	}
+
+	board, err := poker.ParseCards(h.Board)
+	if err != nil || len(board) == 1 || len(board) == 2 || len(board) > 5 {
+		return fmt.Errorf("%w: the board must have 0, 3, 4 or 5 cards", ErrInvalidHand)
+	}
+	if _, err := addCards(used, board); err != nil {
+		return err
+	}
+
+	_, err = Replay(h)
+	return err
+}
+
+// addCards adds cards to the set of used cards, a card cannot be used twice
+func addCards(used poker.CardSet, cards []poker.Card) (poker.CardSet, error) {
+	for _, c := range cards {
+		if used.Has(c) {
+			return used, fmt.Errorf("%w: %s is used twice", ErrInvalidHand, c)
+		}
+		used = used.Add(c)
+	}
+	return used, nil
+}
@@ -0,0 +1,141 @@
+package hands
+
+import (
+	"errors"
+	"testing"
+)
+
+// testHand is an all-in preflop of Alice and Bob, Carol folds the big blind
+func testHand() Hand {
+	return Hand{
+		SmallBlind: 5,
+		BigBlind:   10,
+		Button:     0,
+		Seats: []Seat{
+			{Seat: 0, UserName: "Alice", Stack: 1000, Cards: "AsKs"},
+			{Seat: 1, UserName: "Bob", Stack: 500, Cards: "QhQd"},
+			{Seat: 2, UserName: "Carol", Stack: 1000},
+		},
+		Board: "2c7d9hJsTc",
+		Actions: []Action{
+			{Street: Preflop, Seat: 1, Type: ActionSmallBlind, Amount: 5},
+			{Street: Preflop, Seat: 2, Type: ActionBigBlind, Amount: 10},
+			{Street: Preflop, Seat: 0, Type: ActionRaise, Amount: 30},
+			{Street: Preflop, Seat: 1, Type: ActionRaise, Amount: 495},
+			{Street: Preflop, Seat: 2, Type: ActionFold},
+			{Street: Preflop, Seat: 0, Type: ActionCall, Amount: 470},
+		},
+		Pots: []Pot{{Amount: 1010, Winners: []Award{{Seat: 1, Amount: 1010}}}},
+	}
+}
+
+func TestReplay(t *testing.T) {
+	h := testHand()
+	if err := h.Validate(); err != nil {
+		t.Fatalf("the hand is valid: %s", err)
+	}
+	steps, err := Replay(h)
+	if err != nil {
+		t.Fatalf("cannot replay: %s", err)
+	}
+
+	descriptions := []string{
+		"Cards are dealt, the button is at seat 0",
+		"Bob posts the small blind 5",
+		"Carol posts the big blind 10",
+		"Alice raises to 30",
+		"Bob raises to 500 and is all-in",
+		"Carol folds",
+		"Alice calls 470",
+		"Flop: 2c7d9h",
+		"Turn: Js",
+		"River: Tc",
+		"Bob wins 1010 from the main pot",
+	}
+	if len(steps) != len(descriptions) {
+		t.Fatalf("expected %d steps, got %d", len(descriptions), len(steps))
+	}
+	for i, d := range descriptions {
+		if steps[i].Description != d {
+			t.Errorf("step %d: expected %q, got %q", i+1, d, steps[i].Description)
+		}
+	}
+
+	if s := steps[6]; s.Pot != 1010 || s.Board != "" || s.Seats[0].Stack != 500 || s.Seats[0].Bet != 500 {
+		t.Errorf("unexpected state after the call: %+v", s)
+	}
+	if s := steps[9]; s.Street != River || s.Board != "2c7d9hJsTc" {
+		t.Errorf("unexpected state on the river: %+v", s)
+	}
+	last := steps[len(steps)-1]
+	for i, stack := range []int64{500, 1010, 990} {
+		if last.Seats[i].Stack != stack {
+			t.Errorf("%s: expected stack %d, got %d", last.Seats[i].UserName, stack, last.Seats[i].Stack)
+		}
+	}
+	if last.Pot != 0 || len(last.Awards) != 1 {
+		t.Errorf("the pot must be given away: %+v", last)
+	}
+}
+
+func TestReplayReturnedBet(t *testing.T) {
+	h := Hand{
+		SmallBlind: 5,
+		BigBlind:   10,
+		Button:     3,
+		Seats: []Seat{
+			{Seat: 5, UserName: "Eve", Stack: 100},
+			{Seat: 3, UserName: "Dan", Stack: 200},
+		},
+		Board: "2c7d9hJsTc",
+		Actions: []Action{
+			{Street: Preflop, Seat: 3, Type: ActionSmallBlind, Amount: 5},
+			{Street: Preflop, Seat: 5, Type: ActionBigBlind, Amount: 10},
+			{Street: Preflop, Seat: 3, Type: ActionRaise, Amount: 195},
+			{Street: Preflop, Seat: 5, Type: ActionCall, Amount: 90},
+		},
+		Pots:     []Pot{{Amount: 200, Winners: []Award{{Seat: 5, Amount: 200}}}},
+		Returned: []Award{{Seat: 3, Amount: 100}},
+	}
+	if err := h.Validate(); err != nil {
+		t.Fatalf("the hand is valid: %s", err)
+	}
+	steps, _ := Replay(h)
+
+	last := steps[len(steps)-1]
+	if last.Description != "100 is returned to Dan, Eve wins 200 from the main pot" {
+		t.Errorf("unexpected description: %q", last.Description)
+	}
+	// seats are ordered
+	if last.Seats[0].UserName != "Dan" || last.Seats[0].Stack != 100 || last.Seats[1].Stack != 200 {
+		t.Errorf("unexpected stacks: %+v", last.Seats)
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	tests := map[string]func(h *Hand){
+		"one player":         func(h *Hand) { h.Seats = h.Seats[:1] },
+		"duplicated card":    func(h *Hand) { h.Seats[1].Cards = "AsQd" },
+		"card on the board":  func(h *Hand) { h.Board = "2c7d9hJsKs" },
+		"two board cards":    func(h *Hand) { h.Board = "2c7d" },
+		"same seat":          func(h *Hand) { h.Seats[2].Seat = 1 },
+		"wrong call":         func(h *Hand) { h.Actions[5].Amount = 400 },
+		"check facing bet":   func(h *Hand) { h.Actions[4].Type = ActionCheck },
+		"raise too small":    func(h *Hand) { h.Actions[2].Amount = 10 },
+		"action after fold":  func(h *Hand) { h.Actions = append(h.Actions, Action{Street: Flop, Seat: 2, Type: ActionCheck}) },
+		"more than stack":    func(h *Hand) { h.Actions[3].Amount = 600 },
+		"pots do not sum up": func(h *Hand) { h.Pots[0].Amount, h.Pots[0].Winners[0].Amount = 1000, 1000 },
+		"unassigned pot":     func(h *Hand) { h.Pots[0].Winners[0].Amount = 1000 },
+		"folded winner":      func(h *Hand) { h.Pots[0].Winners[0].Seat = 2 },
+		"unknown street":     func(h *Hand) { h.Actions[5].Street = "showdown" },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := testHand()
+			modify(&h)
+			if err := h.Validate(); !errors.Is(err, ErrInvalidHand) {
+				t.Errorf("expected ErrInvalidHand, got %v", err)
+			}
+		})
+	}
+}
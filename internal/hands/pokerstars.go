@@ -0,0 +1,416 @@
+package hands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // hand histories use the ET time zone
+)
+
+// SourcePokerStars is the source of hands imported from PokerStars hand history files
+const SourcePokerStars = "pokerstars"
+
+var ErrUnsupportedHand = errors.New("unsupported hand history")
+
+// ParsedHand is a hand read from a hand history file
+type ParsedHand struct {
+	// ExternalID is the number of the hand in the file
+	ExternalID string
+	// Line is the first line of the hand in the file
+	Line int
+	// Hand is the valid hand (without ids of the organization and the recording user), if Err is nil
+	Hand Hand
+	Err  error
+}
+
+// nolint:gochecknoglobals // cannot be const
+var (
+	psHeader = regexp.MustCompile(`^PokerStars .*Hand #(\d+):\s*(.*)$`)
+	psBlinds = regexp.MustCompile(`\(([$€£]?)([\d.,]+)/[$€£]?([\d.,]+)`)
+	psTimeET = regexp.MustCompile(`\[(\d{4}/\d{2}/\d{2} \d{1,2}:\d{2}:\d{2}) ET\]`)
+	psTime   = regexp.MustCompile(`(\d{4}/\d{2}/\d{2} \d{1,2}:\d{2}:\d{2}) (\w+)$`)
+	psTable  = regexp.MustCompile(`^Table '([^']*)' .*Seat #(\d+) is the button`)
+	psSeat   = regexp.MustCompile(`^Seat (\d+): (.+) \(([$€£]?[\d.,]+) in chips[^)]*\)(.*)$`)
+	psStreet = regexp.MustCompile(`^\*\*\* ([A-Z ]+) \*\*\*`)
+	psCards  = regexp.MustCompile(`\[([^\]]+)\]$`)
+	psDealt  = regexp.MustCompile(`^Dealt to (.+) \[([^\]]+)\]$`)
+	psReturn = regexp.MustCompile(`^Uncalled bet \(([$€£]?[\d.,]+)\) returned to (.+)$`)
+	psWon    = regexp.MustCompile(`^(.+) collected ([$€£]?[\d.,]+) from (pot|main pot|side pot|side pot-(\d+))$`)
+	psRake   = regexp.MustCompile(`\| Rake ([$€£]?[\d.,]+)`)
+	psRaise  = regexp.MustCompile(`^raises ([$€£]?[\d.,]+) to ([$€£]?[\d.,]+)$`)
+
+	psCurrencies = map[string]string{"$": "USD", "€": "EUR", "£": "GBP"}
+	// psIgnored are messages of players which do not change the hand
+	psIgnored = []string{"mucks hand", "doesn't show hand", "is sitting out", "sits out", "is connected",
+		"is disconnected", "has timed out", "has returned", "leaves the table", "is away"}
+)
+
+// ParsePokerStars reads no-limit hold'em hands (cash games and tournaments) from the PokerStars hand history file.
+// Hands which cannot be parsed or are not valid have Err set, other hands are returned with the source
+// and the external id set. Amounts of cash games are in cents.
+func ParsePokerStars(r io.Reader) ([]ParsedHand, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var parsed []ParsedHand
+	var lines []string
+	flush := func() {
+		if len(parsed) == 0 || lines == nil {
+			return
+		}
+		last := &parsed[len(parsed)-1]
+		last.Hand, last.Err = parsePokerStarsHand(lines)
+		lines = nil
+	}
+	for no := 1; scanner.Scan(); no++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if m := psHeader.FindStringSubmatch(line); m != nil {
+			flush()
+			parsed = append(parsed, ParsedHand{ExternalID: m[1], Line: no})
+			lines = []string{}
+		}
+		if lines != nil && line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the hand history: %w", err)
+	}
+	flush()
+
+	return parsed, nil
+}
+
+// psParser is the state of the hand being parsed
+type psParser struct {
+	h Hand
+	// money is true if amounts have the currency (cash games)
+	money  bool
+	seats  map[string]int // by names of players
+	street Street
+	bets   map[int]int64 // chips put by seats in the current street
+	pots   map[int][]Award
+}
+
+// parsePokerStarsHand parses lines of the hand, the first line is the header
+func parsePokerStarsHand(lines []string) (Hand, error) {
+	p := &psParser{
+		h:      Hand{Source: SourcePokerStars},
+		seats:  make(map[string]int),
+		street: Preflop,
+		bets:   make(map[int]int64),
+		pots:   make(map[int][]Award),
+	}
+	if err := p.header(lines[0]); err != nil {
+		return Hand{}, err
+	}
+
+	summary := false
+	for _, line := range lines[1:] {
+		var err error
+		switch {
+		case summary:
+			err = p.summary(line)
+		case psStreet.MatchString(line):
+			summary, err = p.nextStreet(psStreet.FindStringSubmatch(line)[1], line)
+		default:
+			err = p.line(line)
+		}
+		if err != nil {
+			return Hand{}, err
+		}
+	}
+
+	for i := 0; i < len(p.pots); i++ {
+		winners, ok := p.pots[i]
+		if !ok {
+			return Hand{}, fmt.Errorf("%w: side pot %d is not collected", ErrInvalidHand, i)
+		}
+		pot := Pot{Winners: winners}
+		for _, w := range winners {
+			pot.Amount += w.Amount
+		}
+		p.h.Pots = append(p.h.Pots, pot)
+	}
+	sort.Slice(p.h.Seats, func(i, j int) bool { return p.h.Seats[i].Seat < p.h.Seats[j].Seat })
+
+	if err := p.h.Validate(); err != nil {
+		return Hand{}, err
+	}
+	return p.h, nil
+}
+
+// header parses the first line, e.g.
+// PokerStars Hand #243456789012:  Hold'em No Limit ($0.01/$0.02 USD) - 2023/03/12 14:22:01 CET [2023/03/12 9:22:01 ET]
+func (p *psParser) header(line string) error {
+	m := psHeader.FindStringSubmatch(line)
+	p.h.ExternalID = m[1]
+	if !strings.Contains(m[2], "Hold'em No Limit") {
+		return fmt.Errorf("%w: only no-limit hold'em is supported", ErrUnsupportedHand)
+	}
+
+	blinds := psBlinds.FindStringSubmatch(m[2])
+	if blinds == nil {
+		return fmt.Errorf("%w: no blinds in the header", ErrInvalidHand)
+	}
+	p.money = blinds[1] != ""
+	p.h.Currency = psCurrencies[blinds[1]]
+	var err error
+	if p.h.SmallBlind, err = p.amount(blinds[2]); err != nil {
+		return err
+	}
+	if p.h.BigBlind, err = p.amount(blinds[3]); err != nil {
+		return err
+	}
+
+	if p.h.PlayedAt, err = psPlayedAt(m[2]); err != nil {
+		return err
+	}
+	if i := strings.Index(m[2], "Tournament #"); i >= 0 {
+		p.h.Note = "PokerStars " + strings.SplitN(m[2][i:], ",", 2)[0]
+	}
+	return nil
+}
+
+// psPlayedAt returns the time of the hand, the time in ET is used if there are two times
+func psPlayedAt(header string) (time.Time, error) {
+	value, zone := "", ""
+	if m := psTimeET.FindStringSubmatch(header); m != nil {
+		value, zone = m[1], "ET"
+	} else if m := psTime.FindStringSubmatch(header); m != nil {
+		value, zone = m[1], m[2]
+	} else {
+		return time.Time{}, fmt.Errorf("%w: no time in the header", ErrInvalidHand)
+	}
+	if zone == "ET" {
+		zone = "America/New_York"
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown time zone %s", ErrInvalidHand, zone)
+	}
+	t, err := time.ParseInLocation("2006/01/02 15:04:05", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid time: %s", ErrInvalidHand, err.Error())
+	}
+	return t.UTC(), nil
+}
+
+// nextStreet starts the street, it returns true for the summary
+func (p *psParser) nextStreet(name string, line string) (bool, error) {
+	var street Street
+	switch name {
+	case "HOLE CARDS", "SHOW DOWN":
+		return false, nil
+	case "SUMMARY":
+		return true, nil
+	case "FLOP":
+		street = Flop
+	case "TURN":
+		street = Turn
+	case "RIVER":
+		street = River
+	default:
+		return false, fmt.Errorf("%w: %q is not supported", ErrUnsupportedHand, line)
+	}
+	p.street = street
+	p.bets = make(map[int]int64)
+	return false, nil
+}
+
+// line parses a line before the summary
+func (p *psParser) line(line string) error {
+	if m := psTable.FindStringSubmatch(line); m != nil {
+		button, _ := strconv.Atoi(m[2])
+		p.h.Button = button - 1
+		if m[1] != "" && p.h.Note == "" {
+			p.h.Note = fmt.Sprintf("PokerStars, table '%s'", m[1])
+		}
+		return nil
+	}
+	if m := psSeat.FindStringSubmatch(line); m != nil && len(p.h.Actions) == 0 {
+		if strings.Contains(m[4], "is sitting out") {
+			return nil
+		}
+		seat, _ := strconv.Atoi(m[1])
+		stack, err := p.amount(m[3])
+		if err != nil {
+			return err
+		}
+		p.seats[m[2]] = seat - 1
+		p.h.Seats = append(p.h.Seats, Seat{Seat: seat - 1, UserName: m[2], Stack: stack})
+		return nil
+	}
+	if m := psDealt.FindStringSubmatch(line); m != nil {
+		return p.cards(m[1], m[2])
+	}
+	if m := psReturn.FindStringSubmatch(line); m != nil {
+		return p.award(m[2], m[1], -1)
+	}
+	if m := psWon.FindStringSubmatch(line); m != nil {
+		pot := 0
+		switch {
+		case m[4] != "":
+			pot, _ = strconv.Atoi(m[4])
+		case m[3] == "side pot":
+			pot = 1
+		}
+		return p.award(m[1], m[2], pot)
+	}
+
+	name := p.player(line)
+	if name == "" {
+		// chat and messages of the table
+		return nil
+	}
+	return p.action(name, strings.TrimPrefix(line, name+": "))
+}
+
+// player returns the name of the player (the longest one) the line starts with, empty if none
+func (p *psParser) player(line string) string {
+	name := ""
+	for n := range p.seats {
+		if len(n) > len(name) && strings.HasPrefix(line, n+": ") {
+			name = n
+		}
+	}
+	return name
+}
+
+// action parses the action of the player, e.g. "raises $0.04 to $0.06"
+func (p *psParser) action(name string, line string) error {
+	seat := p.seats[name]
+	for _, ignored := range psIgnored {
+		if strings.HasPrefix(line, ignored) {
+			return nil
+		}
+	}
+	if strings.HasPrefix(line, "shows ") {
+		if m := psCards.FindStringSubmatch(strings.SplitN(line, " (", 2)[0]); m != nil {
+			return p.cards(name, m[1])
+		}
+		return nil
+	}
+
+	line = strings.TrimSuffix(line, " and is all-in")
+	fields := strings.Fields(line)
+	a := Action{Street: p.street, Seat: seat}
+	var err error
+	switch {
+	case len(fields) == 0:
+		return fmt.Errorf("%w: empty action of %s", ErrInvalidHand, name)
+	case fields[0] == "folds":
+		a.Type = ActionFold
+	case fields[0] == "checks":
+		a.Type = ActionCheck
+	case fields[0] == "calls" && len(fields) == 2:
+		a.Type = ActionCall
+		a.Amount, err = p.amount(fields[1])
+	case fields[0] == "bets" && len(fields) == 2:
+		a.Type = ActionBet
+		a.Amount, err = p.amount(fields[1])
+	case psRaise.MatchString(line):
+		var to int64
+		a.Type = ActionRaise
+		to, err = p.amount(psRaise.FindStringSubmatch(line)[2])
+		a.Amount = to - p.bets[seat]
+	case strings.HasPrefix(line, "posts small blind "):
+		a.Type = ActionSmallBlind
+		a.Amount, err = p.amount(fields[len(fields)-1])
+	case strings.HasPrefix(line, "posts big blind "):
+		a.Type = ActionBigBlind
+		a.Amount, err = p.amount(fields[len(fields)-1])
+	case strings.HasPrefix(line, "posts the ante "):
+		a.Type = ActionAnte
+		a.Amount, err = p.amount(fields[len(fields)-1])
+	default:
+		return fmt.Errorf("%w: unknown action %q of %s", ErrUnsupportedHand, line, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if a.Type == ActionAnte {
+		p.h.Ante = max64(p.h.Ante, a.Amount)
+	} else {
+		p.bets[seat] += a.Amount
+	}
+	p.h.Actions = append(p.h.Actions, a)
+	return nil
+}
+
+// summary parses a line of the summary, e.g. "Total pot $3.97 | Rake $0.14" or "Board [2c 7d Ts Js 3h]"
+func (p *psParser) summary(line string) error {
+	if m := psRake.FindStringSubmatch(line); m != nil && strings.HasPrefix(line, "Total pot") {
+		rake, err := p.amount(m[1])
+		p.h.Rake = rake
+		return err
+	}
+	if strings.HasPrefix(line, "Board ") {
+		if m := psCards.FindStringSubmatch(line); m != nil {
+			p.h.Board = strings.ReplaceAll(m[1], " ", "")
+		}
+	}
+	return nil
+}
+
+// cards sets hole cards of the player
+func (p *psParser) cards(name, cards string) error {
+	for i := range p.h.Seats {
+		if p.h.Seats[i].UserName == name {
+			p.h.Seats[i].Cards = strings.ReplaceAll(cards, " ", "")
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not dealt in", ErrInvalidHand, name)
+}
+
+// award gives the amount to the player from the pot (-1 for returned bets)
+func (p *psParser) award(name, amount string, pot int) error {
+	seat, ok := p.seats[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is not dealt in", ErrInvalidHand, name)
+	}
+	a, err := p.amount(amount)
+	if err != nil {
+		return err
+	}
+	if pot < 0 {
+		p.h.Returned = append(p.h.Returned, Award{Seat: seat, Amount: a})
+	} else {
+		p.pots[pot] = append(p.pots[pot], Award{Seat: seat, Amount: a})
+	}
+	return nil
+}
+
+// amount parses chips or money (in cents), e.g. "1,500" or "$0.25"
+func (p *psParser) amount(s string) (int64, error) {
+	value := strings.ReplaceAll(strings.TrimLeft(s, "$€£"), ",", "")
+	if !p.money {
+		a, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidHand, s)
+		}
+		return a, nil
+	}
+
+	parts := strings.SplitN(value, ".", 2)
+	if len(parts) == 1 {
+		parts = append(parts, "")
+	}
+	if len(parts[1]) > 2 {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidHand, s)
+	}
+	a, err := strconv.ParseInt(parts[0]+parts[1]+strings.Repeat("0", 2-len(parts[1])), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidHand, s)
+	}
+	return a, nil
+}
@@ -0,0 +1,112 @@
+package hands
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func parseTestFile(t *testing.T, name string) []ParsedHand {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("cannot open the file: %s", err)
+	}
+	defer f.Close()
+
+	parsed, err := ParsePokerStars(f)
+	if err != nil {
+		t.Fatalf("cannot parse the file: %s", err)
+	}
+	return parsed
+}
+
+func TestParsePokerStarsCash(t *testing.T) {
+	parsed := parseTestFile(t, "./testdata/pokerstars_cash.txt.test")
+	if len(parsed) != 4 {
+		t.Fatalf("expected 4 hands, got %d", len(parsed))
+	}
+
+	first := parsed[0]
+	if first.Err != nil {
+		t.Fatalf("the first hand is valid: %s", first.Err)
+	}
+	h := first.Hand
+	if first.ExternalID != "243456789012" || h.ExternalID != first.ExternalID || h.Source != SourcePokerStars || first.Line != 1 {
+		t.Errorf("unexpected id of the hand: %+v", first)
+	}
+	if h.Currency != "USD" || h.SmallBlind != 1 || h.BigBlind != 2 || h.Button != 2 || h.Rake != 14 {
+		t.Errorf("unexpected hand: %+v", h)
+	}
+	if !h.PlayedAt.Equal(time.Date(2023, 3, 12, 13, 22, 1, 0, time.UTC)) {
+		t.Errorf("unexpected time: %s", h.PlayedAt)
+	}
+	// villain is sitting out
+	seats := []Seat{
+		{Seat: 0, UserName: "player1", Stack: 213},
+		{Seat: 1, UserName: "player2", Stack: 195, Cards: "TcTh"},
+		{Seat: 2, UserName: "Hero", Stack: 200, Cards: "AhKd"},
+	}
+	if !reflect.DeepEqual(h.Seats, seats) {
+		t.Errorf("unexpected seats: %+v", h.Seats)
+	}
+	if h.Board != "2c7dTsJs3h" || len(h.Actions) != 11 || !reflect.DeepEqual(h.Pots, []Pot{{Amount: 377, Winners: []Award{{Seat: 1, Amount: 377}}}}) {
+		t.Errorf("unexpected hand: %+v", h)
+	}
+	if raise := h.Actions[7]; raise != (Action{Street: Flop, Seat: 1, Type: ActionRaise, Amount: 28}) {
+		t.Errorf("unexpected raise: %+v", raise)
+	}
+
+	second := parsed[1]
+	if second.Err != nil {
+		t.Fatalf("the second hand is valid: %s", second.Err)
+	}
+	if !reflect.DeepEqual(second.Hand.Returned, []Award{{Seat: 0, Amount: 1}}) || second.Line != 37 {
+		t.Errorf("unexpected hand: %+v", second)
+	}
+
+	if !errors.Is(parsed[2].Err, ErrUnsupportedHand) || parsed[2].ExternalID != "243456789014" {
+		t.Errorf("omaha is not supported, got %+v", parsed[2])
+	}
+	if !errors.Is(parsed[3].Err, ErrInvalidHand) {
+		t.Errorf("the pot of the last hand is invalid, got %v", parsed[3].Err)
+	}
+}
+
+func TestParsePokerStarsTournament(t *testing.T) {
+	parsed := parseTestFile(t, "./testdata/pokerstars_tournament.txt.test")
+	if len(parsed) != 1 {
+		t.Fatalf("expected 1 hand, got %d", len(parsed))
+	}
+	if parsed[0].Err != nil {
+		t.Fatalf("the hand is valid: %s", parsed[0].Err)
+	}
+
+	h := parsed[0].Hand
+	if h.Currency != "" || h.SmallBlind != 50 || h.BigBlind != 100 || h.Ante != 10 || h.Note != "PokerStars Tournament #2962462381" {
+		t.Errorf("unexpected hand: %+v", h)
+	}
+	if !h.PlayedAt.Equal(time.Date(2020, 2, 5, 5, 15, 30, 0, time.UTC)) {
+		t.Errorf("unexpected time: %s", h.PlayedAt)
+	}
+	pots := []Pot{
+		{Amount: 3000, Winners: []Award{{Seat: 1, Amount: 3000}}},
+		{Amount: 3000, Winners: []Award{{Seat: 0, Amount: 3000}}},
+	}
+	if !reflect.DeepEqual(h.Pots, pots) {
+		t.Errorf("unexpected pots: %+v", h.Pots)
+	}
+
+	steps, err := Replay(h)
+	if err != nil {
+		t.Fatalf("cannot replay: %s", err)
+	}
+	last := steps[len(steps)-1]
+	for i, stack := range []int64{3500, 3000, 0} {
+		if last.Seats[i].Stack != stack {
+			t.Errorf("%s: expected stack %d, got %d", last.Seats[i].UserName, stack, last.Seats[i].Stack)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package hands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"pokergo/pkg/poker"
+)
+
+// SeatState is the player at the given step of the replay
+type SeatState struct {
+	Seat     int    `json:"seat"`
+	UserName string `json:"user_name"`
+	Stack    int64  `json:"stack"`
+	// Bet is the amount put into the pot in the current street
+	Bet    int64  `json:"bet"`
+	Folded bool   `json:"folded"`
+	AllIn  bool   `json:"all_in"`
+	Cards  string `json:"cards,omitempty"`
+}
+
+// Step is a snapshot of the hand after a single change, steps can be animated one by one
+type Step struct {
+	No     int    `json:"no"`
+	Street Street `json:"street"`
+	// Action is the action of the step, nil for dealing cards and distributing pots
+	Action *Action `json:"action,omitempty"`
+	// Awards are chips won (or returned) in the step, the last step only
+	Awards []Award `json:"awards,omitempty"`
+	// Description describes the step, e.g. "Alice raises to 60"
+	Description string `json:"description"`
+	Board       string `json:"board"`
+	// Pot are chips in the pot including bets in the current street
+	Pot   int64       `json:"pot"`
+	Seats []SeatState `json:"seats"`
+}
+
+// replay is the state of the hand being replayed
+type replay struct {
+	h      Hand
+	seats  []SeatState // ordered by seats
+	board  []poker.Card
+	street int // the index in streets
+	// shown is the number of visible board cards
+	shown  int
+	pot    int64
+	maxBet int64
+	steps  []Step
+}
+
+// Replay returns the hand step by step: the deal, every action, every street and the pot distribution.
+// It returns ErrInvalidHand if actions are not possible or pots do not match chips put into them.
+func Replay(h Hand) ([]Step, error) {
+	board, err := poker.ParseCards(h.Board)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid board: %s", ErrInvalidHand, err.Error())
+	}
+	r := &replay{h: h, board: board}
+	for _, s := range h.Seats {
+		r.seats = append(r.seats, SeatState{Seat: s.Seat, UserName: s.UserName, Stack: s.Stack, Cards: s.Cards})
+	}
+	sort.Slice(r.seats, func(i, j int) bool { return r.seats[i].Seat < r.seats[j].Seat })
+
+	r.step(nil, fmt.Sprintf("Cards are dealt, the button is at seat %d", h.Button))
+	for i := range h.Actions {
+		if err := r.act(h.Actions[i]); err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+	// the rest of the board is dealt when players are all-in
+	for r.street+1 < len(streets) && streets[r.street+1].boardCards() <= len(r.board) {
+		r.nextStreet()
+	}
+	if err := r.distribute(); err != nil {
+		return nil, err
+	}
+
+	return r.steps, nil
+}
+
+// act performs the action
+func (r *replay) act(a Action) error {
+	street := -1
+	for i, s := range streets {
+		if s == a.Street {
+			street = i
+		}
+	}
+	switch {
+	case street < 0:
+		return fmt.Errorf("%w: unknown street %q", ErrInvalidHand, a.Street)
+	case street < r.street:
+		return fmt.Errorf("%w: %s action after the %s", ErrInvalidHand, a.Street, streets[r.street])
+	case streets[street].boardCards() > len(r.board):
+		return fmt.Errorf("%w: the %s is not on the board", ErrInvalidHand, a.Street)
+	}
+	for r.street < street {
+		r.nextStreet()
+	}
+
+	s := r.seat(a.Seat)
+	switch {
+	case s == nil:
+		return fmt.Errorf("%w: nobody sits at seat %d", ErrInvalidHand, a.Seat)
+	case s.Folded || s.AllIn:
+		return fmt.Errorf("%w: %s cannot act anymore", ErrInvalidHand, s.UserName)
+	case a.Amount < 0 || a.Amount > s.Stack:
+		return fmt.Errorf("%w: %s cannot put %d", ErrInvalidHand, s.UserName, a.Amount)
+	}
+
+	var description string
+	switch a.Type {
+	case ActionAnte, ActionSmallBlind, ActionBigBlind:
+		if a.Street != Preflop || a.Amount == 0 {
+			return fmt.Errorf("%w: forced bets are posted preflop", ErrInvalidHand)
+		}
+		description = fmt.Sprintf("%s posts the %s %d", s.UserName, strings.ReplaceAll(string(a.Type), "_", " "), a.Amount)
+	case ActionFold:
+		if a.Amount != 0 {
+			return fmt.Errorf("%w: %s cannot put chips folding", ErrInvalidHand, s.UserName)
+		}
+		s.Folded = true
+		description = s.UserName + " folds"
+	case ActionCheck:
+		if a.Amount != 0 || s.Bet != r.maxBet {
+			return fmt.Errorf("%w: %s cannot check facing a bet", ErrInvalidHand, s.UserName)
+		}
+		description = s.UserName + " checks"
+	case ActionCall:
+		// a call for less is all-in
+		if s.Bet+a.Amount != r.maxBet && (a.Amount != s.Stack || s.Bet+a.Amount > r.maxBet) {
+			return fmt.Errorf("%w: %s must call %d", ErrInvalidHand, s.UserName, r.maxBet-s.Bet)
+		}
+		description = fmt.Sprintf("%s calls %d", s.UserName, a.Amount)
+	case ActionBet:
+		if r.maxBet != 0 || a.Amount == 0 {
+			return fmt.Errorf("%w: %s cannot bet, there is a bet already", ErrInvalidHand, s.UserName)
+		}
+		description = fmt.Sprintf("%s bets %d", s.UserName, a.Amount)
+	case ActionRaise:
+		if r.maxBet == 0 || s.Bet+a.Amount <= r.maxBet {
+			return fmt.Errorf("%w: %s must raise above %d", ErrInvalidHand, s.UserName, r.maxBet)
+		}
+		description = fmt.Sprintf("%s raises to %d", s.UserName, s.Bet+a.Amount)
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidHand, a.Type)
+	}
+
+	s.Stack -= a.Amount
+	if a.Type == ActionAnte {
+		// antes are dead money, they are not a part of the bet
+		r.pot += a.Amount
+	} else {
+		s.Bet += a.Amount
+		r.maxBet = max64(r.maxBet, s.Bet)
+	}
+	if a.Type == ActionBigBlind {
+		// others call the whole big blind even if it is posted all-in for less
+		r.maxBet = max64(r.maxBet, r.h.BigBlind)
+	}
+	if s.Stack == 0 && a.Amount > 0 {
+		s.AllIn = true
+		description += " and is all-in"
+	}
+
+	r.step(&a, description)
+	return nil
+}
+
+// nextStreet collects bets and deals cards of the next street
+func (r *replay) nextStreet() {
+	for i := range r.seats {
+		r.pot += r.seats[i].Bet
+		r.seats[i].Bet = 0
+	}
+	r.maxBet = 0
+	r.street++
+
+	street := streets[r.street]
+	dealt := r.board[r.shown:street.boardCards()]
+	r.shown = street.boardCards()
+	r.step(nil, fmt.Sprintf("%s%s: %s", strings.ToUpper(string(street[:1])), street[1:], poker.FormatCards(dealt)))
+}
+
+// distribute checks pots and gives them to winners
+func (r *replay) distribute() error {
+	var put int64
+	for _, s := range r.h.Seats {
+		put += s.Stack - r.seat(s.Seat).Stack
+	}
+	var awarded, returned int64
+	for _, a := range r.h.Returned {
+		returned += a.Amount
+	}
+	for i, p := range r.h.Pots {
+		var won int64
+		for _, w := range p.Winners {
+			won += w.Amount
+		}
+		if len(p.Winners) == 0 || won != p.Amount {
+			return fmt.Errorf("%w: pot %d (%d) must be given to winners", ErrInvalidHand, i+1, p.Amount)
+		}
+		awarded += p.Amount
+	}
+	if awarded+returned+r.h.Rake != put {
+		return fmt.Errorf("%w: pots (%d), returned bets (%d) and the rake (%d) do not sum up to %d put into the pot",
+			ErrInvalidHand, awarded, returned, r.h.Rake, put)
+	}
+
+	for i := range r.seats {
+		r.pot += r.seats[i].Bet
+		r.seats[i].Bet = 0
+	}
+
+	var awards []Award
+	var descriptions []string
+	for _, a := range r.h.Returned {
+		s := r.seat(a.Seat)
+		if s == nil || a.Amount <= 0 {
+			return fmt.Errorf("%w: an uncalled bet must be returned to a player", ErrInvalidHand)
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%d is returned to %s", a.Amount, s.UserName))
+		awards = append(awards, a)
+	}
+	for i, p := range r.h.Pots {
+		name := "the main pot"
+		if i > 0 {
+			name = fmt.Sprintf("side pot %d", i)
+		}
+		for _, w := range p.Winners {
+			s := r.seat(w.Seat)
+			if s == nil || s.Folded || w.Amount < 0 {
+				return fmt.Errorf("%w: %s must be won by players who did not fold", ErrInvalidHand, name)
+			}
+			descriptions = append(descriptions, fmt.Sprintf("%s wins %d from %s", s.UserName, w.Amount, name))
+			awards = append(awards, w)
+		}
+	}
+	for _, a := range awards {
+		r.seat(a.Seat).Stack += a.Amount
+		r.pot -= a.Amount
+	}
+	r.pot -= r.h.Rake
+
+	r.step(nil, strings.Join(descriptions, ", "))
+	r.steps[len(r.steps)-1].Awards = awards
+	return nil
+}
+
+// seat returns the player at the seat, nil if the seat is empty
+func (r *replay) seat(seat int) *SeatState {
+	for i := range r.seats {
+		if r.seats[i].Seat == seat {
+			return &r.seats[i]
+		}
+	}
+	return nil
+}
+
+// step records the current state
+func (r *replay) step(a *Action, description string) {
+	step := Step{
+		No:          len(r.steps) + 1,
+		Street:      streets[r.street],
+		Action:      a,
+		Description: description,
+		Board:       poker.FormatCards(r.board[:r.shown]),
+		Pot:         r.pot,
+		Seats:       append([]SeatState{}, r.seats...),
+	}
+	for _, s := range r.seats {
+		step.Pot += s.Bet
+	}
+	r.steps = append(r.steps, step)
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,152 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig is the configuration of the outgoing mail server (MailHog needs Host and Port only)
+type SMTPConfig struct {
+	Host string
+	Port string
+	// Username and Password are used for PLAIN authentication if Username is set
+	Username string
+	Password string
+	// From is the sender address
+	From string
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *smtpMailer {
+	return &smtpMailer{config: config}
+}
+
+// Send sends the message, STARTTLS is used if the server supports it
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid message headers: %q", msg.To)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("cannot start the smtp session: %w", err)
+	}
+	defer c.Close()
+
+	if err := m.send(c, msg); err != nil {
+		return err
+	}
+	if err := c.Quit(); err != nil {
+		return fmt.Errorf("cannot finish the smtp session: %w", err)
+	}
+	return nil
+}
+
+func (m *smtpMailer) send(c *smtp.Client, msg Message) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(nil); err != nil {
+			return fmt.Errorf("cannot start tls: %w", err)
+		}
+	}
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("cannot authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(m.config.From); err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("cannot send the message: %w", err)
+	}
+	if _, err := w.Write(m.format(msg)); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("cannot send the message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("cannot send the message: %w", err)
+	}
+	return nil
+}
+
+// format returns the message with headers (RFC 5322)
+func (m *smtpMailer) format(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.config.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// MemoryMailer keeps sent messages in memory (for tests)
+type MemoryMailer struct {
+	mux  sync.Mutex
+	sent []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(_ context.Context, msg Message) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent returns messages sent so far
+func (m *MemoryMailer) Sent() []Message {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return append([]Message(nil), m.sent...)
+}
+
+var (
+	_ Mailer = (*smtpMailer)(nil)
+	_ Mailer = (*MemoryMailer)(nil)
+)
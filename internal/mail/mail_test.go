@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_SMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	m := NewSMTPMailer(SMTPConfig{Host: "localhost", Port: "1025", From: "pokergo@localhost"})
+	msg := Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "hi", Body: "body"}
+	if err := m.Send(context.Background(), msg); err == nil {
+		t.Errorf("headers with new lines should be rejected")
+	}
+}
+
+// Test_SMTPMailer_Send sends an email to a local SMTP server (for example MailHog),
+// SMTP_TEST_ADDR is its address (host:port)
+func Test_SMTPMailer_Send(t *testing.T) {
+	addr, ok := os.LookupEnv("SMTP_TEST_ADDR")
+	if !ok {
+		t.Skip("SMTP_TEST_ADDR is not set")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("invalid SMTP_TEST_ADDR: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	m := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "pokergo@localhost"})
+	msg := Message{To: "user@example.com", Subject: "Test", Body: "Hi,\nthis is a test.\n"}
+	if err := m.Send(ctx, msg); err != nil {
+		t.Fatalf("cannot send the email: %s", err)
+	}
+}
@@ -18,6 +18,17 @@ type Collections struct {
 	Org   *mongo.Collection
 	Games *mongo.Collection
 	Arts  *mongo.Collection
+	Hands *mongo.Collection
+	// Payments are payments of debts between players recorded in the ledgers of organizations
+	Payments *mongo.Collection
+	// Invites are invite codes of organizations
+	Invites *mongo.Collection
+	// JoinRequests are requests of users to join organizations
+	JoinRequests *mongo.Collection
+	// RevokedTokens are access tokens revoked before their expiration
+	RevokedTokens *mongo.Collection
+	// OneTimeTokens are single-use tokens of email verification and password reset links
+	OneTimeTokens *mongo.Collection
 }
 
 func NewMongo(ctx context.Context, uri, authDB, user, pass, db string) (*Collections, error) {
@@ -45,9 +56,15 @@ func NewMongo(ctx context.Context, uri, authDB, user, pass, db string) (*Collect
 	appDB := cl.Database(db)
 
 	return &Collections{
-		Users: appDB.Collection("users"),
-		Org:   appDB.Collection("organizations"),
-		Games: appDB.Collection("games"),
-		Arts:  appDB.Collection("articles"),
+		Users:         appDB.Collection("users"),
+		Org:           appDB.Collection("organizations"),
+		Games:         appDB.Collection("games"),
+		Arts:          appDB.Collection("articles"),
+		Hands:         appDB.Collection("hands"),
+		Payments:      appDB.Collection("payments"),
+		Invites:       appDB.Collection("invites"),
+		JoinRequests:  appDB.Collection("join_requests"),
+		RevokedTokens: appDB.Collection("revoked_tokens"),
+		OneTimeTokens: appDB.Collection("one_time_tokens"),
 	}, nil
 }
@@ -0,0 +1,460 @@
+package org
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+var (
+	ErrInvalidInvite       = errors.New("invalid invite code")
+	ErrInviteExpired       = errors.New("the invite has expired")
+	ErrInviteUsedUp        = errors.New("the invite has been used up")
+	ErrJoinRequestExists   = errors.New("the user has already asked to join the organization")
+	ErrJoinRequestNotFound = errors.New("join request not exists")
+	ErrJoinRequestReviewed = errors.New("the join request has been reviewed already")
+)
+
+const (
+	// DefaultInviteValidity is the validity of invites created without the expiration time
+	DefaultInviteValidity = 7 * 24 * time.Hour
+	// MaxInviteValidity is the longest validity of an invite
+	MaxInviteValidity = 30 * 24 * time.Hour
+	// signatureLen is the number of bytes of the HMAC kept in the invite code
+	signatureLen = 16
+)
+
+// Invite lets users join the organization without being added by a member
+type Invite struct {
+	ID           id.ID     `bson:"_id" json:"id"` // nolint:tagliatelle // mongo-id
+	Organization id.ID     `bson:"organization" json:"organization"`
+	CreatedBy    id.ID     `bson:"created_by" json:"created_by"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt    time.Time `bson:"expires_at" json:"expires_at"`
+	// MaxUses limits the number of users who can join with the invite, 0 - no limit
+	MaxUses int `bson:"max_uses" json:"max_uses"`
+	Uses    int `bson:"uses" json:"uses"`
+}
+
+// JoinRequestStatus is a state of the join request
+type JoinRequestStatus string
+
+const (
+	JoinRequestPending  JoinRequestStatus = "pending"
+	JoinRequestApproved JoinRequestStatus = "approved"
+	JoinRequestRejected JoinRequestStatus = "rejected"
+)
+
+// JoinRequest is a request of the user to join the organization, it is reviewed by an admin
+type JoinRequest struct {
+	ID           id.ID             `bson:"_id" json:"id"` // nolint:tagliatelle // mongo-id
+	Organization id.ID             `bson:"organization" json:"organization"`
+	UserID       id.ID             `bson:"user_id" json:"user_id"`
+	Message      string            `bson:"message" json:"message"`
+	Status       JoinRequestStatus `bson:"status" json:"status"`
+	CreatedAt    time.Time         `bson:"created_at" json:"created_at"`
+	ReviewedBy   *id.ID            `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time        `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+}
+
+// InviteAdapter stores invites and join requests
+type InviteAdapter interface {
+	// AddInvite stores a new invite
+	AddInvite(ctx context.Context, invite Invite) error
+	// GetInvite returns the invite, ErrInvalidInvite if it does not exist
+	GetInvite(ctx context.Context, inviteID id.ID) (Invite, error)
+	// UseInvite increases uses of the invite, returns ErrInviteUsedUp if the limit is reached
+	UseInvite(ctx context.Context, inviteID id.ID) error
+	// AddJoinRequest stores a new join request, returns ErrJoinRequestExists if the user has a pending one
+	AddJoinRequest(ctx context.Context, request JoinRequest) error
+	// GetJoinRequest returns the join request, ErrJoinRequestNotFound if it does not exist
+	GetJoinRequest(ctx context.Context, requestID id.ID) (JoinRequest, error)
+	// ListJoinRequests returns join requests of the organization in the status, oldest first
+	ListJoinRequests(ctx context.Context, orgID id.ID, status JoinRequestStatus) ([]JoinRequest, error)
+	// ReviewJoinRequest changes the status of the pending request, returns ErrJoinRequestReviewed
+	// if the request is not pending
+	ReviewJoinRequest(ctx context.Context, requestID id.ID, status JoinRequestStatus, by id.ID, at time.Time) error
+}
+
+// InviteManager lets users join organizations with invite codes and join requests
+type InviteManager interface {
+	// CreateInvite creates an invite valid for the duration (DefaultInviteValidity if 0) for maxUses users
+	// (0 - no limit) and returns it with its signed code. The caller must have PermAddMembers.
+	CreateInvite(ctx context.Context, o Org, by id.ID, validity time.Duration, maxUses int) (Invite, string, error)
+	// Join adds the user to the organization of the invite code
+	Join(ctx context.Context, code string, uID id.ID) (Org, error)
+	// RequestJoin asks admins of the organization to add the user
+	RequestJoin(ctx context.Context, o Org, uID id.ID, message string) (JoinRequest, error)
+	// JoinRequests returns pending join requests of the organization (the caller must have PermReviewJoinRequests)
+	JoinRequests(ctx context.Context, o Org, by id.ID) ([]JoinRequest, error)
+	// ReviewJoinRequest approves (the user becomes a member) or rejects the join request
+	// (the caller must have PermReviewJoinRequests)
+	ReviewJoinRequest(ctx context.Context, o Org, by, requestID id.ID, approve bool) (JoinRequest, error)
+}
+
+type inviteManager struct {
+	orgAdapter    Adapter
+	inviteAdapter InviteAdapter
+	secret        []byte
+	timer         timer.Timer
+}
+
+func NewInviteManager(
+	orgAdapter Adapter,
+	inviteAdapter InviteAdapter,
+	secret []byte,
+	timer timer.Timer,
+) *inviteManager {
+	return &inviteManager{orgAdapter: orgAdapter, inviteAdapter: inviteAdapter, secret: secret, timer: timer}
+}
+
+func (m *inviteManager) CreateInvite(
+	ctx context.Context,
+	o Org,
+	by id.ID,
+	validity time.Duration,
+	maxUses int,
+) (Invite, string, error) {
+	if err := o.authorize(by, PermAddMembers); err != nil {
+		return Invite{}, "", err
+	}
+	if validity == 0 {
+		validity = DefaultInviteValidity
+	}
+	if validity < 0 || validity > MaxInviteValidity || maxUses < 0 {
+		return Invite{}, "", fmt.Errorf("%w: the validity must be up to %s and the limit cannot be negative",
+			ErrInvalidInvite, MaxInviteValidity)
+	}
+
+	now := m.timer.Now()
+	invite := Invite{
+		ID:           id.NewID(),
+		Organization: o.ID,
+		CreatedBy:    by,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(validity),
+		MaxUses:      maxUses,
+	}
+	if err := m.inviteAdapter.AddInvite(ctx, invite); err != nil {
+		return Invite{}, "", fmt.Errorf("cannot save the invite: %w", err)
+	}
+
+	return invite, m.code(invite), nil
+}
+
+// code returns the invite code: the invite id and the signature of the id and the organization
+func (m *inviteManager) code(invite Invite) string {
+	return invite.ID.Hex() + "." + base64.RawURLEncoding.EncodeToString(m.sign(invite.ID, invite.Organization))
+}
+
+func (m *inviteManager) sign(inviteID, orgID id.ID) []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write(inviteID[:])
+	mac.Write(orgID[:])
+	return mac.Sum(nil)[:signatureLen]
+}
+
+// invite returns the invite of the code if the signature is valid
+func (m *inviteManager) invite(ctx context.Context, code string) (Invite, error) {
+	parts := strings.Split(code, ".")
+	if len(parts) != 2 {
+		return Invite{}, ErrInvalidInvite
+	}
+	inviteID, err := id.FromString(parts[0])
+	if err != nil || inviteID.IsZero() {
+		return Invite{}, ErrInvalidInvite
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Invite{}, ErrInvalidInvite
+	}
+
+	invite, err := m.inviteAdapter.GetInvite(ctx, inviteID)
+	if err != nil {
+		return Invite{}, err
+	}
+	if !hmac.Equal(signature, m.sign(invite.ID, invite.Organization)) {
+		return Invite{}, ErrInvalidInvite
+	}
+	return invite, nil
+}
+
+func (m *inviteManager) Join(ctx context.Context, code string, uID id.ID) (Org, error) {
+	invite, err := m.invite(ctx, code)
+	if err != nil {
+		return Org{}, err
+	}
+	if !m.timer.Now().Before(invite.ExpiresAt) {
+		return Org{}, ErrInviteExpired
+	}
+
+	o, err := m.orgAdapter.GetOrgByID(ctx, invite.Organization)
+	if err != nil {
+		return Org{}, fmt.Errorf("cannot find org: %w", err)
+	}
+	if o.IsMember(uID) {
+		return Org{}, ErrAlreadyMember
+	}
+
+	if err := m.inviteAdapter.UseInvite(ctx, invite.ID); err != nil {
+		return Org{}, err
+	}
+	if err := m.orgAdapter.AddToOrg(ctx, o.ID, uID); err != nil {
+		return Org{}, fmt.Errorf("cannot add the member: %w", err)
+	}
+	return o, nil
+}
+
+func (m *inviteManager) RequestJoin(ctx context.Context, o Org, uID id.ID, message string) (JoinRequest, error) {
+	if o.IsMember(uID) {
+		return JoinRequest{}, ErrAlreadyMember
+	}
+
+	request := JoinRequest{
+		ID:           id.NewID(),
+		Organization: o.ID,
+		UserID:       uID,
+		Message:      message,
+		Status:       JoinRequestPending,
+		CreatedAt:    m.timer.Now(),
+	}
+	if err := m.inviteAdapter.AddJoinRequest(ctx, request); err != nil {
+		return JoinRequest{}, err
+	}
+	return request, nil
+}
+
+func (m *inviteManager) JoinRequests(ctx context.Context, o Org, by id.ID) ([]JoinRequest, error) {
+	if err := o.authorize(by, PermReviewJoinRequests); err != nil {
+		return nil, err
+	}
+
+	requests, err := m.inviteAdapter.ListJoinRequests(ctx, o.ID, JoinRequestPending)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list join requests: %w", err)
+	}
+	return requests, nil
+}
+
+func (m *inviteManager) ReviewJoinRequest(
+	ctx context.Context,
+	o Org,
+	by, requestID id.ID,
+	approve bool,
+) (JoinRequest, error) {
+	if err := o.authorize(by, PermReviewJoinRequests); err != nil {
+		return JoinRequest{}, err
+	}
+
+	request, err := m.inviteAdapter.GetJoinRequest(ctx, requestID)
+	if err != nil {
+		return JoinRequest{}, err
+	}
+	if request.Organization != o.ID {
+		return JoinRequest{}, ErrJoinRequestNotFound
+	}
+	if request.Status != JoinRequestPending {
+		return JoinRequest{}, ErrJoinRequestReviewed
+	}
+
+	status := JoinRequestRejected
+	if approve {
+		status = JoinRequestApproved
+	}
+	now := m.timer.Now()
+	if err := m.inviteAdapter.ReviewJoinRequest(ctx, request.ID, status, by, now); err != nil {
+		return JoinRequest{}, err
+	}
+	if approve && !o.IsMember(request.UserID) {
+		if err := m.orgAdapter.AddToOrg(ctx, o.ID, request.UserID); err != nil {
+			return JoinRequest{}, fmt.Errorf("cannot add the member: %w", err)
+		}
+	}
+
+	request.Status, request.ReviewedBy, request.ReviewedAt = status, &by, &now
+	return request, nil
+}
+
+var _ InviteManager = (*inviteManager)(nil)
+
+type mongoInviteAdapter struct {
+	invites      *mongo.Collection
+	joinRequests *mongo.Collection
+}
+
+func NewMongoInviteAdapter(invites, joinRequests *mongo.Collection) *mongoInviteAdapter {
+	return &mongoInviteAdapter{invites: invites, joinRequests: joinRequests}
+}
+
+func (m *mongoInviteAdapter) EnsureIndexes(ctx context.Context) error {
+	invitesIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "organization", Value: 1},
+			{Key: "_id", Value: 1},
+		},
+	}
+	if _, err := m.invites.Indexes().CreateOne(ctx, invitesIdx); err != nil {
+		return fmt.Errorf("cannot create invites indexes: %w", err)
+	}
+
+	// a user can have a single pending request per organization
+	pendingIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "organization", Value: 1},
+			{Key: "user_id", Value: 1},
+		},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": JoinRequestPending}),
+	}
+	statusIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "organization", Value: 1},
+			{Key: "status", Value: 1},
+			{Key: "_id", Value: 1},
+		},
+	}
+	if _, err := m.joinRequests.Indexes().CreateMany(ctx, []mongo.IndexModel{pendingIdx, statusIdx}); err != nil {
+		return fmt.Errorf("cannot create join requests indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoInviteAdapter) AddInvite(ctx context.Context, invite Invite) error {
+	if _, err := m.invites.InsertOne(ctx, invite); err != nil {
+		return fmt.Errorf("cannot insert the invite: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoInviteAdapter) GetInvite(ctx context.Context, inviteID id.ID) (Invite, error) {
+	res := m.invites.FindOne(ctx, bson.M{"_id": inviteID})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Invite{}, ErrInvalidInvite
+		}
+		return Invite{}, fmt.Errorf("cannot find the invite: %w", err)
+	}
+
+	var invite Invite
+	if err := res.Decode(&invite); err != nil {
+		return Invite{}, fmt.Errorf("cannot decode the invite: %w", err)
+	}
+	return invite, nil
+}
+
+func (m *mongoInviteAdapter) UseInvite(ctx context.Context, inviteID id.ID) error {
+	filter := bson.M{
+		"_id": inviteID,
+		"$expr": bson.M{
+			"$or": bson.A{
+				bson.M{"$eq": bson.A{"$max_uses", 0}},
+				bson.M{"$lt": bson.A{"$uses", "$max_uses"}},
+			},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			"uses": 1,
+		},
+	}
+
+	res, err := m.invites.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("cannot use the invite: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrInviteUsedUp
+	}
+	return nil
+}
+
+func (m *mongoInviteAdapter) AddJoinRequest(ctx context.Context, request JoinRequest) error {
+	if _, err := m.joinRequests.InsertOne(ctx, request); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrJoinRequestExists
+		}
+		return fmt.Errorf("cannot insert the join request: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoInviteAdapter) GetJoinRequest(ctx context.Context, requestID id.ID) (JoinRequest, error) {
+	res := m.joinRequests.FindOne(ctx, bson.M{"_id": requestID})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return JoinRequest{}, ErrJoinRequestNotFound
+		}
+		return JoinRequest{}, fmt.Errorf("cannot find the join request: %w", err)
+	}
+
+	var request JoinRequest
+	if err := res.Decode(&request); err != nil {
+		return JoinRequest{}, fmt.Errorf("cannot decode the join request: %w", err)
+	}
+	return request, nil
+}
+
+func (m *mongoInviteAdapter) ListJoinRequests(
+	ctx context.Context,
+	orgID id.ID,
+	status JoinRequestStatus,
+) ([]JoinRequest, error) {
+	opts := &options.FindOptions{
+		Sort: bson.M{"_id": 1},
+	}
+
+	cur, err := m.joinRequests.Find(ctx, bson.M{"organization": orgID, "status": status}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get join requests: %w", err)
+	}
+
+	var requests []JoinRequest
+	if err := cur.All(ctx, &requests); err != nil {
+		return nil, fmt.Errorf("cannot bind join requests: %w", err)
+	}
+	return requests, nil
+}
+
+func (m *mongoInviteAdapter) ReviewJoinRequest(
+	ctx context.Context,
+	requestID id.ID,
+	status JoinRequestStatus,
+	by id.ID,
+	at time.Time,
+) error {
+	filter := bson.M{
+		"_id":    requestID,
+		"status": JoinRequestPending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"reviewed_by": by,
+			"reviewed_at": at,
+		},
+	}
+
+	res, err := m.joinRequests.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("cannot review the join request: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrJoinRequestReviewed
+	}
+	return nil
+}
+
+var _ InviteAdapter = (*mongoInviteAdapter)(nil)
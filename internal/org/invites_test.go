@@ -0,0 +1,187 @@
+package org
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+// memoryInviteAdapter is an in-memory InviteAdapter
+type memoryInviteAdapter struct {
+	invites  map[id.ID]Invite
+	requests []JoinRequest
+}
+
+func newMemoryInviteAdapter() *memoryInviteAdapter {
+	return &memoryInviteAdapter{invites: make(map[id.ID]Invite)}
+}
+
+func (m *memoryInviteAdapter) AddInvite(_ context.Context, invite Invite) error {
+	m.invites[invite.ID] = invite
+	return nil
+}
+
+func (m *memoryInviteAdapter) GetInvite(_ context.Context, inviteID id.ID) (Invite, error) {
+	invite, ok := m.invites[inviteID]
+	if !ok {
+		return Invite{}, ErrInvalidInvite
+	}
+	return invite, nil
+}
+
+func (m *memoryInviteAdapter) UseInvite(_ context.Context, inviteID id.ID) error {
+	invite := m.invites[inviteID]
+	if invite.MaxUses > 0 && invite.Uses >= invite.MaxUses {
+		return ErrInviteUsedUp
+	}
+	invite.Uses++
+	m.invites[inviteID] = invite
+	return nil
+}
+
+func (m *memoryInviteAdapter) AddJoinRequest(_ context.Context, request JoinRequest) error {
+	for _, r := range m.requests {
+		if r.Organization == request.Organization && r.UserID == request.UserID && r.Status == JoinRequestPending {
+			return ErrJoinRequestExists
+		}
+	}
+	m.requests = append(m.requests, request)
+	return nil
+}
+
+func (m *memoryInviteAdapter) GetJoinRequest(_ context.Context, requestID id.ID) (JoinRequest, error) {
+	for _, r := range m.requests {
+		if r.ID == requestID {
+			return r, nil
+		}
+	}
+	return JoinRequest{}, ErrJoinRequestNotFound
+}
+
+func (m *memoryInviteAdapter) ListJoinRequests(
+	_ context.Context,
+	orgID id.ID,
+	status JoinRequestStatus,
+) ([]JoinRequest, error) {
+	var res []JoinRequest
+	for _, r := range m.requests {
+		if r.Organization == orgID && r.Status == status {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryInviteAdapter) ReviewJoinRequest(
+	_ context.Context,
+	requestID id.ID,
+	status JoinRequestStatus,
+	by id.ID,
+	at time.Time,
+) error {
+	for i, r := range m.requests {
+		if r.ID == requestID && r.Status == JoinRequestPending {
+			m.requests[i].Status, m.requests[i].ReviewedBy, m.requests[i].ReviewedAt = status, &by, &at
+			return nil
+		}
+	}
+	return ErrJoinRequestReviewed
+}
+
+func Test_InviteManager_Join(t *testing.T) {
+	ctx := context.Background()
+	orgs := &memoryAdapter{orgs: make(map[id.ID]Org)}
+	clock := timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC))
+	m := NewInviteManager(orgs, newMemoryInviteAdapter(), []byte("secret"), clock)
+	owner, member, first, second := id.NewID(), id.NewID(), id.NewID(), id.NewID()
+	o, _ := orgs.CreateOrg(ctx, owner, "org")
+	_ = orgs.AddToOrg(ctx, o.ID, member)
+	o = orgs.orgs[o.ID]
+
+	if _, _, err := m.CreateInvite(ctx, o, member, 0, 1); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("members cannot invite, got %v", err)
+	}
+	if _, _, err := m.CreateInvite(ctx, o, owner, MaxInviteValidity+time.Hour, 1); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("expected ErrInvalidInvite, got %v", err)
+	}
+	invite, code, err := m.CreateInvite(ctx, o, owner, 0, 1)
+	if err != nil {
+		t.Fatalf("cannot create the invite: %s", err)
+	}
+	if !invite.ExpiresAt.Equal(clock.Now().Add(DefaultInviteValidity)) {
+		t.Errorf("unexpected expiration time: %s", invite.ExpiresAt)
+	}
+
+	forged := invite.ID.Hex() + "." + strings.Repeat("A", 22)
+	for _, c := range []string{"", "abc", forged, id.NewID().Hex() + code[24:]} {
+		if _, err := m.Join(ctx, c, first); !errors.Is(err, ErrInvalidInvite) {
+			t.Errorf("%q: expected ErrInvalidInvite, got %v", c, err)
+		}
+	}
+	if _, err := m.Join(ctx, code, member); !errors.Is(err, ErrAlreadyMember) {
+		t.Errorf("expected ErrAlreadyMember, got %v", err)
+	}
+	joined, err := m.Join(ctx, code, first)
+	if err != nil || joined.ID != o.ID || !orgs.orgs[o.ID].IsMember(first) {
+		t.Fatalf("the user should join the organization, err: %v", err)
+	}
+	if _, err := m.Join(ctx, code, second); !errors.Is(err, ErrInviteUsedUp) {
+		t.Errorf("expected ErrInviteUsedUp, got %v", err)
+	}
+
+	_, code, _ = m.CreateInvite(ctx, o, owner, time.Hour, 0)
+	clock.Advance(time.Hour)
+	if _, err := m.Join(ctx, code, second); !errors.Is(err, ErrInviteExpired) {
+		t.Errorf("expected ErrInviteExpired, got %v", err)
+	}
+}
+
+func Test_InviteManager_JoinRequests(t *testing.T) {
+	ctx := context.Background()
+	orgs := &memoryAdapter{orgs: make(map[id.ID]Org)}
+	m := NewInviteManager(orgs, newMemoryInviteAdapter(), []byte("secret"),
+		timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC)))
+	owner, user, other := id.NewID(), id.NewID(), id.NewID()
+	o, _ := orgs.CreateOrg(ctx, owner, "org")
+
+	request, err := m.RequestJoin(ctx, o, user, "hi")
+	if err != nil {
+		t.Fatalf("cannot request to join: %s", err)
+	}
+	if _, err := m.RequestJoin(ctx, o, user, "hi again"); !errors.Is(err, ErrJoinRequestExists) {
+		t.Errorf("expected ErrJoinRequestExists, got %v", err)
+	}
+	rejected, _ := m.RequestJoin(ctx, o, other, "")
+
+	if _, err := m.JoinRequests(ctx, o, user); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("only admins see join requests, got %v", err)
+	}
+	if requests, err := m.JoinRequests(ctx, o, owner); err != nil || len(requests) != 2 {
+		t.Fatalf("expected 2 pending requests, got %d (%v)", len(requests), err)
+	}
+
+	if _, err := m.ReviewJoinRequest(ctx, o, user, request.ID, true); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("the user cannot approve his own request, got %v", err)
+	}
+	approved, err := m.ReviewJoinRequest(ctx, o, owner, request.ID, true)
+	if err != nil || approved.Status != JoinRequestApproved || !orgs.orgs[o.ID].IsMember(user) {
+		t.Fatalf("the user should be approved, err: %v", err)
+	}
+	if _, err := m.ReviewJoinRequest(ctx, o, owner, request.ID, false); !errors.Is(err, ErrJoinRequestReviewed) {
+		t.Errorf("expected ErrJoinRequestReviewed, got %v", err)
+	}
+	if _, err := m.ReviewJoinRequest(ctx, o, owner, rejected.ID, false); err != nil {
+		t.Fatalf("cannot reject: %s", err)
+	}
+	if orgs.orgs[o.ID].IsMember(other) {
+		t.Errorf("rejected user should not be a member")
+	}
+	if requests, _ := m.JoinRequests(ctx, o, owner); len(requests) != 0 {
+		t.Errorf("all requests are reviewed, got %+v", requests)
+	}
+}
@@ -0,0 +1,292 @@
+package org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+var (
+	ErrInvalidPayment = errors.New("invalid payment")
+	ErrOverpayment    = errors.New("the payment is greater than the debt")
+	ErrNotCreditor    = errors.New("only the creditor or an admin can record the payment")
+	// ErrPaymentConflict is returned if another payment was recorded after the ledger had been read
+	ErrPaymentConflict = errors.New("another payment has been recorded in the meantime")
+)
+
+// maxPayRetries is the number of attempts to record the payment checked against the reloaded ledger
+const maxPayRetries = 3
+
+// Debt is the amount a player owes another one after a single game
+type Debt struct {
+	GameID id.ID  `json:"game_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int64  `json:"amount"`
+}
+
+// DebtSource provides debts from games of the organization
+type DebtSource interface {
+	// Debts returns transfers settling every game of the organization which passes verification
+	// and has not been cancelled, debts are paid off by payments of the ledger only
+	Debts(ctx context.Context, orgID id.ID) ([]Debt, error)
+}
+
+// Payment is money paid by a player to another one to pay off debts from games
+type Payment struct {
+	ID           id.ID     `bson:"_id" json:"id"` // nolint:tagliatelle // mongo-id
+	Organization id.ID     `bson:"organization" json:"organization"`
+	From         string    `bson:"from" json:"from"`
+	To           string    `bson:"to" json:"to"`
+	Amount       int64     `bson:"amount" json:"amount"`
+	RecordedBy   id.ID     `bson:"recorded_by" json:"recorded_by"`
+	At           time.Time `bson:"at" json:"at"`
+	// Seq is the number of the payment in the organization (starting from 1), it is unique,
+	// so two payments checked against the same ledger cannot be both recorded
+	Seq int64 `bson:"seq" json:"seq"`
+}
+
+// Balance is the net debt between two players: From owes To the Amount
+type Balance struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int64  `json:"amount"`
+}
+
+// Ledger are unpaid debts of the organization rolled over from game to game
+type Ledger struct {
+	Debts    []Debt    `json:"debts"`
+	Payments []Payment `json:"payments"`
+	// Balances are net debts of pairs of players (debts minus payments), pairs without a debt are skipped
+	Balances []Balance `json:"balances"`
+}
+
+// pair is a pair of players, A < B
+type pair struct {
+	a, b string
+}
+
+// NewLedger sums up debts and payments of every pair of players
+func NewLedger(debts []Debt, payments []Payment) Ledger {
+	// net is positive if a owes b, negative if b owes a
+	net := make(map[pair]int64)
+	add := func(from, to string, amount int64) {
+		if from < to {
+			net[pair{from, to}] += amount
+		} else {
+			net[pair{to, from}] -= amount
+		}
+	}
+	for _, d := range debts {
+		add(d.From, d.To, d.Amount)
+	}
+	for _, p := range payments {
+		add(p.From, p.To, -p.Amount)
+	}
+
+	l := Ledger{Debts: debts, Payments: payments}
+	for p, amount := range net {
+		switch {
+		case amount > 0:
+			l.Balances = append(l.Balances, Balance{From: p.a, To: p.b, Amount: amount})
+		case amount < 0:
+			l.Balances = append(l.Balances, Balance{From: p.b, To: p.a, Amount: -amount})
+		}
+	}
+	sort.Slice(l.Balances, func(i, j int) bool {
+		if l.Balances[i].From != l.Balances[j].From {
+			return l.Balances[i].From < l.Balances[j].From
+		}
+		return l.Balances[i].To < l.Balances[j].To
+	})
+
+	return l
+}
+
+// Owes returns the net amount the player from owes the player to (0 if there is no debt)
+func (l Ledger) Owes(from, to string) int64 {
+	for _, b := range l.Balances {
+		if b.From == from && b.To == to {
+			return b.Amount
+		}
+	}
+	return 0
+}
+
+// Totals returns the net balance of every player (positive - the player owes money, negative - gets money),
+// the balance of the player is the sum of Report of unpaid games reduced by payments
+func (l Ledger) Totals() map[string]int64 {
+	totals := make(map[string]int64)
+	for _, b := range l.Balances {
+		totals[b.From] += b.Amount
+		totals[b.To] -= b.Amount
+	}
+	return totals
+}
+
+// PaymentAdapter stores payments
+type PaymentAdapter interface {
+	// AddPayment stores a new payment, ErrPaymentConflict is returned if the organization has a payment with its Seq
+	AddPayment(ctx context.Context, payment Payment) error
+	// ListPayments returns all the payments of the organization, oldest first
+	ListPayments(ctx context.Context, orgID id.ID) ([]Payment, error)
+}
+
+// LedgerManager keeps the ledger of debts between players of the organization
+type LedgerManager interface {
+	// Ledger returns debts from games of the organization reduced by payments
+	Ledger(ctx context.Context, orgID id.ID) (Ledger, error)
+	// Pay records the payment (partial or full) of the net debt of the pair of players and returns the updated ledger.
+	// The payment can be recorded by the creditor (callerName is the creditor) or a member with PermManagePayments.
+	Pay(ctx context.Context, o Org, callerID id.ID, callerName string, from, to string, amount int64) (Ledger, error)
+}
+
+type ledgerManager struct {
+	debts    DebtSource
+	payments PaymentAdapter
+	timer    timer.Timer
+}
+
+func NewLedgerManager(debts DebtSource, payments PaymentAdapter, timer timer.Timer) *ledgerManager {
+	return &ledgerManager{debts: debts, payments: payments, timer: timer}
+}
+
+func (m *ledgerManager) Ledger(ctx context.Context, orgID id.ID) (Ledger, error) {
+	debts, err := m.debts.Debts(ctx, orgID)
+	if err != nil {
+		return Ledger{}, fmt.Errorf("cannot get debts: %w", err)
+	}
+	payments, err := m.payments.ListPayments(ctx, orgID)
+	if err != nil {
+		return Ledger{}, fmt.Errorf("cannot get payments: %w", err)
+	}
+
+	return NewLedger(debts, payments), nil
+}
+
+func (m *ledgerManager) Pay(
+	ctx context.Context,
+	o Org,
+	callerID id.ID,
+	callerName string,
+	from, to string,
+	amount int64,
+) (Ledger, error) {
+	if amount <= 0 || from == to {
+		return Ledger{}, fmt.Errorf("%w: the amount must be positive and paid to another player", ErrInvalidPayment)
+	}
+	if callerName != to && !o.Can(callerID, PermManagePayments) {
+		return Ledger{}, ErrNotCreditor
+	}
+
+	for attempt := 1; ; attempt++ {
+		l, err := m.pay(ctx, o.ID, callerID, from, to, amount)
+		if !errors.Is(err, ErrPaymentConflict) || attempt == maxPayRetries {
+			return l, err
+		}
+	}
+}
+
+// pay checks the payment against the current ledger and records it
+// (ErrPaymentConflict if another payment has been recorded in the meantime)
+func (m *ledgerManager) pay(ctx context.Context, orgID, callerID id.ID, from, to string, amount int64) (Ledger, error) {
+	l, err := m.Ledger(ctx, orgID)
+	if err != nil {
+		return Ledger{}, err
+	}
+	if owes := l.Owes(from, to); amount > owes {
+		return Ledger{}, fmt.Errorf("%w: %s owes %s %d", ErrOverpayment, from, to, owes)
+	}
+
+	p := Payment{
+		ID:           id.NewID(),
+		Organization: orgID,
+		Seq:          int64(len(l.Payments)) + 1,
+		From:         from,
+		To:           to,
+		Amount:       amount,
+		RecordedBy:   callerID,
+		At:           m.timer.Now(),
+	}
+	if err := m.payments.AddPayment(ctx, p); err != nil {
+		if errors.Is(err, ErrPaymentConflict) {
+			return Ledger{}, err // nolint:wrapcheck // retried by Pay
+		}
+		return Ledger{}, fmt.Errorf("cannot save the payment: %w", err)
+	}
+
+	return NewLedger(l.Debts, append(l.Payments, p)), nil
+}
+
+var _ LedgerManager = (*ledgerManager)(nil)
+
+type mongoPaymentAdapter struct {
+	coll *mongo.Collection
+}
+
+func NewMongoPaymentAdapter(coll *mongo.Collection) *mongoPaymentAdapter {
+	return &mongoPaymentAdapter{coll: coll}
+}
+
+func (m *mongoPaymentAdapter) EnsureIndexes(ctx context.Context) error {
+	idx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "organization", Value: 1},
+			{Key: "_id", Value: 1},
+		},
+	}
+	// payments recorded before Seq was introduced have no seq
+	seqIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "organization", Value: 1},
+			{Key: "seq", Value: 1},
+		},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}}),
+	}
+
+	if _, err := m.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{idx, seqIdx}); err != nil {
+		return fmt.Errorf("cannot create payments indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoPaymentAdapter) AddPayment(ctx context.Context, payment Payment) error {
+	if _, err := m.coll.InsertOne(ctx, payment); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrPaymentConflict
+		}
+		return fmt.Errorf("cannot insert the payment: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoPaymentAdapter) ListPayments(ctx context.Context, orgID id.ID) ([]Payment, error) {
+	opts := &options.FindOptions{
+		Sort: bson.M{"_id": 1},
+	}
+
+	cur, err := m.coll.Find(ctx, bson.M{"organization": orgID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get payments: %w", err)
+	}
+
+	var payments []Payment
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, fmt.Errorf("cannot bind payments: %w", err)
+	}
+
+	return payments, nil
+}
+
+var _ PaymentAdapter = (*mongoPaymentAdapter)(nil)
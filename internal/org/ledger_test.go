@@ -0,0 +1,146 @@
+package org
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+type staticDebts []Debt
+
+func (s staticDebts) Debts(context.Context, id.ID) ([]Debt, error) {
+	return s, nil
+}
+
+type memoryPayments []Payment
+
+func (m *memoryPayments) AddPayment(_ context.Context, payment Payment) error {
+	for _, p := range *m {
+		if p.Organization == payment.Organization && p.Seq == payment.Seq {
+			return ErrPaymentConflict
+		}
+	}
+	*m = append(*m, payment)
+	return nil
+}
+
+// racingPayments records a concurrent payment just before the first payment is added
+type racingPayments struct {
+	memoryPayments
+	concurrent *Payment
+}
+
+func (r *racingPayments) AddPayment(ctx context.Context, payment Payment) error {
+	if r.concurrent != nil {
+		concurrent := *r.concurrent
+		concurrent.Seq = payment.Seq
+		r.concurrent = nil
+		if err := r.memoryPayments.AddPayment(ctx, concurrent); err != nil {
+			return err
+		}
+	}
+	return r.memoryPayments.AddPayment(ctx, payment)
+}
+
+func (m *memoryPayments) ListPayments(_ context.Context, orgID id.ID) ([]Payment, error) {
+	var res []Payment
+	for _, p := range *m {
+		if p.Organization == orgID {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func Test_NewLedger(t *testing.T) {
+	g1, g2 := id.NewID(), id.NewID()
+	debts := []Debt{
+		{GameID: g1, From: "alice", To: "bob", Amount: 100},
+		{GameID: g1, From: "carol", To: "bob", Amount: 50},
+		// debts roll over, bob owes alice back
+		{GameID: g2, From: "bob", To: "alice", Amount: 30},
+		{GameID: g2, From: "carol", To: "alice", Amount: 20},
+	}
+	payments := []Payment{{From: "carol", To: "bob", Amount: 50}}
+
+	l := NewLedger(debts, payments)
+	expected := []Balance{
+		{From: "alice", To: "bob", Amount: 70},
+		{From: "carol", To: "alice", Amount: 20},
+	}
+	if !reflect.DeepEqual(l.Balances, expected) {
+		t.Errorf("unexpected balances: %+v", l.Balances)
+	}
+	if l.Owes("alice", "bob") != 70 || l.Owes("bob", "alice") != 0 || l.Owes("carol", "bob") != 0 {
+		t.Errorf("unexpected debts of pairs: %+v", l.Balances)
+	}
+	// reports of games: alice 70, bob -120, carol 50; carol paid 50 to bob
+	if totals := l.Totals(); !reflect.DeepEqual(totals, map[string]int64{"alice": 50, "bob": -70, "carol": 20}) {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+}
+
+func Test_LedgerManager_Pay(t *testing.T) {
+	ctx := context.Background()
+	admin, bob := id.NewID(), id.NewID()
+	o := Org{ID: id.NewID(), Name: "org", Owner: admin, Members: []id.ID{admin, bob}}
+	payments := &memoryPayments{}
+	m := NewLedgerManager(staticDebts{{From: "alice", To: "bob", Amount: 100}}, payments,
+		timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC)))
+
+	l, err := m.Pay(ctx, o, bob, "bob", "alice", "bob", 40)
+	if err != nil {
+		t.Fatalf("cannot pay: %s", err)
+	}
+	if l.Owes("alice", "bob") != 60 || len(*payments) != 1 || (*payments)[0].RecordedBy != bob {
+		t.Errorf("a partial payment is expected, got %+v", l)
+	}
+
+	if _, err := m.Pay(ctx, o, bob, "bob", "alice", "bob", 61); !errors.Is(err, ErrOverpayment) {
+		t.Errorf("expected ErrOverpayment, got %v", err)
+	}
+	if _, err := m.Pay(ctx, o, id.NewID(), "alice", "alice", "bob", 10); !errors.Is(err, ErrNotCreditor) {
+		t.Errorf("the debtor cannot record the payment, got %v", err)
+	}
+	if _, err := m.Pay(ctx, o, bob, "bob", "bob", "bob", 10); !errors.Is(err, ErrInvalidPayment) {
+		t.Errorf("expected ErrInvalidPayment, got %v", err)
+	}
+
+	// the admin records the rest
+	l, err = m.Pay(ctx, o, admin, "admin", "alice", "bob", 60)
+	if err != nil {
+		t.Fatalf("cannot pay: %s", err)
+	}
+	if len(l.Balances) != 0 {
+		t.Errorf("the debt is paid, got %+v", l.Balances)
+	}
+	if l, _ := m.Ledger(ctx, o.ID); len(l.Payments) != 2 || len(l.Balances) != 0 {
+		t.Errorf("payments are stored, got %+v", l)
+	}
+}
+
+func Test_LedgerManager_ConcurrentPayments(t *testing.T) {
+	ctx := context.Background()
+	admin := id.NewID()
+	o := Org{ID: id.NewID(), Name: "org", Owner: admin, Members: []id.ID{admin}}
+	payments := &racingPayments{concurrent: &Payment{ID: id.NewID(), Organization: o.ID, From: "alice", To: "bob", Amount: 70}}
+	m := NewLedgerManager(staticDebts{{From: "alice", To: "bob", Amount: 100}}, payments,
+		timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC)))
+
+	// both payments are checked against the debt of 100, the second one is checked again after the conflict
+	if _, err := m.Pay(ctx, o, admin, "admin", "alice", "bob", 50); !errors.Is(err, ErrOverpayment) {
+		t.Fatalf("expected ErrOverpayment after the concurrent payment, got %v", err)
+	}
+	l, err := m.Pay(ctx, o, admin, "admin", "alice", "bob", 30)
+	if err != nil {
+		t.Fatalf("cannot pay: %s", err)
+	}
+	if len(l.Balances) != 0 || len(payments.memoryPayments) != 2 {
+		t.Errorf("the debt should be paid by 2 payments, got %+v", l)
+	}
+}
@@ -0,0 +1,131 @@
+package org
+
+import (
+	"context"
+	"fmt"
+
+	"pokergo/pkg/id"
+)
+
+// Manager changes members of organizations checking permissions of the caller
+type Manager interface {
+	// AddMember adds the user to the organization (the caller must have PermAddMembers)
+	AddMember(ctx context.Context, o Org, by, who id.ID) error
+	// RemoveMember removes the member with a lower role than the caller (who must have PermRemoveMembers)
+	RemoveMember(ctx context.Context, o Org, by, who id.ID) error
+	// Leave removes the caller from the organization, the owner cannot leave
+	Leave(ctx context.Context, o Org, who id.ID) error
+	// SetRole gives the member the role, the caller (with PermManageRoles) must have a higher role than
+	// the member and the new role
+	SetRole(ctx context.Context, o Org, by, who id.ID, role Role) error
+	// TransferOwnership makes the member the owner (the caller must be the owner), the former owner becomes an admin
+	TransferOwnership(ctx context.Context, o Org, by, to id.ID) error
+	// Settings returns settings of the organization (the caller must have PermManageSettings)
+	Settings(ctx context.Context, o Org, by id.ID) (Settings, error)
+	// UpdateSettings validates and replaces settings of the organization (the caller must have PermManageSettings)
+	UpdateSettings(ctx context.Context, o Org, by id.ID, settings Settings) error
+}
+
+type manager struct {
+	adapter Adapter
+}
+
+func NewManager(adapter Adapter) *manager {
+	return &manager{adapter: adapter}
+}
+
+func (m *manager) AddMember(ctx context.Context, o Org, by, who id.ID) error {
+	if err := o.authorize(by, PermAddMembers); err != nil {
+		return err
+	}
+	if o.IsMember(who) {
+		return ErrAlreadyMember
+	}
+
+	if err := m.adapter.AddToOrg(ctx, o.ID, who); err != nil {
+		return fmt.Errorf("cannot add the member: %w", err)
+	}
+	return nil
+}
+
+func (m *manager) RemoveMember(ctx context.Context, o Org, by, who id.ID) error {
+	if _, err := o.manage(by, who, PermRemoveMembers); err != nil {
+		return err
+	}
+
+	if err := m.adapter.RemoveFromOrg(ctx, o.ID, who); err != nil {
+		return fmt.Errorf("cannot remove the member: %w", err)
+	}
+	return nil
+}
+
+func (m *manager) Leave(ctx context.Context, o Org, who id.ID) error {
+	if !o.IsMember(who) {
+		return ErrNotMember
+	}
+	if who == o.Owner {
+		return ErrOwnerCannotLeave
+	}
+
+	if err := m.adapter.RemoveFromOrg(ctx, o.ID, who); err != nil {
+		return fmt.Errorf("cannot leave the organization: %w", err)
+	}
+	return nil
+}
+
+func (m *manager) SetRole(ctx context.Context, o Org, by, who id.ID, role Role) error {
+	if !role.IsValid() || role == RoleOwner {
+		return fmt.Errorf("%w: %s (the ownership can be transferred only)", ErrInvalidRole, role)
+	}
+	if _, err := o.manage(by, who, PermManageRoles); err != nil {
+		return err
+	}
+	if caller, _ := o.RoleOf(by); !caller.outranks(role) {
+		return fmt.Errorf("%w: %s cannot give the %s role", ErrInsufficientPermissions, caller, role)
+	}
+
+	if err := m.adapter.SetRole(ctx, o.ID, who, role); err != nil {
+		return fmt.Errorf("cannot set the role: %w", err)
+	}
+	return nil
+}
+
+func (m *manager) TransferOwnership(ctx context.Context, o Org, by, to id.ID) error {
+	if by != o.Owner {
+		return fmt.Errorf("%w: only the owner can transfer the ownership", ErrInsufficientPermissions)
+	}
+	if !o.IsMember(to) {
+		return ErrNotMember
+	}
+	if to == o.Owner {
+		return fmt.Errorf("%w: the user is the owner already", ErrInvalidRole)
+	}
+
+	if err := m.adapter.TransferOwnership(ctx, o.ID, o.Owner, to); err != nil {
+		return fmt.Errorf("cannot transfer the ownership: %w", err)
+	}
+	return nil
+}
+
+func (m *manager) Settings(_ context.Context, o Org, by id.ID) (Settings, error) {
+	if err := o.authorize(by, PermManageSettings); err != nil {
+		return Settings{}, err
+	}
+	return o.Settings, nil
+}
+
+func (m *manager) UpdateSettings(ctx context.Context, o Org, by id.ID, settings Settings) error {
+	if err := o.authorize(by, PermManageSettings); err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if err := m.adapter.SetSettings(ctx, o.ID, settings); err != nil {
+		return fmt.Errorf("cannot save settings: %w", err)
+	}
+	return nil
+}
+
+var _ Manager = (*manager)(nil)
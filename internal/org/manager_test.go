@@ -0,0 +1,269 @@
+package org
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pokergo/pkg/id"
+)
+
+// memoryAdapter is an in-memory Adapter
+type memoryAdapter struct {
+	orgs map[id.ID]Org
+}
+
+func (m *memoryAdapter) GetOrgByID(_ context.Context, oID id.ID) (Org, error) {
+	o, ok := m.orgs[oID]
+	if !ok {
+		return Org{}, ErrOrgNotExists
+	}
+	return o, nil
+}
+
+func (m *memoryAdapter) GetOrgByName(_ context.Context, name string) (Org, error) {
+	for _, o := range m.orgs {
+		if o.Name == name {
+			return o, nil
+		}
+	}
+	return Org{}, ErrOrgNotExists
+}
+
+func (m *memoryAdapter) CreateOrg(_ context.Context, owner id.ID, orgName string) (Org, error) {
+	o := Org{ID: id.NewID(), Name: orgName, Owner: owner, Members: []id.ID{owner}}
+	m.orgs[o.ID] = o
+	return o, nil
+}
+
+func (m *memoryAdapter) AddToOrg(_ context.Context, orgID id.ID, who id.ID) error {
+	o := m.orgs[orgID]
+	o.Members = append(o.Members, who)
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryAdapter) RemoveFromOrg(_ context.Context, orgID id.ID, who id.ID) error {
+	o := m.orgs[orgID]
+	var members []id.ID
+	for _, member := range o.Members {
+		if member != who {
+			members = append(members, member)
+		}
+	}
+	o.Members, o.Roles = members, o.withRole(who, RoleMember)
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryAdapter) SetRole(_ context.Context, orgID id.ID, who id.ID, role Role) error {
+	o := m.orgs[orgID]
+	if !o.IsMember(who) {
+		return ErrOrgChanged
+	}
+	o.Roles = o.withRole(who, role)
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryAdapter) TransferOwnership(_ context.Context, orgID id.ID, from, to id.ID) error {
+	o := m.orgs[orgID]
+	if o.Owner != from || !o.IsMember(to) {
+		return ErrOrgChanged
+	}
+	o.Roles = append(o.withRole(to, RoleOwner), MemberRole{UserID: from, Role: RoleAdmin})
+	o.Owner = to
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryAdapter) SetSettings(_ context.Context, orgID id.ID, settings Settings) error {
+	o := m.orgs[orgID]
+	o.Settings = settings
+	m.orgs[orgID] = o
+	return nil
+}
+
+func (m *memoryAdapter) ListUserOrg(_ context.Context, userID id.ID) ([]Org, error) {
+	var res []Org
+	for _, o := range m.orgs {
+		if o.IsMember(userID) {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+func Test_Manager_Roles(t *testing.T) {
+	ctx := context.Background()
+	a := &memoryAdapter{orgs: make(map[id.ID]Org)}
+	m := NewManager(a)
+	owner, admin, organizer, member, outsider := id.NewID(), id.NewID(), id.NewID(), id.NewID(), id.NewID()
+	o, _ := a.CreateOrg(ctx, owner, "org")
+	get := func() Org {
+		return a.orgs[o.ID]
+	}
+	role := func(uID id.ID) Role {
+		r, _ := get().RoleOf(uID)
+		return r
+	}
+
+	if err := m.AddMember(ctx, get(), owner, organizer); err != nil {
+		t.Fatalf("cannot add a member: %s", err)
+	}
+	if err := m.SetRole(ctx, get(), owner, organizer, RoleOrganizer); err != nil {
+		t.Fatalf("cannot set the role: %s", err)
+	}
+	if err := m.AddMember(ctx, get(), organizer, member); err != nil {
+		t.Fatalf("organizers add members: %s", err)
+	}
+	if err := m.AddMember(ctx, get(), member, outsider); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("members cannot add members, got %v", err)
+	}
+	if err := m.AddMember(ctx, get(), owner, member); !errors.Is(err, ErrAlreadyMember) {
+		t.Errorf("expected ErrAlreadyMember, got %v", err)
+	}
+
+	_ = m.AddMember(ctx, get(), owner, admin)
+	if err := m.SetRole(ctx, get(), owner, admin, RoleAdmin); err != nil {
+		t.Fatalf("cannot set the role: %s", err)
+	}
+	if err := m.SetRole(ctx, get(), admin, member, RoleAdmin); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("admins cannot make admins, got %v", err)
+	}
+	if err := m.SetRole(ctx, get(), organizer, member, RoleOrganizer); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("organizers cannot manage roles, got %v", err)
+	}
+	if err := m.SetRole(ctx, get(), owner, member, RoleOwner); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("the ownership can be transferred only, got %v", err)
+	}
+	if err := m.RemoveMember(ctx, get(), admin, owner); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("admins cannot remove the owner, got %v", err)
+	}
+	if err := m.RemoveMember(ctx, get(), admin, organizer); err != nil {
+		t.Fatalf("cannot remove the member: %s", err)
+	}
+	if get().IsMember(organizer) || len(get().Roles) != 1 {
+		t.Errorf("the member and his role should be removed: %+v", get())
+	}
+
+	if err := m.Leave(ctx, get(), owner); !errors.Is(err, ErrOwnerCannotLeave) {
+		t.Errorf("expected ErrOwnerCannotLeave, got %v", err)
+	}
+	if err := m.TransferOwnership(ctx, get(), admin, member); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("only the owner transfers the ownership, got %v", err)
+	}
+	if err := m.TransferOwnership(ctx, get(), owner, member); err != nil {
+		t.Fatalf("cannot transfer the ownership: %s", err)
+	}
+	if role(member) != RoleOwner || role(owner) != RoleAdmin || role(admin) != RoleAdmin {
+		t.Errorf("unexpected roles after the transfer: %+v", get())
+	}
+	if err := m.Leave(ctx, get(), owner); err != nil {
+		t.Fatalf("the former owner can leave: %s", err)
+	}
+	if err := m.Leave(ctx, get(), outsider); !errors.Is(err, ErrNotMember) {
+		t.Errorf("expected ErrNotMember, got %v", err)
+	}
+}
+
+func Test_Manager_RolesFromStaleOrg(t *testing.T) {
+	ctx := context.Background()
+	a := &memoryAdapter{orgs: make(map[id.ID]Org)}
+	m := NewManager(a)
+	owner, admin, removed, member := id.NewID(), id.NewID(), id.NewID(), id.NewID()
+	o, _ := a.CreateOrg(ctx, owner, "org")
+	for _, u := range []id.ID{admin, removed, member} {
+		_ = a.AddToOrg(ctx, o.ID, u)
+	}
+	_ = a.SetRole(ctx, o.ID, removed, RoleOrganizer)
+	stale := a.orgs[o.ID]
+
+	// changes made in the meantime by other requests
+	if err := m.SetRole(ctx, a.orgs[o.ID], owner, admin, RoleAdmin); err != nil {
+		t.Fatalf("cannot set the role: %s", err)
+	}
+	if err := m.RemoveMember(ctx, a.orgs[o.ID], owner, removed); err != nil {
+		t.Fatalf("cannot remove the member: %s", err)
+	}
+
+	if err := m.SetRole(ctx, stale, owner, member, RoleOrganizer); err != nil {
+		t.Fatalf("cannot set the role: %s", err)
+	}
+	if err := m.SetRole(ctx, stale, owner, removed, RoleAdmin); !errors.Is(err, ErrOrgChanged) {
+		t.Errorf("the role of a removed member cannot be set, got %v", err)
+	}
+	got := a.orgs[o.ID]
+	if r, _ := got.RoleOf(admin); r != RoleAdmin {
+		t.Errorf("a concurrent role change was lost: %+v", got.Roles)
+	}
+	if r, _ := got.RoleOf(member); r != RoleOrganizer {
+		t.Errorf("the role should be set: %+v", got.Roles)
+	}
+	for _, r := range got.Roles {
+		if r.UserID == removed {
+			t.Errorf("the role of the removed member came back: %+v", got.Roles)
+		}
+	}
+
+	_ = m.TransferOwnership(ctx, a.orgs[o.ID], owner, admin)
+	if err := m.TransferOwnership(ctx, stale, owner, member); !errors.Is(err, ErrOrgChanged) {
+		t.Errorf("the ownership was transferred already, got %v", err)
+	}
+}
+
+func Test_Manager_Settings(t *testing.T) {
+	ctx := context.Background()
+	a := &memoryAdapter{orgs: make(map[id.ID]Org)}
+	m := NewManager(a)
+	owner, member := id.NewID(), id.NewID()
+	o, _ := a.CreateOrg(ctx, owner, "org")
+	_ = a.AddToOrg(ctx, o.ID, member)
+	o = a.orgs[o.ID]
+
+	settings := Settings{
+		DefaultBuyIn: 5000,
+		Currency:     "PLN",
+		Precision:    2,
+		MaxRebuys:    3,
+		Rake:         RakePolicy{Fixed: 100, Percent: 5, Cap: 1000},
+		Timezone:     "Europe/Warsaw",
+	}
+	if err := m.UpdateSettings(ctx, o, member, settings); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("members cannot change settings, got %v", err)
+	}
+	if _, err := m.Settings(ctx, o, member); !errors.Is(err, ErrInsufficientPermissions) {
+		t.Errorf("members cannot see settings, got %v", err)
+	}
+
+	invalid := []Settings{
+		{DefaultBuyIn: -1},
+		{MaxRebuys: -1},
+		{Currency: "zloty"},
+		{Precision: MaxPrecision + 1},
+		{Rake: RakePolicy{Percent: 101}},
+		{Rake: RakePolicy{Fixed: -1}},
+		{Timezone: "Europe/Nowhere"},
+	}
+	for _, s := range invalid {
+		if err := m.UpdateSettings(ctx, o, owner, s); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("%+v: expected ErrInvalidSettings, got %v", s, err)
+		}
+	}
+
+	if err := m.UpdateSettings(ctx, o, owner, settings); err != nil {
+		t.Fatalf("cannot update settings: %s", err)
+	}
+	if got, err := m.Settings(ctx, a.orgs[o.ID], owner); err != nil || got != settings {
+		t.Errorf("unexpected settings: %+v (%v)", got, err)
+	}
+}
+
+func Test_RakePolicy_Amount(t *testing.T) {
+	p := RakePolicy{Fixed: 100, Percent: 5, Cap: 1000}
+	for buyIns, want := range map[int64]int64{0: 100, 10000: 600, 50000: 1100} {
+		if got := p.Amount(buyIns); got != want {
+			t.Errorf("%d: expected %d, got %d", buyIns, want, got)
+		}
+	}
+}
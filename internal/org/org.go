@@ -15,15 +15,20 @@ import (
 )
 
 type Org struct {
-	ID        id.ID     `bson:"_id"` // nolint:tagliatelle // mongo-id
-	Name      string    `bson:"name"`
-	Admin     id.ID     `bson:"admin"`
-	Members   []id.ID   `bson:"members"`
+	ID   id.ID  `bson:"_id"` // nolint:tagliatelle // mongo-id
+	Name string `bson:"name"`
+	// Owner has RoleOwner (stored as admin, organizations had a single admin before roles were introduced)
+	Owner   id.ID   `bson:"admin"`
+	Members []id.ID `bson:"members"`
+	// Roles are roles given explicitly, members without a role have RoleMember
+	Roles []MemberRole `bson:"roles,omitempty"`
+	// Settings are defaults of new games (organizations created before settings have zero settings)
+	Settings  Settings  `bson:"settings"`
 	CreatedAt time.Time `bson:"created_at"`
 }
 
 func (o Org) IsMember(id id.ID) bool {
-	if id == o.Admin {
+	if id == o.Owner {
 		return true
 	}
 	for idx := range o.Members {
@@ -40,9 +45,19 @@ type Adapter interface {
 	// GetOrgByName returns org by its name
 	GetOrgByName(ctx context.Context, name string) (Org, error)
 	// CreateOrg creates a new Org
-	CreateOrg(ctx context.Context, admin id.ID, orgName string) (Org, error)
+	CreateOrg(ctx context.Context, owner id.ID, orgName string) (Org, error)
 	// AddToOrg adds a new member to the organization
 	AddToOrg(ctx context.Context, orgID id.ID, who id.ID) error
+	// RemoveFromOrg removes the member and his role from the organization in a single update
+	RemoveFromOrg(ctx context.Context, orgID id.ID, who id.ID) error
+	// SetRole replaces the role of the member (RoleMember removes the explicit role),
+	// ErrOrgChanged is returned if the user is not a member anymore
+	SetRole(ctx context.Context, orgID id.ID, who id.ID, role Role) error
+	// TransferOwnership makes the member the owner and the former owner an admin,
+	// ErrOrgChanged is returned if the owner has changed or the user is not a member anymore
+	TransferOwnership(ctx context.Context, orgID id.ID, from, to id.ID) error
+	// SetSettings replaces settings of the organization
+	SetSettings(ctx context.Context, orgID id.ID, settings Settings) error
 	// ListUserOrg list all organizations where user belongs to
 	ListUserOrg(ctx context.Context, userID id.ID) ([]Org, error)
 }
@@ -119,12 +134,12 @@ func (m *mongoAdapter) GetOrgByName(ctx context.Context, name string) (Org, erro
 	return org, nil
 }
 
-func (m *mongoAdapter) CreateOrg(ctx context.Context, admin id.ID, orgName string) (Org, error) {
+func (m *mongoAdapter) CreateOrg(ctx context.Context, owner id.ID, orgName string) (Org, error) {
 	newOrg := Org{
 		ID:        id.NewID(),
 		Name:      orgName,
-		Admin:     admin,
-		Members:   []id.ID{admin},
+		Owner:     owner,
+		Members:   []id.ID{owner},
 		CreatedAt: m.timer.Now(),
 	}
 
@@ -157,6 +172,108 @@ func (m *mongoAdapter) AddToOrg(ctx context.Context, orgID id.ID, who id.ID) err
 	return nil
 }
 
+func (m *mongoAdapter) RemoveFromOrg(ctx context.Context, orgID id.ID, who id.ID) error {
+	find := bson.M{
+		"_id": orgID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"members": who,
+			"roles": bson.M{
+				"user_id": who,
+			},
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, find, update)
+	if err != nil {
+		return fmt.Errorf("cannot update members: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("cannot find the organization")
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) SetRole(ctx context.Context, orgID id.ID, who id.ID, role Role) error {
+	// the member must still be a member, so a stale role is never written back after the member was removed
+	find := bson.M{
+		"_id":     orgID,
+		"members": who,
+	}
+	roles := withoutRoles(who)
+	if role != RoleMember {
+		roles = bson.M{"$concatArrays": bson.A{roles, bson.A{MemberRole{UserID: who, Role: role}}}}
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"roles": roles}}},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, find, update)
+	if err != nil {
+		return fmt.Errorf("cannot update roles: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrOrgChanged
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) TransferOwnership(ctx context.Context, orgID id.ID, from, to id.ID) error {
+	find := bson.M{
+		"_id":     orgID,
+		"admin":   from,
+		"members": to,
+	}
+	roles := bson.M{"$concatArrays": bson.A{withoutRoles(from, to), bson.A{MemberRole{UserID: from, Role: RoleAdmin}}}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"admin": to, "roles": roles}}},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, find, update)
+	if err != nil {
+		return fmt.Errorf("cannot transfer the ownership: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrOrgChanged
+	}
+
+	return nil
+}
+
+// withoutRoles is an aggregation expression of stored roles without roles of given users
+func withoutRoles(users ...id.ID) bson.M {
+	return bson.M{
+		"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$roles", bson.A{}}},
+			"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this.user_id", users}}}},
+		},
+	}
+}
+
+func (m *mongoAdapter) SetSettings(ctx context.Context, orgID id.ID, settings Settings) error {
+	find := bson.M{
+		"_id": orgID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"settings": settings,
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, find, update)
+	if err != nil {
+		return fmt.Errorf("cannot update settings: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("cannot find the organization")
+	}
+
+	return nil
+}
+
 func (m *mongoAdapter) ListUserOrg(ctx context.Context, userID id.ID) ([]Org, error) {
 	find := bson.M{
 		"members": bson.M{
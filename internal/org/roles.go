@@ -0,0 +1,159 @@
+package org
+
+import (
+	"errors"
+	"fmt"
+
+	"pokergo/pkg/id"
+)
+
+var (
+	ErrInsufficientPermissions = errors.New("insufficient permissions to manage the organization")
+	ErrNotMember               = errors.New("the user is not a member of the organization")
+	ErrAlreadyMember           = errors.New("the user is already a member of the organization")
+	ErrInvalidRole             = errors.New("invalid role")
+	ErrOwnerCannotLeave        = errors.New("the owner cannot leave the organization before transferring the ownership")
+	ErrOrgChanged              = errors.New("the organization has been changed in the meantime")
+)
+
+// Role is a role of the member in the organization
+type Role string
+
+const (
+	// RoleOwner can do everything, there is exactly one owner (the creator or the one who got the ownership)
+	RoleOwner Role = "owner"
+	// RoleAdmin manages members and their roles
+	RoleAdmin Role = "admin"
+	// RoleOrganizer adds members and manages all the games of the organization
+	RoleOrganizer Role = "organizer"
+	// RoleMember plays and views games
+	RoleMember Role = "member"
+)
+
+// Permission is an operation in the organization which requires a role
+type Permission string
+
+const (
+	// PermAddMembers allows adding new members
+	PermAddMembers Permission = "add_members"
+	// PermRemoveMembers allows removing members with lower roles
+	PermRemoveMembers Permission = "remove_members"
+	// PermManageRoles allows giving members roles lower than the own one
+	PermManageRoles Permission = "manage_roles"
+	// PermManageGames makes the member an organizer of every game of the organization
+	PermManageGames Permission = "manage_games"
+	// PermManagePayments allows recording payments of debts of other members
+	PermManagePayments Permission = "manage_payments"
+	// PermReviewJoinRequests allows approving and rejecting requests of users to join
+	PermReviewJoinRequests Permission = "review_join_requests"
+	// PermManageSettings allows reading and changing settings of the organization
+	PermManageSettings Permission = "manage_settings"
+)
+
+// permissions lists permissions of a role
+var permissions = map[Role][]Permission{ // nolint:gochecknoglobals // cannot be const
+	RoleOwner: {PermAddMembers, PermRemoveMembers, PermManageRoles, PermManageGames, PermManagePayments,
+		PermReviewJoinRequests, PermManageSettings},
+	RoleAdmin: {PermAddMembers, PermRemoveMembers, PermManageRoles, PermManageGames, PermManagePayments,
+		PermReviewJoinRequests, PermManageSettings},
+	RoleOrganizer: {PermAddMembers, PermManageGames},
+	RoleMember:    {},
+}
+
+// ranks orders roles, a member can manage members with lower ranks only
+var ranks = map[Role]int{ // nolint:gochecknoglobals // cannot be const
+	RoleMember:    0,
+	RoleOrganizer: 1,
+	RoleAdmin:     2,
+	RoleOwner:     3,
+}
+
+// IsValid tells if the role exists
+func (r Role) IsValid() bool {
+	_, ok := permissions[r]
+	return ok
+}
+
+// Can tells if the role has the permission
+func (r Role) Can(p Permission) bool {
+	for _, allowed := range permissions[r] {
+		if allowed == p {
+			return true
+		}
+	}
+	return false
+}
+
+// outranks tells if the role is higher than the other one
+func (r Role) outranks(other Role) bool {
+	return ranks[r] > ranks[other]
+}
+
+// MemberRole is a role explicitly given to the member
+type MemberRole struct {
+	UserID id.ID `json:"user_id" bson:"user_id"`
+	Role   Role  `json:"role" bson:"role"`
+}
+
+// RoleOf returns the role of the member, false if the user is not a member
+func (o Org) RoleOf(uID id.ID) (Role, bool) {
+	if !o.IsMember(uID) {
+		return "", false
+	}
+	if uID == o.Owner {
+		return RoleOwner, true
+	}
+	for _, r := range o.Roles {
+		if r.UserID == uID {
+			return r.Role, true
+		}
+	}
+	return RoleMember, true
+}
+
+// Can tells if the user is a member with the permission
+func (o Org) Can(uID id.ID, p Permission) bool {
+	role, ok := o.RoleOf(uID)
+	return ok && role.Can(p)
+}
+
+// authorize returns ErrInsufficientPermissions if the user has not the permission
+func (o Org) authorize(uID id.ID, p Permission) error {
+	role, ok := o.RoleOf(uID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInsufficientPermissions, ErrNotMember.Error())
+	}
+	if !role.Can(p) {
+		return fmt.Errorf("%w: %s has not the %s permission", ErrInsufficientPermissions, role, p)
+	}
+	return nil
+}
+
+// manage checks the user by can change the member who (with the permission and a higher role)
+func (o Org) manage(by, who id.ID, p Permission) (Role, error) {
+	if err := o.authorize(by, p); err != nil {
+		return "", err
+	}
+	target, ok := o.RoleOf(who)
+	if !ok {
+		return "", ErrNotMember
+	}
+	if role, _ := o.RoleOf(by); !role.outranks(target) {
+		return "", fmt.Errorf("%w: %s cannot manage %s", ErrInsufficientPermissions, role, target)
+	}
+	return target, nil
+}
+
+// withRole returns roles with the role of the member replaced (RoleMember is not stored)
+func (o Org) withRole(uID id.ID, role Role) []MemberRole {
+	roles := make([]MemberRole, 0, len(o.Roles)+1)
+	for _, r := range o.Roles {
+		if r.UserID != uID {
+			roles = append(roles, r)
+		}
+	}
+	if role != RoleMember && role != RoleOwner {
+		roles = append(roles, MemberRole{UserID: uID, Role: role})
+	}
+	return roles
+}
@@ -0,0 +1,67 @@
+package org
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+	_ "time/tzdata" // timezones of organizations do not depend on the system database
+)
+
+var ErrInvalidSettings = errors.New("invalid organization settings")
+
+// MaxPrecision is the maximal number of digits of the minor unit of the currency
+const MaxPrecision = 4
+
+// currencyCode is an ISO 4217 code
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`) // nolint:gochecknoglobals // cannot be const
+
+// RakePolicy is the rake usually taken by the house: Fixed plus Percent of all the buy-ins (at most Cap)
+type RakePolicy struct {
+	Fixed   int64 `bson:"fixed" json:"fixed"`
+	Percent int   `bson:"percent" json:"percent"`
+	// Cap limits the percentage part of the rake, 0 - no limit
+	Cap int64 `bson:"cap" json:"cap"`
+}
+
+// Amount returns the rake of the game with the total buy-ins
+func (p RakePolicy) Amount(buyIns int64) int64 {
+	rake := buyIns * int64(p.Percent) / 100
+	if p.Cap > 0 && rake > p.Cap {
+		rake = p.Cap
+	}
+	return p.Fixed + rake
+}
+
+// Settings are defaults of games of the organization, amounts are in minor units of the currency
+type Settings struct {
+	// DefaultBuyIn is the buy-in of players joining a game without an explicit one, 0 - no default
+	DefaultBuyIn int64 `bson:"default_buy_in" json:"default_buy_in"`
+	// Currency is an ISO 4217 code, empty for chips
+	Currency string `bson:"currency" json:"currency"`
+	// Precision is the number of digits of the minor unit (2 if amounts are in cents)
+	Precision int `bson:"precision" json:"precision"`
+	// MaxRebuys limits re-buy-ins of a player in a cash game, 0 means no limit
+	MaxRebuys int        `bson:"max_rebuys" json:"max_rebuys"`
+	Rake      RakePolicy `bson:"rake" json:"rake"`
+	// Timezone is an IANA timezone of games, empty for UTC
+	Timezone string `bson:"timezone" json:"timezone"`
+}
+
+// Validate checks amounts, the currency code and the timezone
+func (s Settings) Validate() error {
+	switch {
+	case s.DefaultBuyIn < 0 || s.MaxRebuys < 0:
+		return fmt.Errorf("%w: the buy-in and the rebuys limit cannot be negative", ErrInvalidSettings)
+	case s.Currency != "" && !currencyCode.MatchString(s.Currency):
+		return fmt.Errorf("%w: the currency must be an ISO 4217 code", ErrInvalidSettings)
+	case s.Precision < 0 || s.Precision > MaxPrecision:
+		return fmt.Errorf("%w: the precision must be from 0 to %d", ErrInvalidSettings, MaxPrecision)
+	case s.Rake.Fixed < 0 || s.Rake.Cap < 0 || s.Rake.Percent < 0 || s.Rake.Percent > 100:
+		return fmt.Errorf("%w: invalid rake policy", ErrInvalidSettings)
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSettings, s.Timezone)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"pokergo/internal/table"
+	"pokergo/pkg/poker"
+)
+
+var ErrUnknownBot = errors.New("unknown bot")
+
+// DefaultEquityThreshold is the equity (in percents) the equity bot needs to call by default
+const DefaultEquityThreshold = 50
+
+// Bot is a strategy of a simulated player
+type Bot interface {
+	// Name is the name of the strategy shown in reports
+	Name() string
+	// Act returns the action of the bot, the view is seen by the bot and it is the bot's turn (view.Hand.Legal is set).
+	// Random decisions must use rng only, so simulations can be repeated with the same seed.
+	Act(view table.View, rng *rand.Rand) table.Action
+}
+
+// NewBot returns the built-in strategy by the name:
+// "random", "tag" (tight-aggressive) or "equity" ("equity:60" calls with at least 60% equity)
+func NewBot(name string) (Bot, error) {
+	parts := strings.SplitN(name, ":", 2)
+	switch {
+	case parts[0] == "random" && len(parts) == 1:
+		return NewRandomBot(), nil
+	case parts[0] == "tag" && len(parts) == 1:
+		return NewTAGBot(), nil
+	case parts[0] == "equity" && len(parts) == 1:
+		return NewEquityBot(DefaultEquityThreshold), nil
+	case parts[0] == "equity":
+		threshold, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || threshold < 0 || threshold > 100 {
+			return nil, fmt.Errorf("%w: the equity threshold must be from 0 to 100", ErrUnknownBot)
+		}
+		return NewEquityBot(threshold), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownBot, name)
+}
+
+// legal returns true if the action is legal
+func legal(l *table.Legal, a table.ActionType) bool {
+	for _, allowed := range l.Actions {
+		if allowed == a {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrFold checks if it is free, folds otherwise
+func checkOrFold(l *table.Legal) table.Action {
+	if legal(l, table.ActionCheck) {
+		return table.Action{Type: table.ActionCheck}
+	}
+	return table.Action{Type: table.ActionFold}
+}
+
+// checkOrCall checks if it is free, calls otherwise
+func checkOrCall(l *table.Legal) table.Action {
+	if legal(l, table.ActionCheck) {
+		return table.Action{Type: table.ActionCheck}
+	}
+	return table.Action{Type: table.ActionCall}
+}
+
+// betTo bets or raises to the amount (limited by the legal range), it calls (or checks) if raising is not allowed
+func betTo(l *table.Legal, amount int64) table.Action {
+	if amount < l.MinTo {
+		amount = l.MinTo
+	}
+	if amount > l.MaxTo {
+		amount = l.MaxTo
+	}
+	switch {
+	case legal(l, table.ActionBet):
+		return table.Action{Type: table.ActionBet, Amount: amount}
+	case legal(l, table.ActionRaise):
+		return table.Action{Type: table.ActionRaise, Amount: amount}
+	}
+	return checkOrCall(l)
+}
+
+// cards returns hole cards of the viewer and the board
+func cards(v table.View) ([]poker.Card, []poker.Card) {
+	hole, _ := poker.ParseCards(v.Seats[v.You].Cards)
+	board, _ := poker.ParseCards(v.Hand.Board)
+	return hole, board
+}
+
+// opponents returns the number of players in the hand who did not fold, except the viewer
+func opponents(v table.View) int {
+	no := 0
+	for seat, s := range v.Seats {
+		if s != nil && s.InHand && !s.Folded && seat != v.You {
+			no++
+		}
+	}
+	return no
+}
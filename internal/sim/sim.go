@@ -0,0 +1,185 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"pokergo/internal/table"
+	"pokergo/pkg/id"
+)
+
+var ErrInvalidConfig = errors.New("invalid simulation config")
+
+const (
+	// smallBlind and bigBlind are blinds of simulated hands, results are reported in big blinds
+	smallBlind = 50
+	bigBlind   = 100
+	// DefaultStack is the stack in big blinds every player starts each hand with
+	DefaultStack = 100
+	// z95 is the z-score of the 95% confidence interval
+	z95 = 1.96
+)
+
+// Config configures Run
+type Config struct {
+	// Bots are players by seats (2 for heads-up, up to table.MaxSeats for ring games)
+	Bots  []Bot
+	Hands int
+	// Seed determines cards and random decisions of bots, simulations with the same seed end up the same
+	Seed int64
+	// Stack is the stack in big blinds, stacks are reset before every hand (DefaultStack if 0)
+	Stack int64
+}
+
+// StrategyResult are winnings of all the players using the strategy
+type StrategyResult struct {
+	Strategy string `json:"strategy"`
+	Seats    int    `json:"seats"`
+	// Won are big blinds won by the strategy
+	Won float64 `json:"won"`
+	// BB100 are big blinds won per 100 hands (of a single player)
+	BB100 float64 `json:"bb100"`
+	// CI95 is the half-width of the 95% confidence interval of BB100
+	CI95 float64 `json:"ci95"`
+}
+
+// Result is the outcome of the simulation, strategies are ordered by BB100 (the best first)
+type Result struct {
+	Hands      int              `json:"hands"`
+	Strategies []StrategyResult `json:"strategies"`
+}
+
+// winnings are statistics of big blinds won per hand by all the seats of the strategy
+// (seats at the same table are not independent, so hands are the samples)
+type winnings struct {
+	seats      int
+	n          int
+	sum, sumSq float64
+}
+
+func (w *winnings) add(bb float64) {
+	w.n++
+	w.sum += bb
+	w.sumSq += bb * bb
+}
+
+// Run plays hands between bots at a single table and reports winnings of every strategy.
+// Stacks are reset before every hand, the button moves as in a real game.
+func Run(config Config) (Result, error) {
+	if len(config.Bots) < 2 || len(config.Bots) > table.MaxSeats {
+		return Result{}, fmt.Errorf("%w: from 2 to %d bots are needed", ErrInvalidConfig, table.MaxSeats)
+	}
+	if config.Hands <= 0 {
+		return Result{}, fmt.Errorf("%w: the number of hands must be positive", ErrInvalidConfig)
+	}
+	if config.Stack == 0 {
+		config.Stack = DefaultStack
+	}
+	stack := config.Stack * bigBlind
+	t, err := table.New(table.Config{
+		Seats:      len(config.Bots),
+		SmallBlind: smallBlind,
+		BigBlind:   bigBlind,
+		MinBuyIn:   stack,
+		MaxBuyIn:   stack,
+	}, config.Seed)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+
+	players := make([]id.ID, len(config.Bots))
+	rngs := make([]*rand.Rand, len(config.Bots))
+	stats := make(map[string]*winnings)
+	for seat, bot := range config.Bots {
+		players[seat] = id.NewID()
+		rngs[seat] = rand.New(rand.NewSource(config.Seed + int64(seat) + 1)) // nolint:gosec // repeatable simulations
+		if stats[bot.Name()] == nil {
+			stats[bot.Name()] = &winnings{}
+		}
+		stats[bot.Name()].seats++
+	}
+
+	for no := 0; no < config.Hands; no++ {
+		if err := reset(t, players, stack); err != nil {
+			return Result{}, err
+		}
+		if err := play(t, config.Bots, players, rngs); err != nil {
+			return Result{}, err
+		}
+
+		view := t.View(id.ID{})
+		won := make(map[string]float64, len(stats))
+		for seat, bot := range config.Bots {
+			won[bot.Name()] += float64(view.Seats[seat].Stack-stack) / bigBlind
+		}
+		for name, bb := range won {
+			stats[name].add(bb)
+		}
+	}
+
+	res := Result{Hands: config.Hands}
+	for name, w := range stats {
+		mean := w.sum / float64(w.n)
+		variance := 0.0
+		if w.n > 1 {
+			variance = math.Max(0, (w.sumSq-w.sum*mean)/float64(w.n-1))
+		}
+		// results of the strategy are split evenly between its seats
+		perSeat := 100 / float64(w.seats)
+		res.Strategies = append(res.Strategies, StrategyResult{
+			Strategy: name,
+			Seats:    w.seats,
+			Won:      w.sum,
+			BB100:    perSeat * mean,
+			CI95:     perSeat * z95 * math.Sqrt(variance/float64(w.n)),
+		})
+	}
+	sort.Slice(res.Strategies, func(i, j int) bool {
+		a, b := res.Strategies[i], res.Strategies[j]
+		if a.BB100 != b.BB100 {
+			return a.BB100 > b.BB100
+		}
+		return a.Strategy < b.Strategy
+	})
+	return res, nil
+}
+
+// reset seats players again with the starting stack
+func reset(t *table.Table, players []id.ID, stack int64) error {
+	view := t.View(id.ID{})
+	for seat, s := range view.Seats {
+		if s != nil && s.Stack == stack {
+			continue
+		}
+		if s != nil {
+			if _, err := t.Leave(players[seat]); err != nil {
+				return fmt.Errorf("cannot leave the table: %w", err)
+			}
+		}
+		if err := t.Sit(players[seat], fmt.Sprintf("seat %d", seat+1), seat, stack); err != nil {
+			return fmt.Errorf("cannot sit: %w", err)
+		}
+	}
+	return nil
+}
+
+// play plays a single hand, illegal actions of bots are replaced with checks or folds
+func play(t *table.Table, bots []Bot, players []id.ID, rngs []*rand.Rand) error {
+	if err := t.StartHand(); err != nil {
+		return fmt.Errorf("cannot start the hand: %w", err)
+	}
+	for t.InHand() {
+		seat := t.ToAct()
+		view := t.View(players[seat])
+		if err := t.Act(players[seat], bots[seat].Act(view, rngs[seat])); err == nil {
+			continue
+		}
+		if err := t.Act(players[seat], checkOrFold(view.Hand.Legal)); err != nil {
+			return fmt.Errorf("cannot act: %w", err)
+		}
+	}
+	return nil
+}
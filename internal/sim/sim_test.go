@@ -0,0 +1,99 @@
+package sim
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRunRepeatable(t *testing.T) {
+	config := Config{Bots: []Bot{NewTAGBot(), NewRandomBot(), NewEquityBot(60)}, Hands: 200, Seed: 7}
+	first, err := Run(config)
+	if err != nil {
+		t.Fatalf("cannot run: %s", err)
+	}
+	second, err := Run(config)
+	if err != nil {
+		t.Fatalf("cannot run: %s", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("the same seed must give the same result: %+v != %+v", first, second)
+	}
+
+	if first.Hands != 200 || len(first.Strategies) != 3 {
+		t.Fatalf("unexpected result: %+v", first)
+	}
+	// chips are not created nor lost
+	total := 0.0
+	for i, s := range first.Strategies {
+		total += s.Won
+		if math.Abs(s.BB100-s.Won/2) > 1e-9 || s.CI95 <= 0 {
+			t.Errorf("unexpected result of %s: %+v", s.Strategy, s)
+		}
+		if i > 0 && s.BB100 > first.Strategies[i-1].BB100 {
+			t.Errorf("strategies must be ordered by bb/100")
+		}
+	}
+	if math.Abs(total) > 1e-9 {
+		t.Errorf("won big blinds must sum up to 0, got %f", total)
+	}
+}
+
+func TestRunSameStrategy(t *testing.T) {
+	res, err := Run(Config{Bots: []Bot{NewTAGBot(), NewTAGBot(), NewRandomBot()}, Hands: 100, Seed: 1, Stack: 50})
+	if err != nil {
+		t.Fatalf("cannot run: %s", err)
+	}
+	seats := map[string]int{}
+	for _, s := range res.Strategies {
+		seats[s.Strategy] = s.Seats
+		if math.Abs(s.BB100-s.Won/float64(s.Seats)) > 1e-9 {
+			t.Errorf("bb/100 must be reported per seat, got %+v", s)
+		}
+	}
+	if !reflect.DeepEqual(seats, map[string]int{"tag": 2, "random": 1}) {
+		t.Errorf("seats of the same strategy must be reported together, got %+v", res.Strategies)
+	}
+}
+
+func TestRunMirroredSeats(t *testing.T) {
+	// chips only move between seats of the same strategy, so its results do not vary
+	res, err := Run(Config{Bots: []Bot{NewRandomBot(), NewRandomBot()}, Hands: 100, Seed: 3})
+	if err != nil {
+		t.Fatalf("cannot run: %s", err)
+	}
+	if len(res.Strategies) != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if s := res.Strategies[0]; s.Won != 0 || s.BB100 != 0 || s.CI95 != 0 {
+		t.Errorf("a strategy playing itself wins nothing, got %+v", s)
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	if _, err := Run(Config{Bots: []Bot{NewTAGBot()}, Hands: 10}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for a single bot, got %v", err)
+	}
+	if _, err := Run(Config{Bots: []Bot{NewTAGBot(), NewTAGBot()}}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for no hands, got %v", err)
+	}
+}
+
+func TestNewBot(t *testing.T) {
+	for name, expected := range map[string]string{"random": "random", "tag": "tag", "equity": "equity", "equity:65": "equity:65"} {
+		bot, err := NewBot(name)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if bot.Name() != expected {
+			t.Errorf("expected %s, got %s", expected, bot.Name())
+		}
+	}
+	for _, name := range []string{"", "gto", "equity:x", "equity:101", "tag:1"} {
+		if _, err := NewBot(name); !errors.Is(err, ErrUnknownBot) {
+			t.Errorf("%q: expected ErrUnknownBot, got %v", name, err)
+		}
+	}
+}
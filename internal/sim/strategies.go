@@ -0,0 +1,192 @@
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+
+	"pokergo/internal/table"
+	"pokergo/pkg/poker"
+)
+
+// randomBot takes a random legal action, bets and raises are of a random size
+type randomBot struct{}
+
+func NewRandomBot() Bot {
+	return randomBot{}
+}
+
+func (randomBot) Name() string {
+	return "random"
+}
+
+func (randomBot) Act(view table.View, rng *rand.Rand) table.Action {
+	l := view.Hand.Legal
+	a := table.Action{Type: l.Actions[rng.Intn(len(l.Actions))]}
+	if a.Type == table.ActionBet || a.Type == table.ActionRaise {
+		a.Amount = l.MinTo + rng.Int63n(l.MaxTo-l.MinTo+1)
+	}
+	return a
+}
+
+const (
+	// tagRaise are hands the tight-aggressive bot raises preflop
+	tagRaise = "77+, ATs+, KJs+, QJs, AJo+, KQo"
+	// tagCall are hands the tight-aggressive bot calls a raise with preflop (besides tagRaise)
+	tagCall = "22-66, A2s-A9s, KTs, QTs+, JTs, T9s, 98s, 87s, ATo, KJo, QJo"
+)
+
+// tagBot is a rule-based tight-aggressive player: it plays few strong hands preflop,
+// bets and raises strong made hands, calls small bets with a pair and gives up otherwise
+type tagBot struct {
+	raise map[poker.CardSet]bool
+	call  map[poker.CardSet]bool
+}
+
+func NewTAGBot() Bot {
+	set := func(notation string) map[poker.CardSet]bool {
+		r, err := poker.ParseRange(notation)
+		if err != nil {
+			panic(fmt.Sprintf("invalid range of the tag bot: %s", err.Error()))
+		}
+		hands := make(map[poker.CardSet]bool, len(r))
+		for _, h := range r.Hands() {
+			hands[poker.NewCardSet(h...)] = true
+		}
+		return hands
+	}
+	return tagBot{raise: set(tagRaise), call: set(tagCall)}
+}
+
+func (tagBot) Name() string {
+	return "tag"
+}
+
+func (b tagBot) Act(view table.View, _ *rand.Rand) table.Action {
+	l, h := view.Hand.Legal, view.Hand
+	bb := view.Config.BigBlind
+	hole, board := cards(view)
+
+	if h.Street == table.Preflop {
+		key := poker.NewCardSet(hole...)
+		switch {
+		case b.raise[key]:
+			// 3 big blinds when opening, 3 times the raise otherwise
+			return betTo(l, 3*h.CurrentBet)
+		case b.call[key] && l.Call <= 4*bb:
+			return checkOrCall(l)
+		}
+		return checkOrFold(l)
+	}
+
+	switch strength(hole, board) {
+	case strong:
+		return betTo(l, h.CurrentBet+(h.Pot+l.Call)*2/3)
+	case medium:
+		if legal(l, table.ActionCheck) || 3*l.Call <= h.Pot {
+			return checkOrCall(l)
+		}
+	case weak:
+	}
+	return checkOrFold(l)
+}
+
+type handStrength int
+
+const (
+	weak handStrength = iota
+	// medium is a pair made with a hole card
+	medium
+	// strong is the top pair, an overpair or better
+	strong
+)
+
+// strength rates the made hand after the flop
+func strength(hole, board []poker.Card) handStrength {
+	category := poker.Evaluate(append(append([]poker.Card{}, hole...), board...)).Category()
+	if category >= poker.TwoPair {
+		return strong
+	}
+	if category != poker.Pair {
+		return weak
+	}
+
+	var top poker.Rank
+	for _, c := range board {
+		if c.Rank() > top {
+			top = c.Rank()
+		}
+	}
+	if hole[0].Rank() == hole[1].Rank() {
+		if hole[0].Rank() > top {
+			return strong
+		}
+		return medium
+	}
+	for _, h := range hole {
+		for _, c := range board {
+			if h.Rank() != c.Rank() {
+				continue
+			}
+			if c.Rank() == top {
+				return strong
+			}
+			return medium
+		}
+	}
+	// the pair is on the board
+	return weak
+}
+
+const (
+	// equitySamples is the number of Monte Carlo samples of the equity bot
+	equitySamples = 400
+	// equityEnumeration is the maximal number of deals the equity bot enumerates exactly
+	equityEnumeration = 5000
+)
+
+// equityBot never bets, it checks when it is free and calls when its equity against random hands
+// of the opponents is at least the threshold
+type equityBot struct {
+	threshold float64
+	random    []poker.Hand
+}
+
+func NewEquityBot(threshold float64) Bot {
+	deck := poker.NewDeck().Cards()
+	hands := make([]poker.Hand, 0, poker.NumCombos)
+	for i := range deck {
+		for j := i + 1; j < len(deck); j++ {
+			hands = append(hands, poker.Hand{deck[i], deck[j]})
+		}
+	}
+	return equityBot{threshold: threshold, random: hands}
+}
+
+func (b equityBot) Name() string {
+	if b.threshold == DefaultEquityThreshold {
+		return "equity"
+	}
+	return fmt.Sprintf("equity:%g", b.threshold)
+}
+
+func (b equityBot) Act(view table.View, rng *rand.Rand) table.Action {
+	l := view.Hand.Legal
+	if legal(l, table.ActionCheck) {
+		return table.Action{Type: table.ActionCheck}
+	}
+
+	hole, board := cards(view)
+	ranges := [][]poker.Hand{{hole}}
+	for i := 0; i < opponents(view); i++ {
+		ranges = append(ranges, b.random)
+	}
+	res, err := poker.CalculateEquity(ranges, board, nil, poker.EquityConfig{
+		MaxEnumeration: equityEnumeration,
+		Samples:        equitySamples,
+		Seed:           rng.Int63(),
+	})
+	if err != nil || res.Players[0].Equity < b.threshold {
+		return table.Action{Type: table.ActionFold}
+	}
+	return table.Action{Type: table.ActionCall}
+}
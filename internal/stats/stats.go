@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"pokergo/internal/game"
+	"pokergo/pkg/id"
+)
+
+// PlayerStats are lifetime statistics of a player (amounts in subunits)
+type PlayerStats struct {
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	GamesPlayed int64  `json:"games_played"`
+	TotalProfit int64  `json:"total_profit"`
+	TotalBuyIn  int64  `json:"total_buy_in"`
+	// ROI is TotalProfit / TotalBuyIn
+	ROI float64 `json:"roi"`
+	// BiggestWin is the biggest profit in a single game (0 if the player never won)
+	BiggestWin int64 `json:"biggest_win"`
+	// BiggestLoss is the biggest loss in a single game as a positive number (0 if the player never lost)
+	BiggestLoss  int64   `json:"biggest_loss"`
+	AverageBuyIn float64 `json:"average_buy_in"`
+	// WinRate is the ratio of games finished with a profit
+	WinRate float64 `json:"win_rate"`
+}
+
+// Window limits games to the ones started in [From, To), nil means no limit
+type Window struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// Adapter computes statistics from closed games
+type Adapter interface {
+	// PlayerStats returns statistics of the user from games of given organizations
+	PlayerStats(ctx context.Context, userID id.ID, orgs []id.ID, window Window) (PlayerStats, error)
+	// Leaderboard returns statistics of the best no players of the organization (by total profit)
+	Leaderboard(ctx context.Context, orgID id.ID, window Window, no int) ([]PlayerStats, error)
+}
+
+type mongoAdapter struct {
+	games *mongo.Collection
+}
+
+func NewMongoAdapter(games *mongo.Collection) *mongoAdapter {
+	return &mongoAdapter{games: games}
+}
+
+func (m *mongoAdapter) EnsureIndexes(ctx context.Context) error {
+	statsIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "organization", Value: 1},
+			{Key: "status", Value: 1},
+			{Key: "start", Value: 1},
+		},
+	}
+
+	_, err := m.games.Indexes().CreateOne(ctx, statsIdx)
+	if err != nil {
+		return fmt.Errorf("cannot create organization:1,status:1,start:1 index: %w", err)
+	}
+
+	return nil
+}
+
+// aggregated is a result of the stats pipeline
+type aggregated struct {
+	UserID      id.ID  `bson:"_id"` // nolint:tagliatelle // mongo-id
+	UserName    string `bson:"user_name"`
+	Games       int64  `bson:"games"`
+	Profit      int64  `bson:"profit"`
+	BuyIn       int64  `bson:"buy_in"`
+	BiggestWin  int64  `bson:"biggest_win"`
+	BiggestLoss int64  `bson:"biggest_loss"`
+	Wins        int64  `bson:"wins"`
+}
+
+func (a aggregated) toStats() PlayerStats {
+	s := PlayerStats{
+		UserID:      a.UserID.Hex(),
+		UserName:    a.UserName,
+		GamesPlayed: a.Games,
+		TotalProfit: a.Profit,
+		TotalBuyIn:  a.BuyIn,
+	}
+	if a.BiggestWin > 0 {
+		s.BiggestWin = a.BiggestWin
+	}
+	if a.BiggestLoss < 0 {
+		s.BiggestLoss = -a.BiggestLoss
+	}
+	if a.BuyIn != 0 {
+		s.ROI = float64(a.Profit) / float64(a.BuyIn)
+	}
+	if a.Games != 0 {
+		s.AverageBuyIn = float64(a.BuyIn) / float64(a.Games)
+		s.WinRate = float64(a.Wins) / float64(a.Games)
+	}
+	return s
+}
+
+func (m *mongoAdapter) PlayerStats(
+	ctx context.Context,
+	userID id.ID,
+	orgs []id.ID,
+	window Window,
+) (PlayerStats, error) {
+	pipeline := playerPipeline(orgs, window, bson.M{"players.user_id": userID})
+
+	res, err := m.aggregate(ctx, pipeline)
+	if err != nil {
+		return PlayerStats{}, err
+	}
+	if len(res) == 0 {
+		return PlayerStats{UserID: userID.Hex()}, nil
+	}
+
+	return res[0].toStats(), nil
+}
+
+func (m *mongoAdapter) Leaderboard(ctx context.Context, orgID id.ID, window Window, no int) ([]PlayerStats, error) {
+	pipeline := playerPipeline([]id.ID{orgID}, window, bson.M{"players.user_id": bson.M{"$ne": nil}})
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "profit", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": no},
+	)
+
+	res, err := m.aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := make([]PlayerStats, 0, len(res))
+	for _, a := range res {
+		leaderboard = append(leaderboard, a.toStats())
+	}
+	return leaderboard, nil
+}
+
+func (m *mongoAdapter) aggregate(ctx context.Context, pipeline []bson.M) ([]aggregated, error) {
+	cur, err := m.games.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("cannot aggregate stats: %w", err)
+	}
+
+	var res []aggregated
+	if err := cur.All(ctx, &res); err != nil {
+		return nil, fmt.Errorf("cannot bind stats: %w", err)
+	}
+
+	return res, nil
+}
+
+// playerPipeline groups results of players (matching playerMatch) from closed games per user
+func playerPipeline(orgs []id.ID, window Window, playerMatch bson.M) []bson.M {
+	gamesMatch := bson.M{
+		"organization": bson.M{"$in": orgs},
+		"status":       bson.M{"$in": bson.A{game.StatusClosed, game.StatusSettled}},
+	}
+	start := bson.M{}
+	if window.From != nil {
+		start["$gte"] = *window.From
+	}
+	if window.To != nil {
+		start["$lt"] = *window.To
+	}
+	if len(start) > 0 {
+		gamesMatch["start"] = start
+	}
+
+	// profit = finish stack + incomes from other players - buy-in (the opposite of game.Report)
+	profit := bson.M{
+		"$subtract": bson.A{
+			bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$players.finish_stack", 0}},
+				bson.M{"$sum": "$players.additional_incomes.amount"},
+			}},
+			"$players.start_stack",
+		},
+	}
+
+	return []bson.M{
+		{"$match": gamesMatch},
+		{"$unwind": "$players"},
+		{"$match": playerMatch},
+		{"$project": bson.M{
+			"user_id":   "$players.user_id",
+			"user_name": "$players.user_name",
+			"buy_in":    "$players.start_stack",
+			"profit":    profit,
+		}},
+		{"$group": bson.M{
+			"_id":          "$user_id",
+			"user_name":    bson.M{"$last": "$user_name"},
+			"games":        bson.M{"$sum": 1},
+			"profit":       bson.M{"$sum": "$profit"},
+			"buy_in":       bson.M{"$sum": "$buy_in"},
+			"biggest_win":  bson.M{"$max": "$profit"},
+			"biggest_loss": bson.M{"$min": "$profit"},
+			"wins": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gt": bson.A{"$profit", 0}}, 1, 0},
+			}},
+		}},
+	}
+}
+
+var _ Adapter = (*mongoAdapter)(nil)
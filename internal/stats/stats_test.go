@@ -0,0 +1,37 @@
+package stats
+
+import (
+	"testing"
+
+	"pokergo/pkg/id"
+)
+
+func Test_Aggregated_ToStats(t *testing.T) {
+	uID := id.NewID()
+	s := aggregated{
+		UserID:      uID,
+		UserName:    "player",
+		Games:       4,
+		Profit:      -100,
+		BuyIn:       800,
+		BiggestWin:  300,
+		BiggestLoss: -250,
+		Wins:        1,
+	}.toStats()
+
+	if s.UserID != uID.Hex() || s.GamesPlayed != 4 || s.TotalProfit != -100 || s.TotalBuyIn != 800 {
+		t.Fatalf("invalid totals: %+v", s)
+	}
+	if s.ROI != -0.125 || s.AverageBuyIn != 200 || s.WinRate != 0.25 {
+		t.Fatalf("invalid ratios: %+v", s)
+	}
+	if s.BiggestWin != 300 || s.BiggestLoss != 250 {
+		t.Fatalf("invalid biggest win/loss: %+v", s)
+	}
+
+	// a player who always lost has no biggest win
+	s = aggregated{UserID: uID, Games: 1, Profit: -50, BuyIn: 100, BiggestWin: -50, BiggestLoss: -50}.toStats()
+	if s.BiggestWin != 0 || s.BiggestLoss != 50 || s.WinRate != 0 {
+		t.Fatalf("invalid stats of a losing player: %+v", s)
+	}
+}
@@ -0,0 +1,410 @@
+package table
+
+import (
+	"fmt"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/poker"
+)
+
+// Street is a betting round of the hand
+type Street string
+
+const (
+	Preflop Street = "preflop"
+	Flop    Street = "flop"
+	Turn    Street = "turn"
+	River   Street = "river"
+)
+
+// ActionType is a decision of the player (or a forced bet)
+type ActionType string
+
+const (
+	ActionFold  ActionType = "fold"
+	ActionCheck ActionType = "check"
+	ActionCall  ActionType = "call"
+	// ActionBet is the first bet in the street
+	ActionBet ActionType = "bet"
+	// ActionRaise increases the bet in the street
+	ActionRaise ActionType = "raise"
+	// ActionSmallBlind and ActionBigBlind are forced bets, they are only logged
+	ActionSmallBlind ActionType = "small_blind"
+	ActionBigBlind   ActionType = "big_blind"
+)
+
+// Action is a decision of the player to act
+type Action struct {
+	Type ActionType `json:"type"`
+	// Amount is the total bet of the player in the street after a bet or a raise ("raise to")
+	Amount int64 `json:"amount"`
+}
+
+// ActionLog is an action taken in the hand
+type ActionLog struct {
+	Seat   int        `json:"seat"`
+	Street Street     `json:"street"`
+	Type   ActionType `json:"type"`
+	// Amount is the total bet of the player in the street after the action
+	Amount int64 `json:"amount"`
+	AllIn  bool  `json:"all_in"`
+}
+
+// Legal are actions the player to act can take
+type Legal struct {
+	Actions []ActionType `json:"actions"`
+	// Call is the amount needed to call
+	Call int64 `json:"call"`
+	// MinTo and MaxTo are limits of the bet or the raise ("raise to"), MaxTo means all-in
+	MinTo int64 `json:"min_to"`
+	MaxTo int64 `json:"max_to"`
+}
+
+func (l Legal) allows(a ActionType) bool {
+	for _, allowed := range l.Actions {
+		if allowed == a {
+			return true
+		}
+	}
+	return false
+}
+
+// player is a player dealt into the hand
+type player struct {
+	hole []poker.Card
+	// bet is the amount put into the pot in the current street
+	bet int64
+	// total is the amount put into the pot in the whole hand
+	total  int64
+	folded bool
+	allIn  bool
+	// acted is true if the player acted since the last full raise
+	acted bool
+}
+
+// canAct tells if the player still makes decisions in the hand
+func (p *player) canAct() bool {
+	return p != nil && !p.folded && !p.allIn
+}
+
+// hand is the state of the hand being played
+type hand struct {
+	no      int
+	deck    []poker.Card
+	board   []poker.Card
+	street  Street
+	players []*player // by seats, nil for seats not dealt in
+	toAct   int
+	// currentBet is the highest bet in the street
+	currentBet int64
+	// minRaise is the size of the last full bet or raise in the street
+	minRaise int64
+	actions  []ActionLog
+	// turnSince is when the player to act got the turn
+	turnSince time.Time
+}
+
+func (h *hand) draw(n int) []poker.Card {
+	cards := h.deck[:n]
+	h.deck = h.deck[n:]
+	return cards
+}
+
+// StartHand moves the button, posts blinds and deals hole cards, players without chips are skipped
+func (t *Table) StartHand() error {
+	if t.hand != nil {
+		return ErrHandInProgress
+	}
+	dealt := func(seat int) bool {
+		return t.seats[seat] != nil && t.seats[seat].Stack > 0
+	}
+	players := 0
+	for seat := range t.seats {
+		if dealt(seat) {
+			players++
+		}
+	}
+	if players < 2 {
+		return ErrNotEnoughPlayers
+	}
+
+	t.hands++
+	t.button = t.nextSeat(t.button, dealt)
+	h := &hand{
+		no:       t.hands,
+		deck:     t.shuffle(),
+		street:   Preflop,
+		players:  make([]*player, len(t.seats)),
+		minRaise: t.config.BigBlind,
+	}
+	for seat := range t.seats {
+		if dealt(seat) {
+			h.players[seat] = &player{}
+		}
+	}
+	t.hand = h
+
+	// the button posts the small blind heads-up
+	sb := t.button
+	if players > 2 {
+		sb = t.nextSeat(t.button, dealt)
+	}
+	bb := t.nextSeat(sb, dealt)
+	t.post(sb, t.config.SmallBlind, ActionSmallBlind)
+	t.post(bb, t.config.BigBlind, ActionBigBlind)
+	h.currentBet = t.config.BigBlind
+
+	for round := 0; round < 2; round++ {
+		for i, seat := 0, t.button; i < players; i++ {
+			seat = t.nextSeat(seat, dealt)
+			h.players[seat].hole = append(h.players[seat].hole, h.draw(1)...)
+		}
+	}
+
+	h.toAct = (bb + 1) % len(t.seats)
+	t.advance()
+	return nil
+}
+
+// post puts a forced bet of the player (all-in if the stack is too short)
+func (t *Table) post(seat int, blind int64, action ActionType) {
+	p := t.hand.players[seat]
+	t.put(seat, min64(blind, t.seats[seat].Stack))
+	t.hand.actions = append(t.hand.actions, ActionLog{
+		Seat: seat, Street: Preflop, Type: action, Amount: p.bet, AllIn: p.allIn,
+	})
+}
+
+// put moves chips of the player from the stack to the pot
+func (t *Table) put(seat int, amount int64) {
+	p := t.hand.players[seat]
+	t.seats[seat].Stack -= amount
+	p.bet += amount
+	p.total += amount
+	if t.seats[seat].Stack == 0 {
+		p.allIn = true
+	}
+}
+
+// ToAct returns the seat of the player to act, -1 if no hand is played
+func (t *Table) ToAct() int {
+	if t.hand == nil {
+		return -1
+	}
+	return t.hand.toAct
+}
+
+// Legal returns actions of the player to act
+func (t *Table) Legal() Legal {
+	if t.hand == nil {
+		return Legal{}
+	}
+	h := t.hand
+	p := h.players[h.toAct]
+	stack := t.seats[h.toAct].Stack
+
+	l := Legal{Actions: []ActionType{ActionFold}}
+	if p.bet == h.currentBet {
+		l.Actions = append(l.Actions, ActionCheck)
+	} else {
+		l.Call = min64(h.currentBet-p.bet, stack)
+		l.Actions = append(l.Actions, ActionCall)
+	}
+
+	opponents := false
+	for seat, other := range h.players {
+		if seat != h.toAct && other.canAct() {
+			opponents = true
+		}
+	}
+	// an all-in for less than a full raise does not reopen the betting for players who already acted
+	if stack > h.currentBet-p.bet && !p.acted && opponents {
+		l.MaxTo = p.bet + stack
+		l.MinTo = min64(h.currentBet+h.minRaise, l.MaxTo)
+		if h.currentBet == 0 {
+			l.Actions = append(l.Actions, ActionBet)
+		} else {
+			l.Actions = append(l.Actions, ActionRaise)
+		}
+	}
+	return l
+}
+
+// Act performs the action of the player, the hand moves to the next street or ends when the betting round is over
+func (t *Table) Act(userID id.ID, a Action) error {
+	h := t.hand
+	if h == nil {
+		return ErrNoHand
+	}
+	seat := t.seatOf(userID)
+	if seat < 0 {
+		return ErrNotSeated
+	}
+	if seat != h.toAct {
+		return ErrNotYourTurn
+	}
+	return t.act(seat, a)
+}
+
+// act performs the action of the player to act
+func (t *Table) act(seat int, a Action) error {
+	h := t.hand
+	legal := t.Legal()
+	if !legal.allows(a.Type) {
+		return fmt.Errorf("%w: cannot %s now", ErrInvalidAction, a.Type)
+	}
+
+	p := h.players[seat]
+	switch a.Type {
+	case ActionFold:
+		p.folded = true
+	case ActionCheck:
+	case ActionCall:
+		t.put(seat, legal.Call)
+	case ActionBet, ActionRaise:
+		if a.Amount < legal.MinTo || a.Amount > legal.MaxTo {
+			return fmt.Errorf("%w: %s must be from %d to %d", ErrInvalidAction, a.Type, legal.MinTo, legal.MaxTo)
+		}
+		// a full raise reopens the betting, so does any first bet in the street
+		if raise := a.Amount - h.currentBet; raise >= h.minRaise || h.currentBet == 0 {
+			h.minRaise = max64(raise, h.minRaise)
+			for _, other := range h.players {
+				if other != nil {
+					other.acted = false
+				}
+			}
+		}
+		h.currentBet = a.Amount
+		t.put(seat, a.Amount-p.bet)
+	case ActionSmallBlind, ActionBigBlind:
+		return fmt.Errorf("%w: blinds are posted automatically", ErrInvalidAction)
+	}
+	p.acted = true
+
+	h.actions = append(h.actions, ActionLog{Seat: seat, Street: h.street, Type: a.Type, Amount: p.bet, AllIn: p.allIn})
+	t.advance()
+	return nil
+}
+
+// fold folds the player out of turn (the player left the table)
+func (t *Table) fold(seat int) {
+	h := t.hand
+	if seat == h.toAct {
+		_ = t.act(seat, Action{Type: ActionFold}) // folding is always legal
+		return
+	}
+
+	p := h.players[seat]
+	p.folded, p.acted = true, true
+	h.actions = append(h.actions, ActionLog{Seat: seat, Street: h.street, Type: ActionFold, Amount: p.bet, AllIn: p.allIn})
+	t.advance()
+}
+
+// Deadline returns when the turn of the player to act runs out, false if turns are not limited or no hand is played
+func (t *Table) Deadline() (time.Time, bool) {
+	if t.hand == nil || t.config.TurnTimeout <= 0 {
+		return time.Time{}, false
+	}
+	return t.hand.turnSince.Add(t.config.TurnTimeout), true
+}
+
+// TimeOut checks (or folds if it cannot check) for the player to act whose turn ran out,
+// it returns false if the player still has time
+func (t *Table) TimeOut() bool {
+	deadline, ok := t.Deadline()
+	if !ok || t.timer.Now().Before(deadline) {
+		return false
+	}
+
+	a := Action{Type: ActionFold}
+	if t.Legal().allows(ActionCheck) {
+		a.Type = ActionCheck
+	}
+	_ = t.act(t.hand.toAct, a) // the action is legal
+	return true
+}
+
+// advance passes the turn to the next player, or ends the betting round (and the hand)
+func (t *Table) advance() {
+	h := t.hand
+
+	contenders, acting := 0, 0
+	for _, p := range h.players {
+		if p != nil && !p.folded {
+			contenders++
+		}
+		if p.canAct() {
+			acting++
+		}
+	}
+	if contenders == 1 {
+		t.settle()
+		return
+	}
+
+	pending := func(seat int) bool {
+		p := h.players[seat]
+		if !p.canAct() {
+			return false
+		}
+		// a lone player who matched the bet has nobody to play against
+		return p.bet < h.currentBet || !p.acted && acting > 1
+	}
+	if next := t.nextSeat(h.toAct-1, pending); next >= 0 {
+		if next != h.toAct || h.turnSince.IsZero() {
+			h.toAct, h.turnSince = next, t.timer.Now()
+		}
+		return
+	}
+
+	// the betting round is over
+	if h.street == River || acting <= 1 {
+		// nobody can bet anymore, the board is run out
+		for len(h.board) < 5 {
+			t.dealStreet()
+		}
+		t.settle()
+		return
+	}
+	t.dealStreet()
+	for _, p := range h.players {
+		if p != nil {
+			p.bet, p.acted = 0, false
+		}
+	}
+	h.currentBet, h.minRaise = 0, t.config.BigBlind
+	h.toAct = t.nextSeat(t.button, func(seat int) bool { return h.players[seat].canAct() })
+	h.turnSince = t.timer.Now()
+}
+
+// dealStreet burns a card and deals the next street
+func (t *Table) dealStreet() {
+	h := t.hand
+	h.draw(1)
+	switch h.street {
+	case Preflop:
+		h.street = Flop
+		h.board = append(h.board, h.draw(3)...)
+	case Flop:
+		h.street = Turn
+		h.board = append(h.board, h.draw(1)...)
+	case Turn, River:
+		h.street = River
+		h.board = append(h.board, h.draw(1)...)
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,287 @@
+package table
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+const (
+	// EmptyTimeout is how long a table without players is kept open
+	EmptyTimeout = 10 * time.Minute
+	// IdleTimeout is how long a table is kept open when nothing happens at it
+	IdleTimeout = time.Hour
+)
+
+// Info describes the table
+type Info struct {
+	ID      id.ID  `json:"id"`
+	OrgID   id.ID  `json:"org_id"`
+	Name    string `json:"name"`
+	Config  Config `json:"config"`
+	Players int    `json:"players"`
+}
+
+// Room is a table shared by connected players, it is safe for concurrent use.
+// Subscribers are signalled after every change, their channels are closed when the room is closed.
+type Room struct {
+	id    id.ID
+	orgID id.ID
+	name  string
+	timer timer.Timer
+
+	mux         sync.Mutex
+	table       *Table
+	subscribers map[chan struct{}]struct{}
+	// changed is the time of the last change
+	changed time.Time
+	closed  bool
+}
+
+// Info returns the description of the table
+func (r *Room) Info() Info {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return Info{ID: r.id, OrgID: r.orgID, Name: r.name, Config: r.table.Config(), Players: r.table.Players()}
+}
+
+// View returns the state of the table seen by the viewer
+func (r *Room) View(viewer id.ID) View {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.table.View(viewer)
+}
+
+// Sit seats the player, see Table.Sit
+func (r *Room) Sit(userID id.ID, userName string, seat int, buyIn int64) error {
+	return r.change(func(t *Table) error {
+		return t.Sit(userID, userName, seat, buyIn)
+	})
+}
+
+// Leave removes the player from the table, see Table.Leave
+func (r *Room) Leave(userID id.ID) (int64, error) {
+	var stack int64
+	err := r.change(func(t *Table) error {
+		var err error
+		stack, err = t.Leave(userID)
+		return err
+	})
+	return stack, err
+}
+
+// StartHand deals a new hand, only seated players can start it
+func (r *Room) StartHand(userID id.ID) error {
+	return r.change(func(t *Table) error {
+		if t.seatOf(userID) < 0 {
+			return ErrNotSeated
+		}
+		return t.StartHand()
+	})
+}
+
+// Act performs the action of the player, see Table.Act
+func (r *Room) Act(userID id.ID, a Action) error {
+	return r.change(func(t *Table) error {
+		return t.Act(userID, a)
+	})
+}
+
+// Subscribe returns a channel signalled when the table changes, the returned function unsubscribes
+func (r *Room) Subscribe() (<-chan struct{}, func()) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if r.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	r.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		r.mux.Lock()
+		defer r.mux.Unlock()
+
+		delete(r.subscribers, ch)
+	}
+}
+
+// change applies the change to the table and signals subscribers if it succeeded
+func (r *Room) change(f func(t *Table) error) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.closed {
+		return ErrTableNotExists
+	}
+	if err := f(r.table); err != nil {
+		return err
+	}
+	r.changed = r.timer.Now()
+	r.signal()
+	return nil
+}
+
+// signal notifies subscribers about the change (must be called with mux locked)
+func (r *Room) signal() {
+	for ch := range r.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default: // the subscriber has not consumed the previous signal yet
+		}
+	}
+}
+
+// timeOut acts for the player whose turn ran out, see Table.TimeOut
+func (r *Room) timeOut() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if !r.closed && r.table.TimeOut() {
+		r.changed = r.timer.Now()
+		r.signal()
+	}
+}
+
+// closeIdle closes the room if it is empty or nothing happened at it for a long time,
+// it returns false if the room is still open
+func (r *Room) closeIdle() bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	idle := r.timer.Now().Sub(r.changed)
+	if idle < IdleTimeout && (r.table.Players() > 0 || idle < EmptyTimeout) {
+		return false
+	}
+
+	r.closed = true
+	for ch := range r.subscribers {
+		close(ch)
+	}
+	r.subscribers = make(map[chan struct{}]struct{})
+	return true
+}
+
+// Manager keeps tables being played (in memory, tables are not persisted)
+type Manager interface {
+	// Create opens a new table of the organization
+	Create(orgID id.ID, name string, config Config) (*Room, error)
+	// Get returns the table or ErrTableNotExists
+	Get(tableID id.ID) (*Room, error)
+	// List returns tables of the organization
+	List(orgID id.ID) []Info
+	// Sweep acts for players whose turn ran out and removes idle tables (see EmptyTimeout and IdleTimeout)
+	Sweep()
+}
+
+type manager struct {
+	timer    timer.Timer
+	roomsMux sync.Mutex
+	rooms    map[id.ID]*Room
+}
+
+func NewManager(timer timer.Timer) *manager {
+	return &manager{timer: timer, rooms: make(map[id.ID]*Room)}
+}
+
+func (m *manager) Create(orgID id.ID, name string, config Config) (*Room, error) {
+	var seed int64
+	if err := binary.Read(crand.Reader, binary.LittleEndian, &seed); err != nil {
+		return nil, fmt.Errorf("cannot seed the deck: %w", err)
+	}
+	t, err := New(config, seed)
+	if err != nil {
+		return nil, err
+	}
+	t.timer = m.timer
+
+	r := &Room{
+		id:          id.NewID(),
+		orgID:       orgID,
+		name:        name,
+		timer:       m.timer,
+		table:       t,
+		subscribers: make(map[chan struct{}]struct{}),
+		changed:     m.timer.Now(),
+	}
+
+	m.roomsMux.Lock()
+	defer m.roomsMux.Unlock()
+
+	m.rooms[r.id] = r
+	return r, nil
+}
+
+func (m *manager) Get(tableID id.ID) (*Room, error) {
+	m.roomsMux.Lock()
+	defer m.roomsMux.Unlock()
+
+	r, ok := m.rooms[tableID]
+	if !ok {
+		return nil, ErrTableNotExists
+	}
+	return r, nil
+}
+
+func (m *manager) List(orgID id.ID) []Info {
+	m.roomsMux.Lock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		if r.orgID == orgID {
+			rooms = append(rooms, r)
+		}
+	}
+	m.roomsMux.Unlock()
+
+	infos := make([]Info, 0, len(rooms))
+	for _, r := range rooms {
+		infos = append(infos, r.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+func (m *manager) Sweep() {
+	m.roomsMux.Lock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		rooms = append(rooms, r)
+	}
+	m.roomsMux.Unlock()
+
+	for _, r := range rooms {
+		r.timeOut()
+		if r.closeIdle() {
+			m.roomsMux.Lock()
+			delete(m.rooms, r.id)
+			m.roomsMux.Unlock()
+		}
+	}
+}
+
+// Run sweeps tables every interval until the context is done
+func (m *manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m.Sweep()
+	}
+}
+
+var _ Manager = (*manager)(nil)
@@ -0,0 +1,177 @@
+package table
+
+import (
+	"sort"
+
+	"pokergo/pkg/poker"
+)
+
+// Pot is the main pot or a side pot
+type Pot struct {
+	Amount int64 `json:"amount"`
+	// Seats are players who can win the pot
+	Seats   []int `json:"seats"`
+	Winners []int `json:"winners"`
+}
+
+// PlayerResult is the outcome of the hand for a player
+type PlayerResult struct {
+	Seat     int    `json:"seat"`
+	UserName string `json:"user_name"`
+	// Won is the amount taken from pots (including the returned uncalled bet)
+	Won int64 `json:"won"`
+	// Cards are hole cards shown at the showdown, empty for players who folded or won uncontested
+	Cards string `json:"cards,omitempty"`
+	// Category is the category of the best hand shown at the showdown
+	Category string `json:"category,omitempty"`
+}
+
+// Result is the outcome of the finished hand
+type Result struct {
+	Hand    int            `json:"hand"`
+	Board   string         `json:"board"`
+	Pots    []Pot          `json:"pots"`
+	Players []PlayerResult `json:"players"`
+	// Showdown is false if all the players but one folded
+	Showdown bool `json:"showdown"`
+}
+
+// LastResult returns the result of the last finished hand, nil before the first hand ends
+func (t *Table) LastResult() *Result {
+	return t.last
+}
+
+// settle returns the uncalled bet, splits chips into pots, awards them and ends the hand
+func (t *Table) settle() {
+	h := t.hand
+	won := make([]int64, len(h.players))
+
+	// the uncalled part of the highest bet goes back to the bettor
+	top, second := -1, int64(0)
+	for seat, p := range h.players {
+		switch {
+		case p == nil:
+		case top < 0 || p.total > h.players[top].total:
+			if top >= 0 {
+				second = h.players[top].total
+			}
+			top = seat
+		case p.total > second:
+			second = p.total
+		}
+	}
+	if uncalled := h.players[top].total - second; uncalled > 0 {
+		h.players[top].total -= uncalled
+		won[top] += uncalled
+	}
+
+	pots := sidePots(h.players)
+	contenders := 0
+	for _, p := range h.players {
+		if p != nil && !p.folded {
+			contenders++
+		}
+	}
+	showdown := contenders > 1
+
+	ranks := make([]poker.HandRank, len(h.players))
+	if showdown {
+		cards := make([]poker.Card, 0, 7)
+		for seat, p := range h.players {
+			if p != nil && !p.folded {
+				cards = append(append(cards[:0], p.hole...), h.board...)
+				ranks[seat] = poker.Evaluate(cards)
+			}
+		}
+	}
+
+	for i := range pots {
+		pot := &pots[i]
+		// seats are ordered from the first seat after the button, odd chips go to the first winners
+		sort.Slice(pot.Seats, func(a, b int) bool {
+			return t.fromButton(pot.Seats[a]) < t.fromButton(pot.Seats[b])
+		})
+		var best poker.HandRank
+		for _, seat := range pot.Seats {
+			switch {
+			case ranks[seat] > best:
+				best = ranks[seat]
+				pot.Winners = append(pot.Winners[:0], seat)
+			case ranks[seat] == best:
+				pot.Winners = append(pot.Winners, seat)
+			}
+		}
+
+		share, odd := pot.Amount/int64(len(pot.Winners)), pot.Amount%int64(len(pot.Winners))
+		for j, seat := range pot.Winners {
+			won[seat] += share
+			if int64(j) < odd {
+				won[seat]++
+			}
+		}
+	}
+
+	res := &Result{Hand: h.no, Board: poker.FormatCards(h.board), Pots: pots, Showdown: showdown}
+	for seat, p := range h.players {
+		if p == nil || t.seats[seat] == nil {
+			// the player folded and left the table
+			continue
+		}
+		t.seats[seat].Stack += won[seat]
+		pr := PlayerResult{Seat: seat, UserName: t.seats[seat].UserName, Won: won[seat]}
+		if showdown && !p.folded {
+			pr.Cards = poker.FormatCards(p.hole)
+			pr.Category = ranks[seat].Category().String()
+		}
+		res.Players = append(res.Players, pr)
+	}
+
+	t.last = res
+	t.hand = nil
+}
+
+// fromButton returns the distance of the seat from the button (the small blind is the closest)
+func (t *Table) fromButton(seat int) int {
+	return (seat - t.button - 1 + len(t.seats)) % len(t.seats)
+}
+
+// sidePots splits contributions of players into the main pot and side pots,
+// a new pot is opened for every all-in of a player who did not fold
+func sidePots(players []*player) []Pot {
+	var levels []int64
+	for _, p := range players {
+		if p != nil && !p.folded {
+			levels = append(levels, p.total)
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+
+	var pots []Pot
+	var previous int64
+	for _, level := range levels {
+		if level == previous {
+			continue
+		}
+		pot := Pot{}
+		for seat, p := range players {
+			if p == nil {
+				continue
+			}
+			pot.Amount += min64(p.total, level) - min64(p.total, previous)
+			if !p.folded && p.total >= level {
+				pot.Seats = append(pot.Seats, seat)
+			}
+		}
+		pots = append(pots, pot)
+		previous = level
+	}
+
+	// chips of folded players above the highest contender are not possible after returning the uncalled bet,
+	// but they are not lost either
+	for _, p := range players {
+		if p != nil && p.total > previous && len(pots) > 0 {
+			pots[len(pots)-1].Amount += p.total - previous
+		}
+	}
+	return pots
+}
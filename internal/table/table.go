@@ -0,0 +1,182 @@
+package table
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/poker"
+	"pokergo/pkg/timer"
+)
+
+var (
+	ErrInvalidConfig    = errors.New("invalid table config")
+	ErrInvalidSeat      = errors.New("invalid seat")
+	ErrSeatTaken        = errors.New("the seat is taken")
+	ErrAlreadySeated    = errors.New("the player is already seated")
+	ErrNotSeated        = errors.New("the player is not seated")
+	ErrInvalidBuyIn     = errors.New("invalid buy-in")
+	ErrHandInProgress   = errors.New("the hand is in progress")
+	ErrNoHand           = errors.New("no hand in progress")
+	ErrNotEnoughPlayers = errors.New("at least two players with chips are needed")
+	ErrNotYourTurn      = errors.New("it is not your turn")
+	ErrInvalidAction    = errors.New("invalid action")
+	ErrTableNotExists   = errors.New("the table does not exist")
+)
+
+// MaxSeats is the maximal number of seats at the table
+const MaxSeats = 10
+
+// Config are rules of the table (no-limit hold'em cash game)
+type Config struct {
+	Seats      int   `json:"seats"`
+	SmallBlind int64 `json:"small_blind"`
+	BigBlind   int64 `json:"big_blind"`
+	MinBuyIn   int64 `json:"min_buy_in"`
+	MaxBuyIn   int64 `json:"max_buy_in"`
+	// TurnTimeout is the time the player has to act, the player checks or folds when it runs out (0 means no limit)
+	TurnTimeout time.Duration `json:"-"`
+}
+
+// Validate checks if the table can be played with the config
+func (c Config) Validate() error {
+	switch {
+	case c.Seats < 2 || c.Seats > MaxSeats:
+		return fmt.Errorf("%w: the table must have from 2 to %d seats", ErrInvalidConfig, MaxSeats)
+	case c.SmallBlind <= 0 || c.BigBlind < c.SmallBlind:
+		return fmt.Errorf("%w: blinds must be positive (small <= big)", ErrInvalidConfig)
+	case c.MinBuyIn < c.BigBlind || c.MaxBuyIn < c.MinBuyIn:
+		return fmt.Errorf("%w: the minimal buy-in must be at least the big blind and not greater than the maximal one", ErrInvalidConfig)
+	case c.TurnTimeout < 0:
+		return fmt.Errorf("%w: the turn timeout cannot be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Seat is a player sitting at the table
+type Seat struct {
+	UserID   id.ID
+	UserName string
+	// Stack are chips of the player not put into the pot yet
+	Stack int64
+}
+
+// Table is a hold'em table engine: seats, the dealer button, blinds, betting rounds, side pots and the showdown.
+// It is deterministic, hands played with the same seed and actions end up the same.
+// Table is not safe for concurrent use, see Room.
+type Table struct {
+	config Config
+	seats  []*Seat
+	// button is the seat of the dealer button, -1 before the first hand
+	button int
+	hands  int
+	hand   *hand
+	// last is the result of the last finished hand
+	last *Result
+	// shuffle returns the deck of the next hand, cards are dealt from the beginning
+	shuffle func() []poker.Card
+	// timer measures turns of players (see Config.TurnTimeout)
+	timer timer.Timer
+}
+
+// New creates an empty table, the seed determines the order of cards in all the hands
+func New(config Config, seed int64) (*Table, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(seed)) // nolint:gosec // the seed is random in production, fixed in tests
+	return &Table{
+		config: config,
+		seats:  make([]*Seat, config.Seats),
+		button: -1,
+		shuffle: func() []poker.Card {
+			deck := poker.NewDeck()
+			deck.Shuffle(rng)
+			return deck.Cards()
+		},
+		timer: timer.NewUTCTimer(),
+	}, nil
+}
+
+// Config returns rules of the table
+func (t *Table) Config() Config {
+	return t.config
+}
+
+// InHand tells if a hand is being played
+func (t *Table) InHand() bool {
+	return t.hand != nil
+}
+
+// Players returns the number of seated players
+func (t *Table) Players() int {
+	no := 0
+	for _, s := range t.seats {
+		if s != nil {
+			no++
+		}
+	}
+	return no
+}
+
+// Sit seats the player with buyIn chips, players seated during a hand are dealt in the next one
+func (t *Table) Sit(userID id.ID, userName string, seat int, buyIn int64) error {
+	if seat < 0 || seat >= len(t.seats) {
+		return fmt.Errorf("%w: %d", ErrInvalidSeat, seat)
+	}
+	// the seat of a player who left during the hand is free after the hand
+	if t.seats[seat] != nil || t.hand != nil && t.hand.players[seat] != nil {
+		return fmt.Errorf("%w: %d", ErrSeatTaken, seat)
+	}
+	if t.seatOf(userID) >= 0 {
+		return ErrAlreadySeated
+	}
+	if buyIn < t.config.MinBuyIn || buyIn > t.config.MaxBuyIn {
+		return fmt.Errorf("%w: must be from %d to %d", ErrInvalidBuyIn, t.config.MinBuyIn, t.config.MaxBuyIn)
+	}
+
+	t.seats[seat] = &Seat{UserID: userID, UserName: userName, Stack: buyIn}
+	return nil
+}
+
+// Leave removes the player from the table and returns chips of the player,
+// players leaving in the middle of a hand fold (chips put into the pot are lost)
+func (t *Table) Leave(userID id.ID) (int64, error) {
+	seat := t.seatOf(userID)
+	if seat < 0 {
+		return 0, ErrNotSeated
+	}
+	if t.hand != nil {
+		if p := t.hand.players[seat]; p != nil && !p.folded {
+			t.fold(seat)
+		}
+	}
+
+	stack := t.seats[seat].Stack
+	t.seats[seat] = nil
+	return stack, nil
+}
+
+// seatOf returns the seat of the player or -1
+func (t *Table) seatOf(userID id.ID) int {
+	for i, s := range t.seats {
+		if s != nil && s.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextSeat returns the first seat after from (clockwise) matching the predicate or -1
+func (t *Table) nextSeat(from int, match func(seat int) bool) int {
+	for i := 1; i <= len(t.seats); i++ {
+		seat := (from + i + len(t.seats)) % len(t.seats)
+		if match(seat) {
+			return seat
+		}
+	}
+	return -1
+}
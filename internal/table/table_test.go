@@ -0,0 +1,480 @@
+package table
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/poker"
+	"pokergo/pkg/timer"
+)
+
+var testConfig = Config{Seats: 3, SmallBlind: 5, BigBlind: 10, MinBuyIn: 10, MaxBuyIn: 1000} // nolint:gochecknoglobals // test data
+
+// newTestTable seats players with given stacks (0 for an empty seat),
+// the first cards of every hand are taken from cards in the dealing order (holes from the first seat
+// after the button, a burnt card before every street), other cards follow in a fixed order
+func newTestTable(t *testing.T, cards string, stacks ...int64) (*Table, []id.ID) {
+	t.Helper()
+	config := testConfig
+	config.Seats = len(stacks)
+	tbl, err := New(config, 1)
+	if err != nil {
+		t.Fatalf("cannot create the table: %s", err)
+	}
+	if cards != "" {
+		first, err := poker.ParseCards(cards)
+		if err != nil {
+			t.Fatalf("invalid cards: %s", err)
+		}
+		tbl.shuffle = func() []poker.Card {
+			return append(append([]poker.Card{}, first...), poker.NewDeck(first...).Cards()...)
+		}
+	}
+
+	users := make([]id.ID, len(stacks))
+	for seat, stack := range stacks {
+		users[seat] = id.NewID()
+		if stack == 0 {
+			continue
+		}
+		if err := tbl.Sit(users[seat], string(rune('A'+seat)), seat, stack); err != nil {
+			t.Fatalf("cannot sit: %s", err)
+		}
+	}
+	return tbl, users
+}
+
+func mustAct(t *testing.T, tbl *Table, user id.ID, action ActionType, amount int64) {
+	t.Helper()
+	if err := tbl.Act(user, Action{Type: action, Amount: amount}); err != nil {
+		t.Fatalf("cannot %s %d: %s", action, amount, err)
+	}
+}
+
+func stacks(tbl *Table) []int64 {
+	res := make([]int64, len(tbl.seats))
+	for seat, s := range tbl.seats {
+		if s != nil {
+			res[seat] = s.Stack
+		}
+	}
+	return res
+}
+
+func Test_Table_HeadsUp(t *testing.T) {
+	tbl, users := newTestTable(t, "", 100, 0, 100)
+
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+	// the button posts the small blind and acts first preflop
+	if tbl.button != 0 || tbl.ToAct() != 0 || tbl.hand.players[0].bet != 5 || tbl.hand.players[2].bet != 10 {
+		t.Fatalf("invalid blinds, button: %d, to act: %d", tbl.button, tbl.ToAct())
+	}
+	if err := tbl.Act(users[2], Action{Type: ActionCheck}); !errors.Is(err, ErrNotYourTurn) {
+		t.Fatalf("the big blind cannot act first, err: %v", err)
+	}
+	if err := tbl.Act(users[0], Action{Type: ActionCheck}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("the small blind cannot check, err: %v", err)
+	}
+	mustAct(t, tbl, users[0], ActionCall, 0)
+	// the big blind has the option
+	if tbl.ToAct() != 2 || tbl.hand.street != Preflop {
+		t.Fatalf("the big blind should have the option, to act: %d", tbl.ToAct())
+	}
+	mustAct(t, tbl, users[2], ActionCheck, 0)
+
+	// the big blind acts first after the flop
+	if tbl.hand.street != Flop || len(tbl.hand.board) != 3 || tbl.ToAct() != 2 {
+		t.Fatalf("invalid flop, street: %s, to act: %d", tbl.hand.street, tbl.ToAct())
+	}
+	mustAct(t, tbl, users[2], ActionBet, 10)
+	mustAct(t, tbl, users[0], ActionFold, 0)
+
+	res := tbl.LastResult()
+	if tbl.InHand() || res == nil || res.Showdown || !reflect.DeepEqual(stacks(tbl), []int64{90, 0, 110}) {
+		t.Fatalf("the big blind should win uncontested, stacks: %v, result: %+v", stacks(tbl), res)
+	}
+
+	// the button moves
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+	if tbl.button != 2 || tbl.ToAct() != 2 {
+		t.Fatalf("the button should move, button: %d, to act: %d", tbl.button, tbl.ToAct())
+	}
+}
+
+func Test_Table_MinRaise(t *testing.T) {
+	tbl, users := newTestTable(t, "", 1000, 1000, 1000)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	// button 0, small blind 1, big blind 2, the button is under the gun
+	if err := tbl.Act(users[0], Action{Type: ActionRaise, Amount: 15}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("the raise must be at least the big blind, err: %v", err)
+	}
+	mustAct(t, tbl, users[0], ActionRaise, 30)
+	if l := tbl.Legal(); l.MinTo != 50 || l.MaxTo != 1000 || l.Call != 25 {
+		t.Fatalf("invalid limits after the raise: %+v", l)
+	}
+	if err := tbl.Act(users[1], Action{Type: ActionRaise, Amount: 40}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("the re-raise must be at least the previous raise, err: %v", err)
+	}
+	mustAct(t, tbl, users[1], ActionRaise, 50)
+	mustAct(t, tbl, users[2], ActionFold, 0)
+	mustAct(t, tbl, users[0], ActionCall, 0)
+
+	// the small blind acts first after the flop, the minimal bet is the big blind again
+	if tbl.hand.street != Flop || tbl.ToAct() != 1 {
+		t.Fatalf("invalid flop, street: %s, to act: %d", tbl.hand.street, tbl.ToAct())
+	}
+	if err := tbl.Act(users[1], Action{Type: ActionBet, Amount: 5}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("the bet must be at least the big blind, err: %v", err)
+	}
+	if err := tbl.Act(users[1], Action{Type: ActionRaise, Amount: 20}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("there is nothing to raise, err: %v", err)
+	}
+	mustAct(t, tbl, users[1], ActionCheck, 0)
+	mustAct(t, tbl, users[0], ActionBet, 10)
+	if l := tbl.Legal(); l.MinTo != 20 {
+		t.Fatalf("invalid limits after the bet: %+v", l)
+	}
+}
+
+func Test_Table_ShortAllInDoesNotReopen(t *testing.T) {
+	tbl, users := newTestTable(t, "", 1000, 45, 1000)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	mustAct(t, tbl, users[0], ActionRaise, 30)
+	// the small blind can only go all-in for less than a full raise
+	if l := tbl.Legal(); l.MinTo != 45 || l.MaxTo != 45 {
+		t.Fatalf("invalid limits of the short stack: %+v", l)
+	}
+	mustAct(t, tbl, users[1], ActionRaise, 45)
+	// the big blind has not acted yet, so it can raise
+	if l := tbl.Legal(); !l.allows(ActionRaise) || l.MinTo != 65 {
+		t.Fatalf("the big blind should be able to raise: %+v", l)
+	}
+	mustAct(t, tbl, users[2], ActionCall, 0)
+
+	// the first raiser can only call or fold
+	if l := tbl.Legal(); tbl.ToAct() != 0 || l.allows(ActionRaise) || l.Call != 15 {
+		t.Fatalf("the betting should not be reopened, to act: %d, legal: %+v", tbl.ToAct(), l)
+	}
+	if err := tbl.Act(users[0], Action{Type: ActionRaise, Amount: 100}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("the raise should be rejected, err: %v", err)
+	}
+	mustAct(t, tbl, users[0], ActionCall, 0)
+	if tbl.hand.street != Flop || tbl.ToAct() != 2 {
+		t.Fatalf("invalid flop, street: %s, to act: %d", tbl.hand.street, tbl.ToAct())
+	}
+}
+
+func Test_Table_SidePots(t *testing.T) {
+	// A (seat 0) has aces, B (seat 1) kings, C (seat 2) seven-deuce
+	tbl, users := newTestTable(t, "Ks2cAsKh7dAh 5cQc8d3s 6c4h Tc9c", 50, 100, 200)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	mustAct(t, tbl, users[0], ActionRaise, 50)
+	mustAct(t, tbl, users[1], ActionRaise, 100)
+	mustAct(t, tbl, users[2], ActionCall, 0)
+
+	// nobody can bet anymore, the board is run out
+	res := tbl.LastResult()
+	if tbl.InHand() || res == nil || !res.Showdown || res.Board != "Qc8d3s4h9c" {
+		t.Fatalf("the hand should be finished at the showdown: %+v", res)
+	}
+	expectedPots := []Pot{
+		{Amount: 150, Seats: []int{1, 2, 0}, Winners: []int{0}},
+		{Amount: 100, Seats: []int{1, 2}, Winners: []int{1}},
+	}
+	if !reflect.DeepEqual(res.Pots, expectedPots) {
+		t.Fatalf("invalid pots:\n got: %+v\nwant: %+v", res.Pots, expectedPots)
+	}
+	if !reflect.DeepEqual(stacks(tbl), []int64{150, 100, 100}) {
+		t.Fatalf("invalid stacks: %v", stacks(tbl))
+	}
+	if res.Players[0].Cards != "AsAh" || res.Players[0].Category != "pair" {
+		t.Fatalf("the winner should show aces: %+v", res.Players[0])
+	}
+}
+
+func Test_Table_UncalledBet(t *testing.T) {
+	tbl, users := newTestTable(t, "Ks2cAsKh7dAh 5cQc8d3s 6c4h Tc9c", 300, 100, 0)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	// heads-up: the button (A) posts the small blind
+	mustAct(t, tbl, users[0], ActionRaise, 250)
+	mustAct(t, tbl, users[1], ActionCall, 0)
+
+	res := tbl.LastResult()
+	if res == nil || len(res.Pots) != 1 || res.Pots[0].Amount != 200 {
+		t.Fatalf("the pot should be 200 (150 is uncalled): %+v", res)
+	}
+	if !reflect.DeepEqual(stacks(tbl), []int64{400, 0, 0}) {
+		t.Fatalf("invalid stacks: %v", stacks(tbl))
+	}
+
+	// the busted player is not dealt in
+	if err := tbl.StartHand(); !errors.Is(err, ErrNotEnoughPlayers) {
+		t.Fatalf("the hand cannot be started with one player, err: %v", err)
+	}
+}
+
+func Test_Table_SplitPot(t *testing.T) {
+	// everybody plays the broadway straight on the board
+	tbl, users := newTestTable(t, "2c4c6c3d5d7d 8hAhKdQc 8sJs 9hTs", 100, 100, 100)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	mustAct(t, tbl, users[0], ActionCall, 0)
+	mustAct(t, tbl, users[1], ActionFold, 0)
+	mustAct(t, tbl, users[2], ActionCheck, 0)
+	for street := 0; street < 3; street++ {
+		mustAct(t, tbl, users[2], ActionCheck, 0)
+		mustAct(t, tbl, users[0], ActionCheck, 0)
+	}
+
+	// the odd chip goes to the first winner after the button
+	res := tbl.LastResult()
+	if res == nil || !reflect.DeepEqual(res.Pots, []Pot{{Amount: 25, Seats: []int{2, 0}, Winners: []int{2, 0}}}) {
+		t.Fatalf("invalid result: %+v", res)
+	}
+	if !reflect.DeepEqual(stacks(tbl), []int64{102, 95, 103}) {
+		t.Fatalf("invalid stacks: %v", stacks(tbl))
+	}
+}
+
+func Test_Table_Seating(t *testing.T) {
+	tbl, users := newTestTable(t, "", 100, 100, 0)
+
+	if err := tbl.Sit(id.NewID(), "X", 0, 100); !errors.Is(err, ErrSeatTaken) {
+		t.Fatalf("the seat is taken, err: %v", err)
+	}
+	if err := tbl.Sit(users[0], "A", 2, 100); !errors.Is(err, ErrAlreadySeated) {
+		t.Fatalf("the player is seated, err: %v", err)
+	}
+	if err := tbl.Sit(users[2], "C", 2, 5); !errors.Is(err, ErrInvalidBuyIn) {
+		t.Fatalf("the buy-in is too small, err: %v", err)
+	}
+	if err := tbl.Sit(users[2], "C", 3, 100); !errors.Is(err, ErrInvalidSeat) {
+		t.Fatalf("the seat does not exist, err: %v", err)
+	}
+
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+	// a player seated during the hand waits for the next one
+	if err := tbl.Sit(users[2], "C", 2, 100); err != nil {
+		t.Fatalf("cannot sit: %s", err)
+	}
+	// the player leaving in the hand folds
+	if stack, err := tbl.Leave(users[0]); err != nil || stack != 95 {
+		t.Fatalf("the player should leave with 95, got: %d, err: %v", stack, err)
+	}
+	if tbl.InHand() || !reflect.DeepEqual(stacks(tbl), []int64{0, 105, 100}) {
+		t.Fatalf("the hand should be won by the other player, stacks: %v", stacks(tbl))
+	}
+	if _, err := tbl.Leave(users[0]); !errors.Is(err, ErrNotSeated) {
+		t.Fatalf("the player has left, err: %v", err)
+	}
+}
+
+func Test_Table_LeaveOutOfTurn(t *testing.T) {
+	tbl, users := newTestTable(t, "", 100, 100, 100)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	// the big blind leaves before the button acts, the blind stays in the pot
+	if stack, err := tbl.Leave(users[2]); err != nil || stack != 90 {
+		t.Fatalf("the player should leave with 90, got: %d, err: %v", stack, err)
+	}
+	if !tbl.InHand() || tbl.ToAct() != 0 {
+		t.Fatalf("the hand should go on with the button to act, to act: %d", tbl.ToAct())
+	}
+	if err := tbl.Sit(id.NewID(), "X", 2, 100); !errors.Is(err, ErrSeatTaken) {
+		t.Fatalf("the seat is free after the hand, err: %v", err)
+	}
+	mustAct(t, tbl, users[0], ActionCall, 0)
+	mustAct(t, tbl, users[1], ActionCall, 0)
+	if tbl.hand.street != Flop {
+		t.Fatalf("the flop should be dealt, street: %s", tbl.hand.street)
+	}
+	mustAct(t, tbl, users[1], ActionBet, 10)
+	mustAct(t, tbl, users[0], ActionFold, 0)
+	if tbl.InHand() || !reflect.DeepEqual(stacks(tbl), []int64{90, 120, 0}) {
+		t.Fatalf("the small blind should win the pot, stacks: %v", stacks(tbl))
+	}
+}
+
+func Test_Table_TimeOut(t *testing.T) {
+	tbl, users := newTestTable(t, "", 100, 100)
+	tm := timer.NewFakeTimer(time.Date(2022, 1, 1, 20, 0, 0, 0, time.UTC))
+	tbl.timer = tm
+	tbl.config.TurnTimeout = 30 * time.Second
+
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+	if deadline, ok := tbl.Deadline(); !ok || !deadline.Equal(tm.Now().Add(30*time.Second)) {
+		t.Fatalf("invalid deadline: %s", deadline)
+	}
+	tm.Advance(29 * time.Second)
+	if tbl.TimeOut() {
+		t.Fatalf("the player still has time")
+	}
+	// the small blind cannot check
+	tm.Advance(time.Second)
+	if !tbl.TimeOut() {
+		t.Fatalf("the turn should run out")
+	}
+	if tbl.InHand() || !reflect.DeepEqual(stacks(tbl), []int64{95, 105}) {
+		t.Fatalf("the small blind should fold, stacks: %v", stacks(tbl))
+	}
+
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+	tm.Advance(10 * time.Second)
+	mustAct(t, tbl, users[1], ActionCall, 0)
+	// the turn of the big blind starts after the call
+	tm.Advance(29 * time.Second)
+	if tbl.TimeOut() {
+		t.Fatalf("the big blind still has time")
+	}
+	tm.Advance(time.Second)
+	if !tbl.TimeOut() {
+		t.Fatalf("the turn should run out")
+	}
+	if !tbl.InHand() || tbl.hand.street != Flop || stacks(tbl)[0] != 85 {
+		t.Fatalf("the big blind should check, street: %s, stacks: %v", tbl.hand.street, stacks(tbl))
+	}
+	if deadline, _ := tbl.Deadline(); !deadline.Equal(tm.Now().Add(30 * time.Second)) {
+		t.Fatalf("the turn should start on the flop, deadline: %s", deadline)
+	}
+}
+
+func Test_Table_View(t *testing.T) {
+	tbl, users := newTestTable(t, "AsKs2c3d", 100, 100)
+	if err := tbl.StartHand(); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+
+	// heads-up the big blind (seat 1) is dealt first
+	v := tbl.View(users[0])
+	if v.You != 0 || v.Seats[0].Cards != "Ks3d" || v.Seats[1].Cards != "" {
+		t.Fatalf("only own cards should be visible: %+v %+v", v.Seats[0], v.Seats[1])
+	}
+	if v.Hand == nil || v.Hand.Pot != 15 || v.Hand.Legal == nil || !v.Hand.Legal.allows(ActionCall) {
+		t.Fatalf("invalid hand view: %+v", v.Hand)
+	}
+	if v := tbl.View(users[1]); v.Hand.Legal != nil || v.Seats[1].Cards != "As2c" {
+		t.Fatalf("it is not the turn of the big blind: %+v", v.Hand)
+	}
+	if v := tbl.View(id.NewID()); v.You != -1 || v.Seats[0].Cards != "" || v.Seats[1].Cards != "" {
+		t.Fatalf("observers cannot see cards")
+	}
+}
+
+func Test_Table_Deterministic(t *testing.T) {
+	play := func() (View, *Result) {
+		tbl, err := New(testConfig, 42)
+		if err != nil {
+			t.Fatalf("cannot create the table: %s", err)
+		}
+		users := []id.ID{{1}, {2}, {3}}
+		for seat, u := range users {
+			if err := tbl.Sit(u, "player", seat, 100); err != nil {
+				t.Fatalf("cannot sit: %s", err)
+			}
+		}
+		for hand := 0; hand < 3; hand++ {
+			if err := tbl.StartHand(); err != nil {
+				t.Fatalf("cannot start the hand: %s", err)
+			}
+			for tbl.InHand() {
+				l := tbl.Legal()
+				action := Action{Type: l.Actions[1]}
+				if l.allows(ActionRaise) && hand == 1 {
+					action = Action{Type: ActionRaise, Amount: l.MaxTo}
+				}
+				mustAct(t, tbl, tbl.seats[tbl.ToAct()].UserID, action.Type, action.Amount)
+			}
+		}
+		return tbl.View(users[0]), tbl.LastResult()
+	}
+
+	v1, r1 := play()
+	v2, r2 := play()
+	if !reflect.DeepEqual(v1, v2) || !reflect.DeepEqual(r1, r2) {
+		t.Fatalf("hands with the same seed should be the same:\n%+v\n%+v", r1, r2)
+	}
+	var total int64
+	for _, s := range v1.Seats {
+		total += s.Stack
+	}
+	if total != 300 {
+		t.Fatalf("chips cannot be created nor lost, total: %d", total)
+	}
+}
+
+func Test_Manager_Sweep(t *testing.T) {
+	tm := timer.NewFakeTimer(time.Date(2022, 1, 1, 20, 0, 0, 0, time.UTC))
+	m := NewManager(tm)
+	orgID := id.NewID()
+	config := testConfig
+	config.TurnTimeout = 30 * time.Second
+
+	empty, err := m.Create(orgID, "empty", config)
+	if err != nil {
+		t.Fatalf("cannot create the table: %s", err)
+	}
+	played, err := m.Create(orgID, "played", config)
+	if err != nil {
+		t.Fatalf("cannot create the table: %s", err)
+	}
+	users := []id.ID{id.NewID(), id.NewID()}
+	for seat, user := range users {
+		if err := played.Sit(user, "P", seat, 100); err != nil {
+			t.Fatalf("cannot sit: %s", err)
+		}
+	}
+	if err := played.StartHand(users[0]); err != nil {
+		t.Fatalf("cannot start the hand: %s", err)
+	}
+	changes, _ := empty.Subscribe()
+
+	tm.Advance(EmptyTimeout)
+	m.Sweep()
+	if _, err := m.Get(empty.id); !errors.Is(err, ErrTableNotExists) {
+		t.Fatalf("the empty table should be removed, err: %v", err)
+	}
+	if _, ok := <-changes; ok {
+		t.Fatalf("subscribers of the removed table should be closed")
+	}
+	if _, err := m.Get(played.id); err != nil {
+		t.Fatalf("the played table should stay: %s", err)
+	}
+	if v := played.View(users[0]); v.Hand != nil {
+		t.Fatalf("the player to act should fold after the timeout: %+v", v.Hand)
+	}
+
+	tm.Advance(IdleTimeout)
+	m.Sweep()
+	if tables := m.List(orgID); len(tables) != 0 {
+		t.Fatalf("the idle table should be removed: %+v", tables)
+	}
+}
@@ -0,0 +1,103 @@
+package table
+
+import (
+	"time"
+
+	"pokergo/pkg/id"
+	"pokergo/pkg/poker"
+)
+
+// SeatView is a seated player seen by the viewer
+type SeatView struct {
+	UserID   id.ID  `json:"user_id"`
+	UserName string `json:"user_name"`
+	Stack    int64  `json:"stack"`
+	// Bet is the amount put into the pot in the current street
+	Bet    int64 `json:"bet"`
+	InHand bool  `json:"in_hand"`
+	Folded bool  `json:"folded"`
+	AllIn  bool  `json:"all_in"`
+	// Cards are hole cards, only the viewer's ones are visible
+	Cards string `json:"cards,omitempty"`
+}
+
+// HandView is the hand being played seen by the viewer
+type HandView struct {
+	No     int    `json:"no"`
+	Street Street `json:"street"`
+	Board  string `json:"board"`
+	// Pot are all the chips put into the pot (including bets in the current street)
+	Pot        int64       `json:"pot"`
+	ToAct      int         `json:"to_act"`
+	CurrentBet int64       `json:"current_bet"`
+	Actions    []ActionLog `json:"actions"`
+	// Deadline is when the player to act checks or folds automatically, nil if turns are not limited
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Legal are actions of the viewer, nil if it is not the viewer's turn
+	Legal *Legal `json:"legal,omitempty"`
+}
+
+// View is the state of the table seen by the viewer (other players' hole cards are hidden)
+type View struct {
+	Config Config `json:"config"`
+	// Seats are players by seats, nil for empty seats
+	Seats []*SeatView `json:"seats"`
+	// Button is the seat of the dealer button, -1 before the first hand
+	Button int `json:"button"`
+	// You is the seat of the viewer, -1 if the viewer is not seated
+	You  int       `json:"you"`
+	Hand *HandView `json:"hand,omitempty"`
+	Last *Result   `json:"last,omitempty"`
+}
+
+// View returns the state of the table seen by the viewer
+func (t *Table) View(viewer id.ID) View {
+	v := View{
+		Config: t.config,
+		Seats:  make([]*SeatView, len(t.seats)),
+		Button: t.button,
+		You:    t.seatOf(viewer),
+		Last:   t.last,
+	}
+
+	for seat, s := range t.seats {
+		if s == nil {
+			continue
+		}
+		sv := &SeatView{UserID: s.UserID, UserName: s.UserName, Stack: s.Stack}
+		if t.hand != nil && t.hand.players[seat] != nil {
+			p := t.hand.players[seat]
+			sv.Bet, sv.InHand, sv.Folded, sv.AllIn = p.bet, true, p.folded, p.allIn
+			if seat == v.You {
+				sv.Cards = poker.FormatCards(p.hole)
+			}
+		}
+		v.Seats[seat] = sv
+	}
+
+	if h := t.hand; h != nil {
+		hv := &HandView{
+			No:         h.no,
+			Street:     h.street,
+			Board:      poker.FormatCards(h.board),
+			ToAct:      h.toAct,
+			CurrentBet: h.currentBet,
+			Actions:    append([]ActionLog{}, h.actions...),
+		}
+		for _, p := range h.players {
+			if p != nil {
+				hv.Pot += p.total
+			}
+		}
+		if deadline, ok := t.Deadline(); ok {
+			hv.Deadline = &deadline
+		}
+		if h.toAct == v.You {
+			legal := t.Legal()
+			hv.Legal = &legal
+		}
+		v.Hand = hv
+	}
+
+	return v
+}
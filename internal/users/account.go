@@ -0,0 +1,293 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"pokergo/internal/mail"
+	"pokergo/pkg/crypto"
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+var ErrInvalidToken = errors.New("the token is invalid or expired")
+
+const (
+	// VerificationValidity is the validity of email verification links
+	VerificationValidity = 48 * time.Hour
+	// ResetValidity is the validity of password reset links
+	ResetValidity = time.Hour
+)
+
+// Purpose tells what a single-use token is for
+type Purpose string
+
+const (
+	PurposeVerifyEmail   Purpose = "verify_email"
+	PurposeResetPassword Purpose = "reset_password"
+)
+
+// OneTimeToken is a single-use token sent to the user by email, only its hash is stored
+type OneTimeToken struct {
+	// Hash is the hex encoded SHA-256 of the token
+	Hash      string    `bson:"_id"` // nolint:tagliatelle // mongo-id
+	UserID    id.ID     `bson:"user_id"`
+	Purpose   Purpose   `bson:"purpose"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// OneTimeTokenAdapter stores single-use tokens
+type OneTimeTokenAdapter interface {
+	AddToken(ctx context.Context, token OneTimeToken) error
+	// UseToken removes and returns the token (ErrInvalidToken if there is no such token)
+	UseToken(ctx context.Context, hash string, purpose Purpose) (OneTimeToken, error)
+	// DeleteTokens removes all tokens of the user with the purpose
+	DeleteTokens(ctx context.Context, userID id.ID, purpose Purpose) error
+}
+
+// AccountManager verifies emails and resets passwords of users using links sent by email
+type AccountManager interface {
+	// SendVerification sends the email verification link to the user
+	SendVerification(ctx context.Context, u User) error
+	// VerifyEmail marks the email of the token owner as verified
+	VerifyEmail(ctx context.Context, token string) (User, error)
+	// ForgotPassword sends the password reset link to the user
+	ForgotPassword(ctx context.Context, name string) error
+	// ResetPassword sets the new password of the token owner (the email becomes verified too),
+	// it returns the user before the reset, so the caller can revoke the user access token
+	ResetPassword(ctx context.Context, token, password string) (User, error)
+}
+
+type accountManager struct {
+	userAdapter  Adapter
+	tokenAdapter OneTimeTokenAdapter
+	mailer       mail.Mailer
+	timer        timer.Timer
+	// appURL is the address of the web application handling links from emails
+	appURL string
+}
+
+func NewAccountManager(
+	userAdapter Adapter,
+	tokenAdapter OneTimeTokenAdapter,
+	mailer mail.Mailer,
+	timer timer.Timer,
+	appURL string,
+) *accountManager {
+	return &accountManager{userAdapter, tokenAdapter, mailer, timer, appURL}
+}
+
+func (m *accountManager) SendVerification(ctx context.Context, u User) error {
+	link, err := m.link(ctx, u, PurposeVerifyEmail, "verify", VerificationValidity)
+	if err != nil {
+		return err
+	}
+
+	return m.send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nopen the link to verify your email:\n%s\n\nThe link expires in %s.\n",
+			u.Username, link, VerificationValidity),
+	})
+}
+
+func (m *accountManager) VerifyEmail(ctx context.Context, token string) (User, error) {
+	u, err := m.use(ctx, token, PurposeVerifyEmail)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := m.userAdapter.SetEmailVerified(ctx, u.ID); err != nil {
+		return User{}, fmt.Errorf("cannot verify the email: %w", err)
+	}
+	u.EmailVerified = true
+	return u, nil
+}
+
+func (m *accountManager) ForgotPassword(ctx context.Context, name string) error {
+	u, err := m.userAdapter.GetUserByName(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot find the user: %w", err)
+	}
+
+	link, err := m.link(ctx, u, PurposeResetPassword, "reset", ResetValidity)
+	if err != nil {
+		return err
+	}
+
+	return m.send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nopen the link to set a new password:\n%s\n\n"+
+			"The link expires in %s. Ignore this email if you have not asked to reset the password.\n",
+			u.Username, link, ResetValidity),
+	})
+}
+
+func (m *accountManager) ResetPassword(ctx context.Context, token, password string) (User, error) {
+	u, err := m.use(ctx, token, PurposeResetPassword)
+	if err != nil {
+		return User{}, err
+	}
+
+	encPass, err := crypto.HashPassword(password)
+	if err != nil {
+		return User{}, fmt.Errorf("cannot encrypt password: %w", err)
+	}
+	if err := m.userAdapter.SetPassword(ctx, u.ID, encPass); err != nil {
+		return User{}, fmt.Errorf("cannot reset the password: %w", err)
+	}
+	// the user has just opened the link sent to the email
+	if err := m.userAdapter.SetEmailVerified(ctx, u.ID); err != nil {
+		return User{}, fmt.Errorf("cannot verify the email: %w", err)
+	}
+	return u, nil
+}
+
+// link creates a single-use token and returns the link of the web application with it
+func (m *accountManager) link(
+	ctx context.Context,
+	u User,
+	purpose Purpose,
+	path string,
+	validity time.Duration,
+) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("cannot generate the token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := m.tokenAdapter.AddToken(ctx, OneTimeToken{
+		Hash:      hash(token),
+		UserID:    u.ID,
+		Purpose:   purpose,
+		ExpiresAt: m.timer.Now().Add(validity),
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot save the token: %w", err)
+	}
+
+	return fmt.Sprintf("%s/%s?token=%s", m.appURL, path, url.QueryEscape(token)), nil
+}
+
+// use consumes the token and returns its owner, other tokens of the user with the same purpose are removed
+func (m *accountManager) use(ctx context.Context, token string, purpose Purpose) (User, error) {
+	t, err := m.tokenAdapter.UseToken(ctx, hash(token), purpose)
+	if err != nil {
+		return User{}, err // nolint:wrapcheck // ErrInvalidToken or wrapped by the adapter
+	}
+	if !m.timer.Now().Before(t.ExpiresAt) {
+		return User{}, ErrInvalidToken
+	}
+
+	if err := m.tokenAdapter.DeleteTokens(ctx, t.UserID, purpose); err != nil {
+		return User{}, fmt.Errorf("cannot remove tokens: %w", err)
+	}
+
+	u, err := m.userAdapter.GetUserByID(ctx, t.UserID)
+	if err != nil {
+		return User{}, fmt.Errorf("cannot find the user: %w", err)
+	}
+	return u, nil
+}
+
+func (m *accountManager) send(ctx context.Context, msg mail.Message) error {
+	if err := m.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("cannot send the email: %w", err)
+	}
+	return nil
+}
+
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type mongoOneTimeTokenAdapter struct {
+	coll *mongo.Collection
+}
+
+func NewMongoOneTimeTokenAdapter(coll *mongo.Collection) *mongoOneTimeTokenAdapter {
+	return &mongoOneTimeTokenAdapter{coll: coll}
+}
+
+func (m *mongoOneTimeTokenAdapter) EnsureIndexes(ctx context.Context) error {
+	expiresIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	userIdx := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "user_id", Value: 1},
+			{Key: "purpose", Value: 1},
+		},
+	}
+
+	if _, err := m.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{expiresIdx, userIdx}); err != nil {
+		return fmt.Errorf("cannot create one-time tokens indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoOneTimeTokenAdapter) AddToken(ctx context.Context, token OneTimeToken) error {
+	if _, err := m.coll.InsertOne(ctx, token); err != nil {
+		return fmt.Errorf("cannot insert the token: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoOneTimeTokenAdapter) UseToken(
+	ctx context.Context,
+	tokenHash string,
+	purpose Purpose,
+) (OneTimeToken, error) {
+	filter := bson.M{
+		"_id":     tokenHash,
+		"purpose": purpose,
+	}
+
+	res := m.coll.FindOneAndDelete(ctx, filter)
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return OneTimeToken{}, ErrInvalidToken
+		}
+		return OneTimeToken{}, fmt.Errorf("cannot find the token: %w", err)
+	}
+
+	var token OneTimeToken
+	if err := res.Decode(&token); err != nil {
+		return OneTimeToken{}, fmt.Errorf("cannot decode the token: %w", err)
+	}
+	return token, nil
+}
+
+func (m *mongoOneTimeTokenAdapter) DeleteTokens(ctx context.Context, userID id.ID, purpose Purpose) error {
+	filter := bson.M{
+		"user_id": userID,
+		"purpose": purpose,
+	}
+
+	if _, err := m.coll.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("cannot delete tokens: %w", err)
+	}
+	return nil
+}
+
+var (
+	_ AccountManager      = (*accountManager)(nil)
+	_ OneTimeTokenAdapter = (*mongoOneTimeTokenAdapter)(nil)
+)
@@ -0,0 +1,230 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"pokergo/internal/mail"
+	"pokergo/pkg/crypto"
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+// memoryAdapter is an in-memory Adapter
+type memoryAdapter struct {
+	users map[id.ID]User
+}
+
+func (m *memoryAdapter) NewUser(_ context.Context, user User) (User, error) {
+	user.ID = id.NewID()
+	m.users[user.ID] = user
+	return user, nil
+}
+
+func (m *memoryAdapter) GetUserByName(_ context.Context, name string) (User, error) {
+	for _, u := range m.users {
+		if u.Username == name {
+			return u, nil
+		}
+	}
+	return User{}, ErrUserNotExists
+}
+
+func (m *memoryAdapter) GetUserByID(_ context.Context, userID id.ID) (User, error) {
+	u, ok := m.users[userID]
+	if !ok {
+		return User{}, ErrUserNotExists
+	}
+	return u, nil
+}
+
+func (m *memoryAdapter) UserDetails(_ context.Context, ids []id.ID) (map[id.ID]User, error) {
+	res := make(map[id.ID]User, len(ids))
+	for _, i := range ids {
+		if u, ok := m.users[i]; ok {
+			res[i] = u
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryAdapter) UpdateTokens(_ context.Context, userID id.ID, token, refreshedToken *string) error {
+	u := m.users[userID]
+	if token != nil {
+		u.Token = *token
+	}
+	if refreshedToken != nil {
+		u.RefreshToken = *refreshedToken
+	}
+	m.users[userID] = u
+	return nil
+}
+
+func (m *memoryAdapter) RotateTokens(_ context.Context, userID id.ID, refreshToken, newToken, newRefresh string) error {
+	u, ok := m.users[userID]
+	if !ok || u.RefreshToken != refreshToken {
+		return ErrRefreshTokenRevoked
+	}
+	u.Token, u.RefreshToken = newToken, newRefresh
+	m.users[userID] = u
+	return nil
+}
+
+func (m *memoryAdapter) SetEmailVerified(_ context.Context, userID id.ID) error {
+	u := m.users[userID]
+	u.EmailVerified = true
+	m.users[userID] = u
+	return nil
+}
+
+func (m *memoryAdapter) SetPassword(_ context.Context, userID id.ID, password string) error {
+	u := m.users[userID]
+	u.Password, u.Token, u.RefreshToken = password, "", ""
+	m.users[userID] = u
+	return nil
+}
+
+// memoryTokenAdapter is an in-memory OneTimeTokenAdapter
+type memoryTokenAdapter struct {
+	tokens map[string]OneTimeToken
+}
+
+func (m *memoryTokenAdapter) AddToken(_ context.Context, token OneTimeToken) error {
+	m.tokens[token.Hash] = token
+	return nil
+}
+
+func (m *memoryTokenAdapter) UseToken(_ context.Context, tokenHash string, purpose Purpose) (OneTimeToken, error) {
+	t, ok := m.tokens[tokenHash]
+	if !ok || t.Purpose != purpose {
+		return OneTimeToken{}, ErrInvalidToken
+	}
+	delete(m.tokens, tokenHash)
+	return t, nil
+}
+
+func (m *memoryTokenAdapter) DeleteTokens(_ context.Context, userID id.ID, purpose Purpose) error {
+	for h, t := range m.tokens {
+		if t.UserID == userID && t.Purpose == purpose {
+			delete(m.tokens, h)
+		}
+	}
+	return nil
+}
+
+type accountTestEnv struct {
+	ctx     context.Context
+	clock   *timer.FakeTimer
+	users   *memoryAdapter
+	tokens  *memoryTokenAdapter
+	mailer  *mail.MemoryMailer
+	manager *accountManager
+	user    User
+}
+
+func newAccountTestEnv() *accountTestEnv {
+	env := &accountTestEnv{
+		ctx:    context.Background(),
+		clock:  timer.NewFakeTimer(time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC)),
+		users:  &memoryAdapter{users: make(map[id.ID]User)},
+		tokens: &memoryTokenAdapter{tokens: make(map[string]OneTimeToken)},
+		mailer: mail.NewMemoryMailer(),
+	}
+	env.manager = NewAccountManager(env.users, env.tokens, env.mailer, env.clock, "http://app")
+	env.user, _ = env.users.NewUser(env.ctx, User{Username: "user", Email: "user@example.com", Token: "t"})
+	return env
+}
+
+// lastToken returns the token from the link of the last sent email
+func (e *accountTestEnv) lastToken(t *testing.T, path string) string {
+	t.Helper()
+	sent := e.mailer.Sent()
+	if len(sent) == 0 {
+		t.Fatalf("no email was sent")
+	}
+	msg := sent[len(sent)-1]
+	if msg.To != e.user.Email {
+		t.Fatalf("the email was sent to %s", msg.To)
+	}
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if strings.HasPrefix(line, "http://app/"+path+"?") {
+			u, err := url.Parse(line)
+			if err != nil {
+				t.Fatalf("invalid link %q: %s", line, err)
+			}
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("no %s link in the email: %q", path, msg.Body)
+	return ""
+}
+
+func Test_AccountManager_VerifyEmail(t *testing.T) {
+	env := newAccountTestEnv()
+
+	if err := env.manager.SendVerification(env.ctx, env.user); err != nil {
+		t.Fatalf("cannot send the verification: %s", err)
+	}
+	token := env.lastToken(t, "verify")
+	if _, ok := env.tokens.tokens[token]; ok {
+		t.Errorf("the token should be stored as a hash only")
+	}
+
+	if _, err := env.manager.VerifyEmail(env.ctx, token+"x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	u, err := env.manager.VerifyEmail(env.ctx, token)
+	if err != nil || !u.EmailVerified || !env.users.users[env.user.ID].EmailVerified {
+		t.Fatalf("the email should be verified, err: %v", err)
+	}
+	if _, err := env.manager.VerifyEmail(env.ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("the token can be used once, got %v", err)
+	}
+
+	_ = env.manager.SendVerification(env.ctx, env.user)
+	token = env.lastToken(t, "verify")
+	env.clock.Advance(VerificationValidity)
+	if _, err := env.manager.VerifyEmail(env.ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired token, expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func Test_AccountManager_ResetPassword(t *testing.T) {
+	env := newAccountTestEnv()
+
+	if err := env.manager.ForgotPassword(env.ctx, "nobody"); !errors.Is(err, ErrUserNotExists) {
+		t.Errorf("expected ErrUserNotExists, got %v", err)
+	}
+	if err := env.manager.ForgotPassword(env.ctx, env.user.Username); err != nil {
+		t.Fatalf("cannot send the reset email: %s", err)
+	}
+	first := env.lastToken(t, "reset")
+	_ = env.manager.ForgotPassword(env.ctx, env.user.Username)
+	second := env.lastToken(t, "reset")
+
+	if _, err := env.manager.ResetPassword(env.ctx, first, "new"); err != nil {
+		t.Fatalf("cannot reset the password: %s", err)
+	}
+	u := env.users.users[env.user.ID]
+	if err := crypto.VerifyPassword(u.Password, "new"); err != nil {
+		t.Errorf("the password should be changed: %s", err)
+	}
+	if u.Token != "" || u.RefreshToken != "" || !u.EmailVerified {
+		t.Errorf("tokens should be cleared and the email verified: %+v", u)
+	}
+
+	if _, err := env.manager.ResetPassword(env.ctx, second, "other"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("other reset tokens should be invalidated, got %v", err)
+	}
+
+	_ = env.manager.ForgotPassword(env.ctx, env.user.Username)
+	expired := env.lastToken(t, "reset")
+	env.clock.Advance(ResetValidity)
+	if _, err := env.manager.ResetPassword(env.ctx, expired, "other"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired token, expected ErrInvalidToken, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RevokedToken is an access token which cannot be used anymore (the user logged out or refreshed tokens)
+type RevokedToken struct {
+	// TokenID is the jti claim of the token
+	TokenID string `bson:"_id"` // nolint:tagliatelle // mongo-id
+	// ExpiresAt is the expiration time of the token, the revocation is removed after it
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// RevocationAdapter stores revoked access tokens till they expire
+type RevocationAdapter interface {
+	// Revoke revokes the token, revoking it again is a no-op
+	Revoke(ctx context.Context, token RevokedToken) error
+	// IsRevoked tells if the token was revoked
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+type mongoRevocationAdapter struct {
+	coll *mongo.Collection
+}
+
+func NewMongoRevocationAdapter(coll *mongo.Collection) *mongoRevocationAdapter {
+	return &mongoRevocationAdapter{coll: coll}
+}
+
+func (m *mongoRevocationAdapter) EnsureIndexes(ctx context.Context) error {
+	// expired tokens are rejected anyway, so mongo removes their revocations
+	expiresIdx := mongo.IndexModel{
+		Keys: bson.M{
+			"expires_at": 1,
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	if _, err := m.coll.Indexes().CreateOne(ctx, expiresIdx); err != nil {
+		return fmt.Errorf("cannot create expires_at:1 ttl index: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoRevocationAdapter) Revoke(ctx context.Context, token RevokedToken) error {
+	filter := bson.M{
+		"_id": token.TokenID,
+	}
+	update := bson.M{
+		"$setOnInsert": token,
+	}
+
+	if _, err := m.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("cannot revoke the token: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongoRevocationAdapter) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if tokenID == "" {
+		// tokens issued before revocations were introduced
+		return false, nil
+	}
+
+	err := m.coll.FindOne(ctx, bson.M{"_id": tokenID}).Err()
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("cannot check the token: %w", err)
+	default:
+		return true, nil
+	}
+}
+
+var _ RevocationAdapter = (*mongoRevocationAdapter)(nil)
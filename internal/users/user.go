@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,6 +21,8 @@ type User struct {
 	Username string `bson:"name"`
 	// Email is user email
 	Email string `bson:"email"`
+	// EmailVerified is true if the user opened the link sent to the email
+	EmailVerified bool `bson:"email_verified"`
 	// Password is an encrypted password
 	Password string `bson:"password"`
 	// Token is a jwt-token
@@ -41,9 +44,19 @@ type Adapter interface {
 
 	// UpdateTokens update user tokens (if they are not nil)
 	UpdateTokens(ctx context.Context, userID id.ID, token, refreshedToken *string) error
+	// RotateTokens replaces user tokens if the refresh token is the current one (ErrRefreshTokenRevoked otherwise),
+	// empty tokens revoke the session
+	RotateTokens(ctx context.Context, userID id.ID, refreshToken, newToken, newRefreshToken string) error
+	// SetEmailVerified marks the email of the user as verified
+	SetEmailVerified(ctx context.Context, userID id.ID) error
+	// SetPassword replaces the encrypted password and clears user tokens
+	SetPassword(ctx context.Context, userID id.ID, password string) error
 }
 
-var ErrUserNotExists = mongo.ErrNoDocuments
+var (
+	ErrUserNotExists       = mongo.ErrNoDocuments
+	ErrRefreshTokenRevoked = errors.New("refresh token was revoked")
+)
 
 type mongoAdapter struct {
 	coll   *mongo.Collection
@@ -171,4 +184,76 @@ func (m *mongoAdapter) UpdateTokens(ctx context.Context, userID id.ID, token, re
 	return nil
 }
 
+func (m *mongoAdapter) RotateTokens(
+	ctx context.Context,
+	userID id.ID,
+	refreshToken, newToken, newRefreshToken string,
+) error {
+	// the refresh token is a part of the filter, so the token can be used once even by concurrent requests
+	filter := bson.M{
+		"_id":           userID,
+		"refresh_token": refreshToken,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"token":         newToken,
+			"refresh_token": newRefreshToken,
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("cannot rotate tokens: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrRefreshTokenRevoked
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) SetEmailVerified(ctx context.Context, userID id.ID) error {
+	filter := bson.M{
+		"_id": userID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"email_verified": true,
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("cannot verify the email: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotExists
+	}
+
+	return nil
+}
+
+func (m *mongoAdapter) SetPassword(ctx context.Context, userID id.ID, password string) error {
+	filter := bson.M{
+		"_id": userID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"password":      password,
+			"token":         "",
+			"refresh_token": "",
+		},
+	}
+
+	res, err := m.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("cannot set the password: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotExists
+	}
+
+	return nil
+}
+
 var _ Adapter = (*mongoAdapter)(nil)
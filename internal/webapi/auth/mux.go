@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"time"
@@ -11,26 +12,40 @@ import (
 	"pokergo/pkg/crypto"
 	"pokergo/pkg/id"
 	"pokergo/pkg/jwt"
+	"pokergo/pkg/logger"
 	"pokergo/pkg/timer"
 )
 
+// forgotPasswordTimeout limits sending the password reset email (it is sent after the response)
+const forgotPasswordTimeout = 60 * time.Second
+
 type mux struct {
-	userAdapter users.Adapter
-	timer       timer.Timer
-	jwt         *jwt.JWT
+	userAdapter       users.Adapter
+	revocationAdapter users.RevocationAdapter
+	accountManager    users.AccountManager
+	timer             timer.Timer
+	jwt               *jwt.JWT
+	logger            logger.Logger
 }
 
 func NewMux(
 	userAdapter users.Adapter,
+	revocationAdapter users.RevocationAdapter,
+	accountManager users.AccountManager,
 	timer timer.Timer,
 	jwt *jwt.JWT,
 ) *mux {
-	return &mux{userAdapter, timer, jwt}
+	return &mux{userAdapter, revocationAdapter, accountManager, timer, jwt, logger.NewLogger()}
 }
 
 func (m *mux) Route(g *echo.Group) {
 	g.POST("/signup", m.SignUp)
 	g.POST("/login", m.LogIn)
+	g.POST("/refresh", m.Refresh)
+	g.POST("/logout", m.LogOut)
+	g.POST("/verify", m.VerifyEmail)
+	g.POST("/forgot", m.ForgotPassword)
+	g.POST("/reset", m.ResetPassword)
 }
 
 func (m *mux) SignUp(c echo.Context) error {
@@ -76,6 +91,11 @@ func (m *mux) SignUp(c echo.Context) error {
 			"but user was created: %s", err.Error()))
 	}
 
+	if err := m.accountManager.SendVerification(reqCtx, u); err != nil {
+		return c.String(500, fmt.Sprintf("cannot send the verification email, "+
+			"but user was created: %s", err.Error()))
+	}
+
 	return c.JSON(200, authResponse{
 		ID:           u.ID.Hex(),
 		Token:        token,
@@ -119,8 +139,196 @@ func (m *mux) LogIn(c echo.Context) error {
 	}
 
 	return c.JSON(200, authResponse{
-		ID:           u.ID.Hex(),
-		Token:        token,
-		RefreshToken: refresh,
+		ID:            u.ID.Hex(),
+		Token:         token,
+		RefreshToken:  refresh,
+		EmailVerified: u.EmailVerified,
+	})
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens, the refresh token can be used once
+// and the previous access token is revoked
+func (m *mux) Refresh(c echo.Context) error {
+	reqCtx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(60)*time.Second)
+	defer cancel()
+
+	var request refreshRequest
+	if err := c.Bind(&request); err != nil {
+		return c.String(400, fmt.Sprintf("cannot bind input data: %s", err.Error()))
+	}
+	if err := c.Validate(request); err != nil {
+		return c.String(400, fmt.Sprintf("invalid request: %s", err.Error()))
+	}
+
+	u, code, err := m.refreshTokenUser(reqCtx, request.RefreshToken)
+	if err != nil {
+		return c.String(code, err.Error())
+	}
+
+	token, refresh, err := m.jwt.GenerateTokens(u.Email, u.Username, u.ID)
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot generate user token: %s", err.Error()))
+	}
+
+	if err := m.userAdapter.RotateTokens(reqCtx, u.ID, request.RefreshToken, token, refresh); err != nil {
+		if errors.Is(err, users.ErrRefreshTokenRevoked) {
+			return c.String(403, err.Error())
+		}
+		return c.String(500, fmt.Sprintf("cannot update user token: %s", err.Error()))
+	}
+
+	if err := m.revoke(reqCtx, u.Token); err != nil {
+		return c.String(500, err.Error())
+	}
+
+	return c.JSON(200, authResponse{
+		ID:            u.ID.Hex(),
+		Token:         token,
+		RefreshToken:  refresh,
+		EmailVerified: u.EmailVerified,
 	})
 }
+
+// LogOut revokes the refresh token and the access token issued with it
+func (m *mux) LogOut(c echo.Context) error {
+	reqCtx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(60)*time.Second)
+	defer cancel()
+
+	var request refreshRequest
+	if err := c.Bind(&request); err != nil {
+		return c.String(400, fmt.Sprintf("cannot bind input data: %s", err.Error()))
+	}
+	if err := c.Validate(request); err != nil {
+		return c.String(400, fmt.Sprintf("invalid request: %s", err.Error()))
+	}
+
+	u, code, err := m.refreshTokenUser(reqCtx, request.RefreshToken)
+	if err != nil {
+		return c.String(code, err.Error())
+	}
+
+	if err := m.userAdapter.RotateTokens(reqCtx, u.ID, request.RefreshToken, "", ""); err != nil {
+		if errors.Is(err, users.ErrRefreshTokenRevoked) {
+			return c.String(403, err.Error())
+		}
+		return c.String(500, fmt.Sprintf("cannot revoke user token: %s", err.Error()))
+	}
+
+	if err := m.revoke(reqCtx, u.Token); err != nil {
+		return c.String(500, err.Error())
+	}
+
+	return c.String(200, "ok")
+}
+
+// VerifyEmail verifies the email with the token sent at sign up
+func (m *mux) VerifyEmail(c echo.Context) error {
+	reqCtx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(60)*time.Second)
+	defer cancel()
+
+	var request verifyRequest
+	if err := c.Bind(&request); err != nil {
+		return c.String(400, fmt.Sprintf("cannot bind input data: %s", err.Error()))
+	}
+	if err := c.Validate(request); err != nil {
+		return c.String(400, fmt.Sprintf("invalid request: %s", err.Error()))
+	}
+
+	if _, err := m.accountManager.VerifyEmail(reqCtx, request.Token); err != nil {
+		if errors.Is(err, users.ErrInvalidToken) {
+			return c.String(400, err.Error())
+		}
+		return c.String(500, fmt.Sprintf("cannot verify the email: %s", err.Error()))
+	}
+
+	return c.String(200, "ok")
+}
+
+// ForgotPassword sends the password reset link to the email of the user,
+// the response does not tell if the user exists (the email is sent in the background, failures are logged)
+func (m *mux) ForgotPassword(c echo.Context) error {
+	var request forgotRequest
+	if err := c.Bind(&request); err != nil {
+		return c.String(400, fmt.Sprintf("cannot bind input data: %s", err.Error()))
+	}
+	if err := c.Validate(request); err != nil {
+		return c.String(400, fmt.Sprintf("invalid request: %s", err.Error()))
+	}
+
+	// waiting for the mail server would tell existing users from unknown ones
+	go func(name string) {
+		ctx, cancel := context.WithTimeout(context.Background(), forgotPasswordTimeout)
+		defer cancel()
+
+		err := m.accountManager.ForgotPassword(ctx, name)
+		if err != nil && !errors.Is(err, users.ErrUserNotExists) {
+			m.logger.Errorf("cannot send the reset email to %s: %s", name, err)
+		}
+	}(request.Name)
+
+	return c.String(200, "ok")
+}
+
+// ResetPassword sets the new password with the token from the reset email, the user is logged out
+func (m *mux) ResetPassword(c echo.Context) error {
+	reqCtx, cancel := context.WithTimeout(c.Request().Context(), time.Duration(60)*time.Second)
+	defer cancel()
+
+	var request resetRequest
+	if err := c.Bind(&request); err != nil {
+		return c.String(400, fmt.Sprintf("cannot bind input data: %s", err.Error()))
+	}
+	if err := c.Validate(request); err != nil {
+		return c.String(400, fmt.Sprintf("invalid request: %s", err.Error()))
+	}
+
+	u, err := m.accountManager.ResetPassword(reqCtx, request.Token, request.Password)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidToken) {
+			return c.String(400, err.Error())
+		}
+		return c.String(500, fmt.Sprintf("cannot reset the password: %s", err.Error()))
+	}
+
+	if err := m.revoke(reqCtx, u.Token); err != nil {
+		return c.String(500, err.Error())
+	}
+
+	return c.String(200, "ok")
+}
+
+// refreshTokenUser returns the owner of the refresh token (with http code of the error)
+func (m *mux) refreshTokenUser(ctx context.Context, refreshToken string) (users.User, int, error) {
+	userID, err := m.jwt.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return users.User{}, 403, fmt.Errorf("invalid refresh token: %w", err)
+	}
+
+	u, err := m.userAdapter.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return users.User{}, 404, errors.New("not exists")
+		}
+		return users.User{}, 500, fmt.Errorf("cannot find user (internal error): %w", err)
+	}
+
+	if u.RefreshToken == "" || subtle.ConstantTimeCompare([]byte(u.RefreshToken), []byte(refreshToken)) != 1 {
+		return users.User{}, 403, users.ErrRefreshTokenRevoked
+	}
+
+	return u, 200, nil
+}
+
+// revoke revokes the access token till it expires, invalid and expired tokens are rejected anyway
+func (m *mux) revoke(ctx context.Context, token string) error {
+	claims, err := m.jwt.ValidateToken(token)
+	if err != nil {
+		return nil // nolint:nilerr // nothing to revoke
+	}
+
+	revoked := users.RevokedToken{TokenID: claims.Id, ExpiresAt: time.Unix(claims.ExpiresAt, 0)}
+	if err := m.revocationAdapter.Revoke(ctx, revoked); err != nil {
+		return fmt.Errorf("cannot revoke the access token: %w", err)
+	}
+	return nil
+}
@@ -11,8 +11,26 @@ type logInRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type verifyRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type forgotRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type resetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
 type authResponse struct {
-	ID           string `json:"id"`
-	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token"`
+	ID            string `json:"id"`
+	Token         string `json:"token"`
+	RefreshToken  string `json:"refresh_token"`
+	EmailVerified bool   `json:"email_verified"`
 }
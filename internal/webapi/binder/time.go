@@ -0,0 +1,19 @@
+package binder
+
+import (
+	"fmt"
+	"time"
+)
+
+// OptionalTime parses RFC3339 time (from query params), returns nil for empty string
+func OptionalTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil // nolint:nilnil // no time is not an error
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse time: %w", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+	"pokergo/internal/clock"
+	"pokergo/internal/game"
+	"pokergo/internal/webapi/binder"
+	"pokergo/pkg/id"
+)
+
+// clockPushInterval is how often the clock state is pushed to WebSocket clients
+const clockPushInterval = time.Second
+
+// StartClock starts (or restarts) the blind clock of the tournament
+func (m *mux) StartClock(c echo.Context) error {
+	data, bindErr := binder.BindRequest[startClockRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	g, err := m.clockGame(data, data.Request.GameID, game.ActionModify)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	levels := make([]clock.Level, 0, len(data.Request.Levels))
+	for _, l := range data.Request.Levels {
+		levels = append(levels, l.level())
+	}
+
+	clk, err := m.clockManager.Start(g.ID, levels)
+	if err != nil {
+		return c.String(clockErrCode(err), fmt.Sprintf("cannot start the clock: %s", err.Error()))
+	}
+
+	return c.JSON(200, clk.State())
+}
+
+// PauseClock stops the countdown of the blind clock
+func (m *mux) PauseClock(c echo.Context) error {
+	return m.controlClock(c, (*clock.Clock).Pause)
+}
+
+// ResumeClock continues the countdown of the blind clock
+func (m *mux) ResumeClock(c echo.Context) error {
+	return m.controlClock(c, (*clock.Clock).Resume)
+}
+
+// SkipClock moves the blind clock to the next level
+func (m *mux) SkipClock(c echo.Context) error {
+	return m.controlClock(c, (*clock.Clock).Skip)
+}
+
+// StopClock stops the blind clock, WebSocket clients of the clock are disconnected
+func (m *mux) StopClock(c echo.Context) error {
+	data, bindErr := binder.BindRequest[clockRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	g, err := m.clockGame(data, data.Request.GameID, game.ActionModify)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	if _, err := m.clockManager.Get(g.ID); err != nil {
+		return c.String(clockErrCode(err), fmt.Sprintf("cannot get the clock: %s", err.Error()))
+	}
+	m.clockManager.Stop(g.ID)
+
+	return c.String(200, "ok")
+}
+
+func (m *mux) controlClock(c echo.Context, control func(*clock.Clock) error) error {
+	data, bindErr := binder.BindRequest[clockRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	g, err := m.clockGame(data, data.Request.GameID, game.ActionModify)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	clk, err := m.clockManager.Get(g.ID)
+	if err != nil {
+		return c.String(clockErrCode(err), fmt.Sprintf("cannot get the clock: %s", err.Error()))
+	}
+	if err := control(clk); err != nil {
+		return c.String(clockErrCode(err), fmt.Sprintf("cannot control the clock: %s", err.Error()))
+	}
+
+	return c.JSON(200, clk.State())
+}
+
+// Clock returns the state of the blind clock
+// QueryParams:
+//	game_id = string, required
+func (m *mux) Clock(c echo.Context) error {
+	data, bindErr := binder.BindRequest[clockQueryRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	g, err := m.clockGame(data, data.Request.GameID, game.ActionView)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	clk, err := m.clockManager.Get(g.ID)
+	if err != nil {
+		return c.String(clockErrCode(err), fmt.Sprintf("cannot get the clock: %s", err.Error()))
+	}
+
+	return c.JSON(200, clk.State())
+}
+
+// ClockSocket pushes the state of the blind clock over WebSocket
+// every clockPushInterval and whenever the clock is controlled.
+// The socket is closed when the clock is stopped.
+// QueryParams:
+//	game_id = string, required
+func (m *mux) ClockSocket(c echo.Context) error {
+	data, bindErr := binder.BindRequest[clockQueryRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel() // the socket uses the request context (data.Context times out)
+
+	g, err := m.clockGame(data, data.Request.GameID, game.ActionView)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+	if _, err := m.clockManager.Get(g.ID); err != nil {
+		return c.String(clockErrCode(err), fmt.Sprintf("cannot get the clock: %s", err.Error()))
+	}
+
+	// the origin is not checked, clients are authenticated with the jwt token (not cookies)
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		m.pushClock(c.Request().Context(), ws, g.ID)
+	}}
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// pushClock sends clock states until the client disconnects or the clock is stopped
+func (m *mux) pushClock(ctx context.Context, ws *websocket.Conn, gameID id.ID) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// clients do not send anything, reading detects the closed connection
+	go func() {
+		defer cancel()
+		var ignored string
+		for {
+			if err := websocket.Message.Receive(ws, &ignored); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(clockPushInterval)
+	defer ticker.Stop()
+
+	for {
+		clk, err := m.clockManager.Get(gameID)
+		if err != nil {
+			return
+		}
+		changes, unsubscribe := clk.Subscribe()
+
+		if err := websocket.JSON.Send(ws, clk.State()); err != nil {
+			unsubscribe()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+		unsubscribe()
+	}
+}
+
+// clockGame returns the tournament the caller can perform the action on
+func (m *mux) clockGame(data binder.BaseContext, gameID string, action game.Action) (*game.Game, error) {
+	gID, err := id.FromString(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid game id", game.ErrGameNotExists)
+	}
+
+	g, err := m.gameManager.GetGame(data.Context(), data.UserID(), gID, action)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // errCode needs the original error
+	}
+	if g.Type != game.TypeTournament {
+		return nil, fmt.Errorf("%w: only tournaments have clocks", game.ErrWrongGameType)
+	}
+
+	return g, nil
+}
+
+// clockErrCode returns http code for errors returned by clock.Clock and clock.Manager methods
+func clockErrCode(err error) int {
+	switch {
+	case errors.Is(err, clock.ErrClockNotExists):
+		return 404
+	case errors.Is(err, clock.ErrPaused), errors.Is(err, clock.ErrRunning), errors.Is(err, clock.ErrLastLevel):
+		return 409
+	case errors.Is(err, clock.ErrNoLevels), errors.Is(err, clock.ErrInvalidLevel):
+		return 400
+	default:
+		return 500
+	}
+}
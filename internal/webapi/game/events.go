@@ -0,0 +1,105 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"pokergo/internal/game"
+	"pokergo/internal/webapi/binder"
+	"pokergo/pkg/id"
+)
+
+// keepAliveInterval is how often a comment is sent to idle Server-Sent Events clients
+const keepAliveInterval = 15 * time.Second
+
+// Events streams changes of the game as Server-Sent Events, the event id is the sequence number of the change.
+// Reconnecting clients get changes they have missed (after Last-Event-ID header or lastEventID param).
+// QueryParams:
+//	game_id = string, required
+//	lastEventID = int, default 0 (all the changes)
+func (m *mux) Events(c echo.Context) error {
+	data, bindErr := binder.BindRequest[eventsRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	gameID, err := id.FromString(data.Request.GameID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid game id: %s", err))
+	}
+
+	lastSeq := data.Request.LastEventID
+	if header := c.Request().Header.Get("Last-Event-ID"); header != "" {
+		if lastSeq, err = strconv.ParseInt(header, 10, 64); err != nil {
+			return c.String(400, fmt.Sprintf("invalid Last-Event-ID: %s", err))
+		}
+	}
+
+	sub, err := m.gameManager.Subscribe(data.Context(), data.UserID(), gameID, lastSeq)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot subscribe: %s", err.Error()))
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(200)
+
+	for _, e := range sub.Backlog {
+		if err := writeEvent(res, e); err != nil {
+			return nil
+		}
+		lastSeq = e.Seq
+	}
+	res.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	// the stream lives longer than data.Context, so the request context is used
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		case e, ok := <-sub.Events:
+			if !ok {
+				// too slow, the client reconnects with Last-Event-ID
+				return nil
+			}
+			if e.Seq <= lastSeq {
+				continue
+			}
+			// permissions are checked on every change, the caller may have been removed from the organization
+			if _, err := m.gameManager.GetGame(ctx, data.UserID(), gameID, game.ActionView); err != nil {
+				return nil
+			}
+			if err := writeEvent(res, e); err != nil {
+				return nil
+			}
+			lastSeq = e.Seq
+		}
+		res.Flush()
+	}
+}
+
+// writeEvent writes the change of the game in the Server-Sent Events format
+func writeEvent(res *echo.Response, e game.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot marshal event: %w", err)
+	}
+
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, payload)
+	return err // nolint:wrapcheck // the client is gone
+}
@@ -1,20 +1,26 @@
 package game
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"pokergo/internal/clock"
 	"pokergo/internal/game"
 	"pokergo/internal/webapi/binder"
 	"pokergo/pkg/id"
+	"pokergo/pkg/iif"
+	"pokergo/pkg/pointers"
 )
 
 type mux struct {
-	gameManager game.Manager
+	gameManager  game.Manager
+	clockManager clock.Manager
 }
 
-func NewMux(gameManager game.Manager) *mux {
-	return &mux{gameManager}
+func NewMux(gameManager game.Manager, clockManager clock.Manager) *mux {
+	return &mux{gameManager, clockManager}
 }
 
 func (m *mux) Route(g *echo.Group) {
@@ -23,6 +29,36 @@ func (m *mux) Route(g *echo.Group) {
 	g.POST("/setFinishStack", m.SetFinishStack)
 	g.POST("/reBuyIn", m.ReBuyIn)
 	g.POST("/reBuyInFromPlayer", m.ReBuyInFromPlayer)
+	g.POST("/addExpense", m.AddExpense)
+	g.POST("/setRake", m.SetRake)
+	g.GET("/settlement", m.Settlement)
+	g.POST("/close", m.Close)
+	g.POST("/reopen", m.Reopen)
+	g.POST("/cancel", m.Cancel)
+	g.POST("/settle", m.Settle)
+	g.POST("/undo", m.Undo)
+	g.GET("/history", m.History)
+	g.POST("/setRole", m.SetRole)
+	g.GET("/list", m.ListGames)
+	g.POST("/createTournament", m.CreateTournament)
+	g.POST("/register", m.Register)
+	g.POST("/rebuy", m.Rebuy)
+	g.POST("/addOn", m.AddOn)
+	g.POST("/eliminate", m.Eliminate)
+	g.GET("/prizes", m.Prizes)
+	g.POST("/clock/start", m.StartClock)
+	g.POST("/clock/pause", m.PauseClock)
+	g.POST("/clock/resume", m.ResumeClock)
+	g.POST("/clock/skip", m.SkipClock)
+	g.POST("/clock/stop", m.StopClock)
+	g.GET("/clock", m.Clock)
+	g.GET("/clock/ws", m.ClockSocket)
+	g.GET("/events", m.Events)
+}
+
+// RouteInternal registers routes served on the internal address only (not exposed publicly)
+func (m *mux) RouteInternal(g *echo.Group) {
+	g.GET("/cacheStats", m.CacheStats)
 }
 
 // CreateGame just creates a game for a specific user.
@@ -49,7 +85,7 @@ func (m *mux) AppendPlayer(c echo.Context) error {
 	}
 	defer data.Cancel()
 
-	return m.performOnGame(data, data.Request.GameID, func(g *game.Game) (bool, int, string) {
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
 		// No need to verify if requester has the right to the organization - manager do the job.
 		isAnonymous := data.Request.UserID == nil
 		var i *id.ID
@@ -61,9 +97,17 @@ func (m *mux) AppendPlayer(c echo.Context) error {
 			}
 		}
 
-		fErr := g.AppendPlayer(data.Context(), i, data.Request.UserName, *data.Request.StartStack)
+		startStack, ok := g.DefaultBuyIn()
+		if data.Request.StartStack != nil {
+			startStack, ok = *data.Request.StartStack, true
+		}
+		if !ok {
+			return false, 400, "start_stack is required: the organization has no default buy-in"
+		}
+
+		fErr := g.AppendPlayer(data.Context(), data.UserID(), i, data.Request.UserName, startStack)
 		if fErr != nil {
-			return false, 500, fmt.Sprintf("cannot add the player: %s", fErr.Error())
+			return false, errCode(fErr), fmt.Sprintf("cannot add the player: %s", fErr.Error())
 		}
 
 		return true, 200, "ok"
@@ -77,9 +121,9 @@ func (m *mux) SetFinishStack(c echo.Context) error {
 	}
 	defer data.Cancel()
 
-	return m.performOnGame(data, data.Request.GameID, func(g *game.Game) (bool, int, string) {
-		if fErr := g.SetFinishStack(data.Request.UserName, *data.Request.FinishStack); fErr != nil {
-			return false, 500, fmt.Sprintf("cannot set finish stack: %s", fErr.Error())
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		if fErr := g.SetFinishStack(data.UserID(), data.Request.UserName, *data.Request.FinishStack); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("cannot set finish stack: %s", fErr.Error())
 		}
 
 		return true, 200, "ok"
@@ -93,9 +137,9 @@ func (m *mux) ReBuyIn(c echo.Context) error {
 	}
 	defer data.Cancel()
 
-	return m.performOnGame(data, data.Request.GameID, func(g *game.Game) (bool, int, string) {
-		if fErr := g.ReBuyIn(data.Request.UserName, data.Request.BuyIn); fErr != nil {
-			return false, 500, fmt.Sprintf("error on rebuy-in: %s", fErr.Error())
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		if fErr := g.ReBuyIn(data.UserID(), data.Request.UserName, data.Request.BuyIn); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("error on rebuy-in: %s", fErr.Error())
 		}
 
 		return true, 200, "ok"
@@ -109,49 +153,484 @@ func (m *mux) ReBuyInFromPlayer(c echo.Context) error {
 	}
 	defer data.Cancel()
 
-	return m.performOnGame(data, data.Request.GameID, func(g *game.Game) (bool, int, string) {
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
 		if fErr := g.ReBuyInFromPlayer(
+			data.UserID(),
 			data.Request.UserName,
 			data.Request.FromName,
 			data.Request.BuyIn,
 		); fErr != nil {
-			return false, 500, fmt.Sprintf("error on rebuy-in: %s", fErr.Error())
+			return false, errCode(fErr), fmt.Sprintf("error on rebuy-in: %s", fErr.Error())
+		}
+
+		return true, 200, "ok"
+	})
+}
+
+// AddExpense records a shared expense of the game (pizza, a room fee...) paid by a player,
+// it is settled together with chips
+func (m *mux) AddExpense(c echo.Context) error {
+	data, bindErr := binder.BindRequest[addExpenseRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		if fErr := g.AddExpense(data.UserID(), data.Request.expense()); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("cannot add the expense: %s", fErr.Error())
+		}
+
+		return true, 200, "ok"
+	})
+}
+
+// SetRake sets the rake of the cash game kept by a player (0 removes the rake)
+func (m *mux) SetRake(c echo.Context) error {
+	data, bindErr := binder.BindRequest[setRakeRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		amount := g.RakeDue()
+		if data.Request.Amount != nil {
+			amount = *data.Request.Amount
+		}
+
+		if fErr := g.SetRake(data.UserID(), amount, data.Request.CollectedBy); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("cannot set the rake: %s", fErr.Error())
+		}
+
+		return true, 200, "ok"
+	})
+}
+
+// Close finishes the game (the game must be verified), closed game cannot be changed.
+// The clock of the tournament is stopped.
+func (m *mux) Close(c echo.Context) error {
+	return m.endGame(c, (*game.Game).Close)
+}
+
+// Reopen allows changing the closed game again
+func (m *mux) Reopen(c echo.Context) error {
+	return m.changeStatus(c, game.ActionModify, (*game.Game).Reopen)
+}
+
+// Cancel cancels the game, the clock of the tournament is stopped
+func (m *mux) Cancel(c echo.Context) error {
+	return m.endGame(c, (*game.Game).Cancel)
+}
+
+// Settle marks the closed game as settled
+func (m *mux) Settle(c echo.Context) error {
+	return m.changeStatus(c, game.ActionSettle, (*game.Game).Settle)
+}
+
+// endGame changes the status of the game and stops its clock once the change is committed
+func (m *mux) endGame(c echo.Context, change func(*game.Game, id.ID) error) error {
+	var ended *game.Game
+	err := m.changeStatus(c, game.ActionModify, func(g *game.Game, uID id.ID) error {
+		ended = g
+		return change(g, uID)
+	})
+	if ended != nil && c.Response().Status == 200 {
+		m.clockManager.Stop(ended.ID)
+	}
+	return err
+}
+
+func (m *mux) changeStatus(c echo.Context, action game.Action, change func(*game.Game, id.ID) error) error {
+	data, bindErr := binder.BindRequest[gameRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	return m.performOnGame(data, data.Request.gameRef, action, func(g *game.Game) (bool, int, string) {
+		if fErr := change(g, data.UserID()); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("cannot change the game state: %s", fErr.Error())
+		}
+
+		return true, 200, "ok"
+	})
+}
+
+// Undo reverts the last change made to players
+func (m *mux) Undo(c echo.Context) error {
+	data, bindErr := binder.BindRequest[gameRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		e, fErr := g.Undo(data.UserID())
+		if fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("cannot undo: %s", fErr.Error())
+		}
+
+		return true, 200, fmt.Sprintf("reverted: %s", e.Type)
+	})
+}
+
+// History returns the timeline of the game (all the changes and who made them)
+// QueryParams:
+//	game_id = string, required
+func (m *mux) History(c echo.Context) error {
+	data, bindErr := binder.BindRequest[historyRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	gameID, err := id.FromString(data.Request.GameID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid game id: %s", err))
+	}
+
+	g, err := m.gameManager.GetGame(data.Context(), data.UserID(), gameID, game.ActionView)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	history, err := g.History(data.Context())
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot get the history: %s", err.Error()))
+	}
+
+	setVersionHeader(c, g)
+	return c.JSON(200, historyResponse{Version: g.Version, Events: history})
+}
+
+// Settlement returns a list of transfers which settles the finished game.
+// QueryParams:
+//	game_id = string, required
+func (m *mux) Settlement(c echo.Context) error {
+	data, bindErr := binder.BindRequest[settlementRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	gameID, err := id.FromString(data.Request.GameID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid game id: %s", err))
+	}
+
+	g, err := m.gameManager.GetGame(data.Context(), data.UserID(), gameID, game.ActionView)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	transfers, err := g.Settlement()
+	if err != nil {
+		return c.String(400, fmt.Sprintf("cannot settle the game: %s", err.Error()))
+	}
+
+	return c.JSON(200, settlementResponse{Transfers: transfers})
+}
+
+// ListGames returns games of organizations the user belongs to
+// QueryParams:
+//	org = string, organization name, default empty (all user organizations)
+//	from, to = RFC3339 time, game start time range, default empty
+//	status = string, default empty
+//	player = string, player name, default empty
+//	lastDocID = string, default empty (returns from the newest game)
+//	no = int, default 20, min 5, max 40
+func (m *mux) ListGames(c echo.Context) error {
+	data, bindErr := binder.BindRequest[listGamesRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	lastItemID, err := id.FromString(iif.EmptyIfNil(data.Request.LastDocID))
+	if err != nil {
+		return c.String(400, "unparseable last item id")
+	}
+
+	from, err := binder.OptionalTime(data.Request.From)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid from: %s", err))
+	}
+	to, err := binder.OptionalTime(data.Request.To)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid to: %s", err))
+	}
+
+	filter := game.ListFilter{
+		From:       from,
+		To:         to,
+		PlayerName: data.Request.Player,
+	}
+	if data.Request.Status != "" {
+		filter.Status = pointers.Pointer(game.Status(data.Request.Status))
+	}
+
+	games, err := m.gameManager.ListGames(data.Context(), data.UserID(), data.Request.Org, filter,
+		lastItemID, iif.IfElse(data.Request.NO == 0, 20, data.Request.NO))
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot list games: %s", err.Error()))
+	}
+
+	res := make([]gameListItem, 0, len(games))
+	for _, g := range games {
+		item := gameListItem{
+			ID:           g.ID.Hex(),
+			Organization: g.Organization.Hex(),
+			Organizer:    g.Organizer.Hex(),
+			Start:        g.Start,
+			End:          g.End,
+			Status:       iif.IfElse(g.Status == "", game.StatusOpen, g.Status),
+			Type:         iif.IfElse(g.Type == "", game.TypeCash, g.Type),
+			Version:      g.Version,
+		}
+		for _, p := range g.Players {
+			item.Players = append(item.Players, p.UserName)
+		}
+		res = append(res, item)
+	}
+
+	return c.JSON(200, listGamesResponse{Games: res})
+}
+
+// CreateTournament creates a tournament without players, see game.Tournament for the configuration.
+// Rebuy and add-on periods are in minutes from the tournament start.
+func (m *mux) CreateTournament(c echo.Context) error {
+	data, bindErr := binder.BindRequest[createTournamentRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	req := data.Request
+	g, err := m.gameManager.CreateTournament(data.Context(), data.UserID(), req.Org, game.Tournament{
+		BuyIn:       req.BuyIn,
+		Fee:         req.Fee,
+		Rebuy:       req.Rebuy.period(),
+		RebuyAmount: req.RebuyAmount,
+		MaxRebuys:   req.MaxRebuys,
+		AddOn:       req.AddOn.period(),
+		AddOnAmount: req.AddOnAmount,
+		Payouts:     game.Payouts{Kind: game.PayoutKind(req.PayoutKind), Places: req.Payouts},
+	})
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot create a new tournament: %s", err.Error()))
+	}
+
+	return c.JSON(200, createGameResponse{g.ID.Hex()})
+}
+
+// Register registers a player in the tournament (the buy-in and the fee are taken from the configuration)
+func (m *mux) Register(c echo.Context) error {
+	data, bindErr := binder.BindRequest[registerRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		var uID *id.ID
+		if data.Request.UserID != nil {
+			ii, fErr := id.FromString(*data.Request.UserID)
+			if fErr != nil {
+				return false, 400, fmt.Sprintf("invalid user id: %s", fErr.Error())
+			}
+			uID = &ii
+		}
+
+		if fErr := g.Register(data.Context(), data.UserID(), uID, data.Request.UserName); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("cannot register the player: %s", fErr.Error())
 		}
 
 		return true, 200, "ok"
 	})
 }
 
+// Rebuy buys the tournament player in again (in the rebuy period only)
+func (m *mux) Rebuy(c echo.Context) error {
+	return m.changePlayer(c, "cannot rebuy", (*game.Game).Rebuy)
+}
+
+// AddOn buys the add-on for the tournament player (in the add-on period only)
+func (m *mux) AddOn(c echo.Context) error {
+	return m.changePlayer(c, "cannot buy the add-on", (*game.Game).AddOn)
+}
+
+// Eliminate records the tournament player has busted out
+func (m *mux) Eliminate(c echo.Context) error {
+	return m.changePlayer(c, "cannot eliminate the player", (*game.Game).Eliminate)
+}
+
+func (m *mux) changePlayer(c echo.Context, errMsg string, change func(*game.Game, id.ID, string) error) error {
+	data, bindErr := binder.BindRequest[tournamentPlayerRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	return m.performOnGame(data, data.Request.gameRef, game.ActionModify, func(g *game.Game) (bool, int, string) {
+		if fErr := change(g, data.UserID(), data.Request.UserName); fErr != nil {
+			return false, errCode(fErr), fmt.Sprintf("%s: %s", errMsg, fErr.Error())
+		}
+
+		return true, 200, "ok"
+	})
+}
+
+// Prizes returns prizes of the decided tournament
+// QueryParams:
+//	game_id = string, required
+func (m *mux) Prizes(c echo.Context) error {
+	data, bindErr := binder.BindRequest[prizesRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	gameID, err := id.FromString(data.Request.GameID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid game id: %s", err))
+	}
+
+	g, err := m.gameManager.GetGame(data.Context(), data.UserID(), gameID, game.ActionView)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
+	}
+
+	prizes, err := g.Prizes()
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot compute prizes: %s", err.Error()))
+	}
+
+	setVersionHeader(c, g)
+	return c.JSON(200, prizesResponse{PrizePool: g.PrizePool(), Prizes: prizes})
+}
+
+// SetRole gives a role in the game to a member of the organization (organizers only)
+func (m *mux) SetRole(c echo.Context) error {
+	data, bindErr := binder.BindRequest[setRoleRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	gameID, err := id.FromString(data.Request.GameID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid game id: %s", err))
+	}
+	userID, err := id.FromString(data.Request.UserID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid user id: %s", err))
+	}
+
+	err = m.gameManager.SetRole(data.Context(), data.UserID(), gameID, userID, game.Role(data.Request.Role))
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot set the role: %s", err.Error()))
+	}
+
+	return c.String(200, "ok")
+}
+
+// CacheStats returns metrics of the games cache
+func (m *mux) CacheStats(c echo.Context) error {
+	stats := m.gameManager.CacheStats()
+	return c.JSON(200, cacheStatsResponse{
+		CacheStats: stats,
+		HitRate:    stats.HitRate(),
+	})
+}
+
+// errNotPerformed is returned by the change when performOnGame responds with the prepared message
+var errNotPerformed = errors.New("the action was not performed")
+
 // performOnGame makes direct write on data.Echo
 // this should be last call of the function
 //
+// if version is set and the game is in another version, the request is rejected with 409
+// (the client works on a stale version of the game)
+//
 // f should return indicator if the call was ok (if so, the commit is done)
 // return code and then string
 func (m *mux) performOnGame(
 	binder binder.BaseContext,
-	game string,
+	ref gameRef,
+	action game.Action,
 	f func(*game.Game) (bool, int, string),
 ) error {
 
-	gameID, err := id.FromString(game)
+	gameID, err := id.FromString(ref.GameID)
 	if err != nil {
 		return binder.Echo().String(400, fmt.Sprintf("invalid game id: %s", err))
 	}
 
-	g, err := m.gameManager.GetGame(binder.Context(), binder.UserID(), gameID)
+	g, err := m.gameManager.GetGame(binder.Context(), binder.UserID(), gameID, action)
 	if err != nil {
-		return binder.Echo().String(500, fmt.Sprintf("cannot get the game: %s", err.Error()))
+		return binder.Echo().String(errCode(err), fmt.Sprintf("cannot get the game: %s", err.Error()))
 	}
 
-	ok, code, msg := f(g)
-	if !ok {
+	// the version is checked and the change is committed with other changes of the game on hold
+	var code int
+	var msg string
+	err = m.gameManager.Perform(binder.Context(), g, func(g *game.Game) error {
+		if version := ref.Version; version != nil && *version > g.Version {
+			// the cached game may be older than the one the client has seen
+			if err := m.gameManager.Refresh(binder.Context(), g); err != nil {
+				code, msg = errCode(err), fmt.Sprintf("cannot refresh the game: %s", err.Error())
+				return errNotPerformed
+			}
+		}
+		if version := ref.Version; version != nil && *version != g.Version {
+			code, msg = 409, fmt.Sprintf("stale game version %d, current version: %d", *version, g.Version)
+			return errNotPerformed
+		}
+
+		var ok bool
+		ok, code, msg = f(g)
+		if !ok {
+			return errNotPerformed
+		}
+		return nil
+	})
+	if errors.Is(err, errNotPerformed) {
 		return binder.Echo().String(code, msg)
 	}
-
-	err = m.gameManager.Commit(binder.Context(), binder.UserID(), gameID)
 	if err != nil {
-		return binder.Echo().String(500, fmt.Sprintf("cannot commit the state: %s", err.Error()))
+		return binder.Echo().String(errCode(err), fmt.Sprintf("cannot commit the state: %s", err.Error()))
 	}
 
+	setVersionHeader(binder.Echo(), g)
 	return binder.Echo().String(code, msg)
 }
+
+// setVersionHeader informs the client about the current version of the game
+func setVersionHeader(c echo.Context, g *game.Game) {
+	c.Response().Header().Set("X-Game-Version", strconv.FormatInt(g.Version, 10))
+}
+
+// errCode returns http code for errors returned by game.Game and game.Manager methods
+func errCode(err error) int {
+	switch {
+	case errors.Is(err, game.ErrInsufficientPermissions):
+		return 403
+	case errors.Is(err, game.ErrGameNotExists), errors.Is(err, game.ErrOrgNotFound),
+		errors.Is(err, game.ErrUserNotFound):
+		return 404
+	case errors.Is(err, game.ErrGameNotModifiable), errors.Is(err, game.ErrInvalidTransition),
+		errors.Is(err, game.ErrVersionConflict), errors.Is(err, game.ErrPeriodClosed),
+		errors.Is(err, game.ErrTournamentFinished):
+		return 409
+	case errors.Is(err, game.ErrGameNotFinished), errors.Is(err, game.ErrStackInconsistent),
+		errors.Is(err, game.ErrUserExists), errors.Is(err, game.ErrNothingToUndo),
+		errors.Is(err, game.ErrInvalidRole), errors.Is(err, game.ErrWrongGameType),
+		errors.Is(err, game.ErrInvalidTournament), errors.Is(err, game.ErrRebuyLimit),
+		errors.Is(err, game.ErrPlayerEliminated), errors.Is(err, game.ErrTournamentNotFinished),
+		errors.Is(err, game.ErrPayoutExceedsPool), errors.Is(err, game.ErrInvalidExpense):
+		return 400
+	default:
+		return 500
+	}
+}
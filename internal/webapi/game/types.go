@@ -1,5 +1,12 @@
 package game
 
+import (
+	"time"
+
+	"pokergo/internal/clock"
+	"pokergo/internal/game"
+)
+
 type createGameRequest struct {
 	Org string `json:"org" validate:"required"`
 }
@@ -8,28 +15,217 @@ type createGameResponse struct {
 	ID string `json:"id"`
 }
 
+// gameRef points to the changed game
+type gameRef struct {
+	GameID string `json:"game_id" validate:"required,hexadecimal,len=24"`
+	// Version is the version of the game the client has seen (optional),
+	// the change is rejected if the game was changed in the meantime
+	Version *int64 `json:"version" validate:"omitempty,gte=0"`
+}
+
+type gameRequest struct {
+	gameRef
+}
+
 type appendPlayerRequest struct {
-	GameID     string  `json:"game_id" validate:"required,hexadecimal,len=24"`
+	gameRef
 	UserID     *string `json:"user_id" validate:"hexadecimal,len=24"`
 	UserName   string  `json:"user_name" validate:"required"`
-	StartStack *int64  `json:"start_stack" validate:"required"` // ptr to allow 0 value
+	StartStack *int64  `json:"start_stack"` // ptr to allow 0 value, the default buy-in of the organization if nil
 }
 
 type setFinishStack struct {
-	GameID      string `json:"game_id" validate:"required,hexadecimal,len=24"`
+	gameRef
 	UserName    string `json:"user_name" validate:"required"`
 	FinishStack *int64 `json:"finish_stack" validate:"required"` // ptr to allow 0 value
 }
 
 type reBuyIn struct {
-	GameID   string `json:"game_id" validate:"required,hexadecimal,len=24"`
+	gameRef
 	UserName string `json:"user_name" validate:"required"`
 	BuyIn    int64  `json:"buy_in" validate:"required"`
 }
 
 type reBuyInFromPlayer struct {
-	GameID   string `json:"game_id" validate:"required,hexadecimal,len=24"`
+	gameRef
 	UserName string `json:"user_name" validate:"required"`
 	FromName string `json:"from_name" validate:"required"`
 	BuyIn    int64  `json:"buy_in" validate:"required"`
 }
+
+type shareRequest struct {
+	Player string `json:"player" validate:"required"`
+	Weight int64  `json:"weight" validate:"gte=0"`
+}
+
+type addExpenseRequest struct {
+	gameRef
+	Description string `json:"description"`
+	Payer       string `json:"payer" validate:"required"`
+	Amount      int64  `json:"amount" validate:"required,gt=0"`
+	Split       string `json:"split" validate:"required,oneof=equal hours shares"`
+	// Participants share the expense split equally or by hours, all players if empty
+	Participants []string       `json:"participants" validate:"dive,required"`
+	Shares       []shareRequest `json:"shares" validate:"dive"`
+}
+
+func (r addExpenseRequest) expense() game.Expense {
+	x := game.Expense{
+		Description:  r.Description,
+		Payer:        r.Payer,
+		Amount:       r.Amount,
+		Split:        game.SplitRule(r.Split),
+		Participants: r.Participants,
+	}
+	for _, s := range r.Shares {
+		x.Shares = append(x.Shares, game.Share{Player: s.Player, Weight: s.Weight})
+	}
+	return x
+}
+
+type setRakeRequest struct {
+	gameRef
+	// Amount is the rake due by the rake policy of the organization if missing
+	Amount      *int64 `json:"amount" validate:"omitempty,gte=0"` // ptr to allow 0 value
+	CollectedBy string `json:"collected_by"`
+}
+
+// periodRequest is a period in minutes from the tournament start
+type periodRequest struct {
+	From int `json:"from" validate:"gte=0"`
+	To   int `json:"to" validate:"gte=0,gtefield=From"`
+}
+
+func (p periodRequest) period() game.Period {
+	return game.Period{From: time.Duration(p.From) * time.Minute, To: time.Duration(p.To) * time.Minute}
+}
+
+type createTournamentRequest struct {
+	Org         string        `json:"org" validate:"required"`
+	BuyIn       int64         `json:"buy_in" validate:"required,gt=0"`
+	Fee         int64         `json:"fee" validate:"gte=0"`
+	Rebuy       periodRequest `json:"rebuy"`
+	RebuyAmount int64         `json:"rebuy_amount" validate:"gte=0"`
+	MaxRebuys   int           `json:"max_rebuys" validate:"gte=0"`
+	AddOn       periodRequest `json:"add_on"`
+	AddOnAmount int64         `json:"add_on_amount" validate:"gte=0"`
+	PayoutKind  string        `json:"payout_kind" validate:"required,oneof=percentage fixed"`
+	Payouts     []int64       `json:"payouts" validate:"required,min=1,dive,gt=0"`
+}
+
+type registerRequest struct {
+	gameRef
+	UserID   *string `json:"user_id" validate:"omitempty,hexadecimal,len=24"`
+	UserName string  `json:"user_name" validate:"required"`
+}
+
+type tournamentPlayerRequest struct {
+	gameRef
+	UserName string `json:"user_name" validate:"required"`
+}
+
+type prizesRequest struct {
+	GameID string `query:"game_id" validate:"required,hexadecimal,len=24"`
+}
+
+type prizeResponse = game.Prize
+
+type prizesResponse struct {
+	PrizePool int64           `json:"prize_pool"`
+	Prizes    []prizeResponse `json:"prizes"`
+}
+
+type levelRequest struct {
+	SmallBlind int64 `json:"small_blind" validate:"gte=0"`
+	BigBlind   int64 `json:"big_blind" validate:"gte=0"`
+	Ante       int64 `json:"ante" validate:"gte=0"`
+	// Minutes is the duration of the level
+	Minutes int  `json:"minutes" validate:"required,gt=0"`
+	Break   bool `json:"break"`
+}
+
+func (l levelRequest) level() clock.Level {
+	return clock.Level{
+		SmallBlind: l.SmallBlind,
+		BigBlind:   l.BigBlind,
+		Ante:       l.Ante,
+		Duration:   time.Duration(l.Minutes) * time.Minute,
+		Break:      l.Break,
+	}
+}
+
+type startClockRequest struct {
+	GameID string         `json:"game_id" validate:"required,hexadecimal,len=24"`
+	Levels []levelRequest `json:"levels" validate:"required,min=1,dive"`
+}
+
+type clockRequest struct {
+	GameID string `json:"game_id" validate:"required,hexadecimal,len=24"`
+}
+
+type clockQueryRequest struct {
+	GameID string `query:"game_id" validate:"required,hexadecimal,len=24"`
+}
+
+type eventsRequest struct {
+	GameID      string `query:"game_id" validate:"required,hexadecimal,len=24"`
+	LastEventID int64  `query:"lastEventID" validate:"gte=0"`
+}
+
+type setRoleRequest struct {
+	GameID string `json:"game_id" validate:"required,hexadecimal,len=24"`
+	UserID string `json:"user_id" validate:"required,hexadecimal,len=24"`
+	Role   string `json:"role" validate:"required,oneof=organizer banker player viewer"`
+}
+
+type settlementRequest struct {
+	GameID string `query:"game_id" validate:"required,hexadecimal,len=24"`
+}
+
+type transferResponse = game.Transfer
+
+type settlementResponse struct {
+	Transfers []transferResponse `json:"transfers"`
+}
+
+type historyRequest struct {
+	GameID string `query:"game_id" validate:"required,hexadecimal,len=24"`
+}
+
+type historyEntryResponse = game.HistoryEntry
+
+type historyResponse struct {
+	Version int64                  `json:"version"`
+	Events  []historyEntryResponse `json:"events"`
+}
+
+type listGamesRequest struct {
+	Org       string  `query:"org"`
+	From      string  `query:"from"`
+	To        string  `query:"to"`
+	Status    string  `query:"status" validate:"omitempty,oneof=open finishing closed settled cancelled"`
+	Player    string  `query:"player"`
+	LastDocID *string `query:"lastDocID" validate:"omitempty,hexadecimal,len=24"`
+	NO        int     `query:"no" validate:"omitempty,gte=5,lte=40"`
+}
+
+type gameListItem struct {
+	ID           string      `json:"id"`
+	Organization string      `json:"organization"`
+	Organizer    string      `json:"organizer"`
+	Start        time.Time   `json:"start"`
+	End          *time.Time  `json:"end,omitempty"`
+	Status       game.Status `json:"status"`
+	Type         game.Type   `json:"type"`
+	Version      int64       `json:"version"`
+	Players      []string    `json:"players"`
+}
+
+type listGamesResponse struct {
+	Games []gameListItem `json:"games"`
+}
+
+type cacheStatsResponse struct {
+	game.CacheStats
+	HitRate float64 `json:"hit_rate"`
+}
@@ -0,0 +1,222 @@
+package hands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"pokergo/internal/game"
+	"pokergo/internal/hands"
+	"pokergo/internal/org"
+	"pokergo/internal/webapi/binder"
+	"pokergo/pkg/id"
+	"pokergo/pkg/iif"
+	"pokergo/pkg/timer"
+)
+
+type mux struct {
+	handsAdapter hands.Adapter
+	orgAdapter   org.Adapter
+	gameManager  game.Manager
+	timer        timer.Timer
+}
+
+func NewMux(handsAdapter hands.Adapter, orgAdapter org.Adapter, gameManager game.Manager, timer timer.Timer) *mux {
+	return &mux{handsAdapter: handsAdapter, orgAdapter: orgAdapter, gameManager: gameManager, timer: timer}
+}
+
+func (m *mux) Route(g *echo.Group) {
+	g.POST("/record", m.RecordHand)
+	g.GET("/get", m.GetHand)
+	g.GET("/list", m.ListHands)
+	g.GET("/replay", m.Replay)
+}
+
+// RecordHand stores a hand history of the organization (members only).
+// The hand is validated: cards, the order of actions and the pot distribution must be possible.
+func (m *mux) RecordHand(c echo.Context) error {
+	data, bindErr := binder.BindRequest[recordHandRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	h, err := data.Request.hand()
+	if err != nil {
+		return c.String(400, err.Error())
+	}
+	if err := h.Validate(); err != nil {
+		return c.String(400, err.Error())
+	}
+	playedAt, err := binder.OptionalTime(data.Request.PlayedAt)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid played_at: %s", err.Error()))
+	}
+
+	o, err := m.orgAdapter.GetOrgByName(data.Context(), data.Request.Org)
+	if err != nil {
+		if errors.Is(err, org.ErrOrgNotExists) {
+			return c.String(404, "org not exists")
+		}
+		return c.String(500, fmt.Sprintf("cannot find org: %s", err.Error()))
+	}
+	if !o.IsMember(data.UserID()) {
+		return c.String(403, "a user is NOT a member of the organization")
+	}
+
+	if data.Request.GameID != nil {
+		gID, err := id.FromString(*data.Request.GameID)
+		if err != nil {
+			return c.String(400, "unparseable game id")
+		}
+		g, err := m.gameManager.GetGame(data.Context(), data.UserID(), gID, game.ActionView)
+		if err != nil {
+			if errors.Is(err, game.ErrGameNotExists) {
+				return c.String(404, "game not exists")
+			}
+			if errors.Is(err, game.ErrInsufficientPermissions) {
+				return c.String(403, "cannot view the game")
+			}
+			return c.String(500, fmt.Sprintf("cannot get the game: %s", err.Error()))
+		}
+		if g.Organization != o.ID {
+			return c.String(400, "the game belongs to another organization")
+		}
+		h.GameID = &gID
+	}
+
+	h.ID = id.NewID()
+	h.Organization = o.ID
+	h.RecordedBy = data.UserID()
+	h.PlayedAt = m.timer.Now()
+	if playedAt != nil {
+		h.PlayedAt = *playedAt
+	}
+	if err := m.handsAdapter.Save(data.Context(), h); err != nil {
+		return c.String(500, fmt.Sprintf("cannot save the hand: %s", err.Error()))
+	}
+
+	return c.JSON(200, recordHandResponse{ID: h.ID.Hex()})
+}
+
+// GetHand returns the hand history (members of the organization only)
+// QueryParams:
+//	hand_id = string, required
+func (m *mux) GetHand(c echo.Context) error {
+	data, bindErr := binder.BindRequest[handRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	h, err := m.hand(data, data.Request.HandID)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the hand: %s", err.Error()))
+	}
+
+	return c.JSON(200, h)
+}
+
+// ListHands returns hands of the organization (members only)
+// QueryParams:
+//	org = string, required
+//	game_id = string, default empty (hands of all games)
+//	lastDocID = string, default empty (returns from the newest hand)
+//	no = int, default 20, min 5, max 40
+func (m *mux) ListHands(c echo.Context) error {
+	data, bindErr := binder.BindRequest[listHandsRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	lastItemID, err := id.FromString(iif.EmptyIfNil(data.Request.LastDocID))
+	if err != nil {
+		return c.String(400, "unparseable last item id")
+	}
+	var gameID *id.ID
+	if data.Request.GameID != nil {
+		gID, err := id.FromString(*data.Request.GameID)
+		if err != nil {
+			return c.String(400, "unparseable game id")
+		}
+		gameID = &gID
+	}
+
+	o, err := m.orgAdapter.GetOrgByName(data.Context(), data.Request.Org)
+	if err != nil {
+		if errors.Is(err, org.ErrOrgNotExists) {
+			return c.String(404, "org not exists")
+		}
+		return c.String(500, fmt.Sprintf("cannot find org: %s", err.Error()))
+	}
+	if !o.IsMember(data.UserID()) {
+		return c.String(403, "a user is NOT a member of the organization")
+	}
+
+	hs, err := m.handsAdapter.ListHands(data.Context(), o.ID, gameID, lastItemID,
+		iif.IfElse(data.Request.NO == 0, 20, data.Request.NO))
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot list hands: %s", err.Error()))
+	}
+
+	return c.JSON(200, listHandsResponse{Hands: hs})
+}
+
+// Replay returns the hand step by step (the deal, every action, every street and the pot distribution),
+// so it can be animated.
+// QueryParams:
+//	hand_id = string, required
+func (m *mux) Replay(c echo.Context) error {
+	data, bindErr := binder.BindRequest[handRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	h, err := m.hand(data, data.Request.HandID)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the hand: %s", err.Error()))
+	}
+	steps, err := hands.Replay(h)
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot replay the hand: %s", err.Error()))
+	}
+
+	return c.JSON(200, replayResponse{Hand: h, Steps: steps})
+}
+
+// hand returns the hand if the caller is a member of its organization
+func (m *mux) hand(data binder.BaseContext, handID string) (hands.Hand, error) {
+	hID, err := id.FromString(handID)
+	if err != nil {
+		return hands.Hand{}, fmt.Errorf("%w: invalid hand id", hands.ErrHandNotExists)
+	}
+	h, err := m.handsAdapter.FindHandByID(data.Context(), hID)
+	if err != nil {
+		return hands.Hand{}, err // nolint:wrapcheck // errCode needs the original error
+	}
+
+	o, err := m.orgAdapter.GetOrgByID(data.Context(), h.Organization)
+	if err != nil {
+		return hands.Hand{}, fmt.Errorf("cannot find org: %w", err)
+	}
+	if !o.IsMember(data.UserID()) {
+		return hands.Hand{}, errNotMember
+	}
+	return h, nil
+}
+
+var errNotMember = errors.New("a user is NOT a member of the organization")
+
+// errCode returns http code for errors returned by hands.Adapter and mux.hand
+func errCode(err error) int {
+	switch {
+	case errors.Is(err, hands.ErrHandNotExists):
+		return 404
+	case errors.Is(err, errNotMember):
+		return 403
+	default:
+		return 500
+	}
+}
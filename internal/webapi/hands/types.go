@@ -0,0 +1,119 @@
+package hands
+
+import (
+	"fmt"
+
+	"pokergo/internal/hands"
+	"pokergo/pkg/id"
+)
+
+type seatRequest struct {
+	Seat     int     `json:"seat" validate:"gte=0,lt=10"`
+	UserName string  `json:"user_name" validate:"required"`
+	UserID   *string `json:"user_id" validate:"omitempty,hexadecimal,len=24"`
+	Stack    int64   `json:"stack" validate:"required,gt=0"`
+	Cards    string  `json:"cards"`
+}
+
+type actionRequest struct {
+	Street string `json:"street" validate:"required,oneof=preflop flop turn river"`
+	Seat   int    `json:"seat" validate:"gte=0,lt=10"`
+	Type   string `json:"type" validate:"required,oneof=ante small_blind big_blind fold check call bet raise"`
+	Amount int64  `json:"amount" validate:"gte=0"`
+}
+
+type awardRequest struct {
+	Seat   int   `json:"seat" validate:"gte=0,lt=10"`
+	Amount int64 `json:"amount" validate:"gte=0"`
+}
+
+type potRequest struct {
+	Amount  int64          `json:"amount" validate:"gte=0"`
+	Winners []awardRequest `json:"winners" validate:"required,min=1,dive"`
+}
+
+type recordHandRequest struct {
+	Org string `json:"org" validate:"required"`
+	// GameID is the live game the hand was played in (optional)
+	GameID *string `json:"game_id" validate:"omitempty,hexadecimal,len=24"`
+	// PlayedAt is RFC3339 time, now by default
+	PlayedAt   string          `json:"played_at"`
+	Note       string          `json:"note" validate:"max=1000"`
+	SmallBlind int64           `json:"small_blind" validate:"gte=0"`
+	BigBlind   int64           `json:"big_blind" validate:"required,gt=0"`
+	Ante       int64           `json:"ante" validate:"gte=0"`
+	Button     int             `json:"button" validate:"gte=0,lt=10"`
+	Seats      []seatRequest   `json:"seats" validate:"required,min=2,max=10,dive"`
+	Board      string          `json:"board"`
+	Actions    []actionRequest `json:"actions" validate:"required,min=1,dive"`
+	Pots       []potRequest    `json:"pots" validate:"required,min=1,dive"`
+	Returned   []awardRequest  `json:"returned" validate:"dive"`
+	Rake       int64           `json:"rake" validate:"gte=0"`
+}
+
+// hand converts the request to the hand history (ids and the time are set by the caller)
+func (r recordHandRequest) hand() (hands.Hand, error) {
+	h := hands.Hand{
+		Note:       r.Note,
+		SmallBlind: r.SmallBlind,
+		BigBlind:   r.BigBlind,
+		Ante:       r.Ante,
+		Button:     r.Button,
+		Board:      r.Board,
+		Rake:       r.Rake,
+	}
+	for _, s := range r.Seats {
+		seat := hands.Seat{Seat: s.Seat, UserName: s.UserName, Stack: s.Stack, Cards: s.Cards}
+		if s.UserID != nil {
+			uID, err := id.FromString(*s.UserID)
+			if err != nil {
+				return hands.Hand{}, fmt.Errorf("invalid user id: %w", err)
+			}
+			seat.UserID = &uID
+		}
+		h.Seats = append(h.Seats, seat)
+	}
+	for _, a := range r.Actions {
+		h.Actions = append(h.Actions, hands.Action{
+			Street: hands.Street(a.Street),
+			Seat:   a.Seat,
+			Type:   hands.ActionType(a.Type),
+			Amount: a.Amount,
+		})
+	}
+	for _, p := range r.Pots {
+		pot := hands.Pot{Amount: p.Amount}
+		for _, w := range p.Winners {
+			pot.Winners = append(pot.Winners, hands.Award(w))
+		}
+		h.Pots = append(h.Pots, pot)
+	}
+	for _, a := range r.Returned {
+		h.Returned = append(h.Returned, hands.Award(a))
+	}
+	return h, nil
+}
+
+type recordHandResponse struct {
+	ID string `json:"id"`
+}
+
+type handRequest struct {
+	HandID string `query:"hand_id" validate:"required,hexadecimal,len=24"`
+}
+
+type listHandsRequest struct {
+	Org       string  `query:"org" validate:"required"`
+	GameID    *string `query:"game_id" validate:"omitempty,hexadecimal,len=24"`
+	LastDocID *string `query:"lastDocID" validate:"omitempty,hexadecimal,len=24"`
+	NO        int     `query:"no" validate:"omitempty,gte=5,lte=40"`
+}
+
+type listHandsResponse struct {
+	Hands []hands.Hand `json:"hands"`
+}
+
+type replayResponse struct {
+	Hand  hands.Hand   `json:"hand"`
+	Steps []hands.Step `json:"steps"`
+}
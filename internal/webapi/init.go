@@ -1,6 +1,7 @@
 package webapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -15,6 +16,11 @@ type Router interface {
 	Route(g *echo.Group)
 }
 
+// InternalRouter registers routes of the internal server (metrics not meant for users)
+type InternalRouter interface {
+	RouteInternal(g *echo.Group)
+}
+
 type echoValidator struct {
 	validator *validator.Validate
 }
@@ -33,16 +39,26 @@ func GetJWTToken(c echo.Context) (jwt.SignedToken, error) {
 	return jwtToken, nil
 }
 
+// TokenRevocations tells if the access token was revoked (by logging out or refreshing tokens)
+type TokenRevocations interface {
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
 type EchoRouters struct {
-	AuthRouter Router
-	OrgRouter  Router
-	GameRouter Router
-	NewsRouter Router
+	AuthRouter  Router
+	OrgRouter   Router
+	GameRouter  Router
+	NewsRouter  Router
+	StatsRouter Router
+	ToolsRouter Router
+	TableRouter Router
+	HandsRouter Router
 }
 
 func NewEcho(
 	validate *validator.Validate,
 	jwtInstance *jwt.JWT,
+	revocations TokenRevocations,
 	routers EchoRouters,
 	log logger.Logger,
 	debug bool,
@@ -54,6 +70,11 @@ func NewEcho(
 	auth := func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			jwtToken := c.Request().Header.Get("Authorization")
+			isStream := c.IsWebSocket() || c.Request().Header.Get("Accept") == "text/event-stream"
+			if jwtToken == "" && isStream && c.QueryParam("access_token") != "" {
+				// browsers cannot set headers of WebSocket and EventSource requests
+				jwtToken = "Bearer: " + c.QueryParam("access_token")
+			}
 			if jwtToken == "" {
 				return c.String(403, "missing jwt token")
 			}
@@ -66,6 +87,13 @@ func NewEcho(
 			if err != nil {
 				return c.String(403, fmt.Sprintf("invalid token: %s", err.Error()))
 			}
+			revoked, err := revocations.IsRevoked(c.Request().Context(), v.Id)
+			if err != nil {
+				return c.String(500, fmt.Sprintf("cannot check the token: %s", err.Error()))
+			}
+			if revoked {
+				return c.String(403, "invalid token: the token was revoked")
+			}
 			c.Set("user", v)
 
 			return next(c)
@@ -76,11 +104,19 @@ func NewEcho(
 	orgRouter := e.Group("/org", auth)
 	gameRouter := e.Group("/game", auth)
 	newsRouter := e.Group("/news")
+	statsRouter := e.Group("/stats", auth)
+	toolsRouter := e.Group("/tools", auth)
+	tableRouter := e.Group("/table", auth)
+	handsRouter := e.Group("/hands", auth)
 
 	routers.AuthRouter.Route(authRouter)
 	routers.OrgRouter.Route(orgRouter)
 	routers.GameRouter.Route(gameRouter)
 	routers.NewsRouter.Route(newsRouter)
+	routers.StatsRouter.Route(statsRouter)
+	routers.ToolsRouter.Route(toolsRouter)
+	routers.TableRouter.Route(tableRouter)
+	routers.HandsRouter.Route(handsRouter)
 
 	e.GET("health", func(c echo.Context) error {
 		return c.JSON(200, "ok")
@@ -110,3 +146,21 @@ func NewEcho(
 
 	return e
 }
+
+// NewInternalEcho creates the server of internal routes, it must not be exposed publicly
+// (it has no authentication)
+func NewInternalEcho(gameRouter InternalRouter, log logger.Logger) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+
+	gameRouter.RouteInternal(e.Group("/game"))
+
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			logger.MakeEchoLogEntry(log, c).Info("incoming internal request")
+			return next(c)
+		}
+	})
+
+	return e
+}
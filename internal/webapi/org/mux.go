@@ -3,6 +3,7 @@ package org
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"pokergo/internal/org"
@@ -12,18 +13,40 @@ import (
 )
 
 type mux struct {
-	orgAdapter  org.Adapter
-	userAdapter users.Adapter
+	orgAdapter    org.Adapter
+	orgManager    org.Manager
+	inviteManager org.InviteManager
+	userAdapter   users.Adapter
+	ledgerManager org.LedgerManager
 }
 
-func NewMux(orgAdapter org.Adapter, userAdapter users.Adapter) *mux {
-	return &mux{orgAdapter, userAdapter}
+func NewMux(
+	orgAdapter org.Adapter,
+	orgManager org.Manager,
+	inviteManager org.InviteManager,
+	userAdapter users.Adapter,
+	ledgerManager org.LedgerManager,
+) *mux {
+	return &mux{orgAdapter, orgManager, inviteManager, userAdapter, ledgerManager}
 }
 
 func (m *mux) Route(g *echo.Group) {
 	g.POST("/newOrg", m.NewOrg)
 	g.POST("/addToOrg", m.AddToOrg)
+	g.POST("/removeMember", m.RemoveMember)
+	g.POST("/leave", m.Leave)
+	g.POST("/transferOwnership", m.TransferOwnership)
+	g.POST("/setRole", m.SetRole)
+	g.POST("/invite", m.Invite)
+	g.POST("/join", m.Join)
+	g.POST("/requestJoin", m.RequestJoin)
+	g.GET("/joinRequests", m.JoinRequests)
+	g.POST("/reviewJoinRequest", m.ReviewJoinRequest)
 	g.GET("/listOrg", m.ListOrg)
+	g.GET("/settings", m.Settings)
+	g.PUT("/settings", m.UpdateSettings)
+	g.GET("/ledger", m.Ledger)
+	g.POST("/ledger/pay", m.Pay)
 }
 
 func (m *mux) NewOrg(c echo.Context) error {
@@ -44,6 +67,7 @@ func (m *mux) NewOrg(c echo.Context) error {
 	})
 }
 
+// AddToOrg adds the user to the organization (organizers, admins and the owner only)
 func (m *mux) AddToOrg(c echo.Context) error {
 	data, bindErr := binder.BindRequest[addToOrgRequest](c, true)
 	if bindErr != nil {
@@ -51,39 +75,267 @@ func (m *mux) AddToOrg(c echo.Context) error {
 	}
 	defer data.Cancel()
 
-	o, err := m.orgAdapter.GetOrgByName(data.Context(), data.Request.OrgName)
+	o, usr, err := m.orgAndUser(data, data.Request.OrgName, data.Request.Who)
 	if err != nil {
-		if errors.Is(err, org.ErrOrgNotExists) {
-			return c.String(404, "org not exists")
-		}
-		return c.String(500, fmt.Sprintf("cannot find org: %s", err.Error()))
+		return c.String(errCode(err), err.Error())
+	}
+
+	if err := m.orgManager.AddMember(data.Context(), o, data.UserID(), usr.ID); err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot add user to org: %s", err.Error()))
 	}
 
-	usr, err := m.userAdapter.GetUserByName(data.Context(), data.Request.Who)
+	return c.String(200, "ok")
+}
+
+// RemoveMember removes the member from the organization (admins and the owner only, the member must have a lower role)
+func (m *mux) RemoveMember(c echo.Context) error {
+	data, bindErr := binder.BindRequest[memberRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, usr, err := m.orgAndUser(data, data.Request.OrgName, data.Request.Who)
 	if err != nil {
-		if errors.Is(err, users.ErrUserNotExists) {
-			return c.String(404, "user not exists")
-		}
-		return c.String(500, fmt.Sprintf("cannot perform the query: %s", err.Error()))
+		return c.String(errCode(err), err.Error())
 	}
 
-	alreadyPresent := o.IsMember(usr.ID)
-	if alreadyPresent {
-		return c.String(400, "user already is a member of this org")
+	if err := m.orgManager.RemoveMember(data.Context(), o, data.UserID(), usr.ID); err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot remove the member: %s", err.Error()))
 	}
 
-	canAddMember := o.IsMember(data.UserID())
-	if !canAddMember {
-		return c.String(403, "a user is NOT a member of the organization")
+	return c.String(200, "ok")
+}
+
+// Leave removes the caller from the organization (the owner must transfer the ownership first)
+func (m *mux) Leave(c echo.Context) error {
+	data, bindErr := binder.BindRequest[leaveRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
 	}
+	defer data.Cancel()
 
-	if err := m.orgAdapter.AddToOrg(data.Context(), o.ID, usr.ID); err != nil {
-		return c.String(500, "cannot add user to org")
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	if err := m.orgManager.Leave(data.Context(), o, data.UserID()); err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot leave the organization: %s", err.Error()))
 	}
 
 	return c.String(200, "ok")
 }
 
+// TransferOwnership makes the member the owner of the organization (the owner only), the caller becomes an admin
+func (m *mux) TransferOwnership(c echo.Context) error {
+	data, bindErr := binder.BindRequest[memberRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, usr, err := m.orgAndUser(data, data.Request.OrgName, data.Request.Who)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	if err := m.orgManager.TransferOwnership(data.Context(), o, data.UserID(), usr.ID); err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot transfer the ownership: %s", err.Error()))
+	}
+
+	return c.String(200, "ok")
+}
+
+// SetRole gives the member a role (admins and the owner only, both roles of the member must be lower than the caller's)
+func (m *mux) SetRole(c echo.Context) error {
+	data, bindErr := binder.BindRequest[setRoleRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, usr, err := m.orgAndUser(data, data.Request.OrgName, data.Request.Who)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	role := org.Role(data.Request.Role)
+	if err := m.orgManager.SetRole(data.Context(), o, data.UserID(), usr.ID, role); err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot set the role: %s", err.Error()))
+	}
+
+	return c.String(200, "ok")
+}
+
+// Invite creates a signed invite code of the organization (organizers, admins and the owner only)
+func (m *mux) Invite(c echo.Context) error {
+	data, bindErr := binder.BindRequest[inviteRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	validity := time.Duration(data.Request.ValidHours) * time.Hour
+	invite, code, err := m.inviteManager.CreateInvite(data.Context(), o, data.UserID(), validity, data.Request.MaxUses)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot create the invite: %s", err.Error()))
+	}
+
+	return c.JSON(200, inviteResponse{Code: code, ExpiresAt: invite.ExpiresAt, MaxUses: invite.MaxUses})
+}
+
+// Join adds the caller to the organization of the invite code
+func (m *mux) Join(c echo.Context) error {
+	data, bindErr := binder.BindRequest[joinRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.inviteManager.Join(data.Context(), data.Request.Code, data.UserID())
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot join the organization: %s", err.Error()))
+	}
+
+	return c.JSON(200, newOrgResponse{
+		ID:   o.ID.Hex(),
+		Name: o.Name,
+	})
+}
+
+// RequestJoin asks admins of the organization to add the caller
+func (m *mux) RequestJoin(c echo.Context) error {
+	data, bindErr := binder.BindRequest[requestJoinRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.org(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	request, err := m.inviteManager.RequestJoin(data.Context(), o, data.UserID(), data.Request.Message)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot request to join: %s", err.Error()))
+	}
+
+	return c.JSON(200, joinRequestResponse{JoinRequest: request, UserName: data.TokenData().UserName})
+}
+
+// JoinRequests returns pending join requests of the organization (admins and the owner only)
+// QueryParams:
+//	name = string, required (the name of the organization)
+func (m *mux) JoinRequests(c echo.Context) error {
+	data, bindErr := binder.BindRequest[joinRequestsRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	requests, err := m.inviteManager.JoinRequests(data.Context(), o, data.UserID())
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get join requests: %s", err.Error()))
+	}
+
+	ids := make([]id.ID, 0, len(requests))
+	for _, r := range requests {
+		ids = append(ids, r.UserID)
+	}
+	names, err := m.userAdapter.UserDetails(data.Context(), ids)
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot get users details: %s", err.Error()))
+	}
+
+	response := joinRequestsResponse{Requests: []joinRequestResponse{}}
+	for _, r := range requests {
+		response.Requests = append(response.Requests, joinRequestResponse{JoinRequest: r, UserName: names[r.UserID].Username})
+	}
+
+	return c.JSON(200, response)
+}
+
+// ReviewJoinRequest approves (the user becomes a member) or rejects the join request (admins and the owner only)
+func (m *mux) ReviewJoinRequest(c echo.Context) error {
+	data, bindErr := binder.BindRequest[reviewJoinRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	requestID, err := id.FromString(data.Request.RequestID)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid request id: %s", err))
+	}
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	request, err := m.inviteManager.ReviewJoinRequest(data.Context(), o, data.UserID(), requestID, *data.Request.Approve)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot review the join request: %s", err.Error()))
+	}
+
+	return c.JSON(200, request)
+}
+
+// Settings returns settings of the organization (admins and the owner only)
+// QueryParams:
+//	name = string, required (the name of the organization)
+func (m *mux) Settings(c echo.Context) error {
+	data, bindErr := binder.BindRequest[settingsRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	settings, err := m.orgManager.Settings(data.Context(), o, data.UserID())
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get settings: %s", err.Error()))
+	}
+
+	return c.JSON(200, settings)
+}
+
+// UpdateSettings replaces settings of the organization (admins and the owner only)
+func (m *mux) UpdateSettings(c echo.Context) error {
+	data, bindErr := binder.BindRequest[updateSettingsRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	if err := m.orgManager.UpdateSettings(data.Context(), o, data.UserID(), data.Request.Settings); err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot update settings: %s", err.Error()))
+	}
+
+	return c.JSON(200, data.Request.Settings)
+}
+
 func (m *mux) ListOrg(c echo.Context) error {
 	data, bindErr := binder.BindRequest[listUserOrgRequest](c, true)
 	if bindErr != nil {
@@ -106,8 +358,8 @@ func (m *mux) ListOrg(c echo.Context) error {
 		response = append(response, orgResponse{
 			ID:        o.ID.Hex(),
 			Name:      o.Name,
-			Admin:     members[o.ID].Username,
-			Members:   idsAndNames(members),
+			Admin:     members[o.Owner].Username,
+			Members:   membersWithRoles(o, members),
 			CreatedAt: o.CreatedAt,
 		})
 	}
@@ -115,12 +367,132 @@ func (m *mux) ListOrg(c echo.Context) error {
 	return c.JSON(200, listUserOrgResponse{response})
 }
 
-func idsAndNames(input map[id.ID]users.User) []idWithName {
-	var res []idWithName
+// Ledger returns unpaid debts between players from all the verified games of the organization (members only)
+// QueryParams:
+//	name = string, required (the name of the organization)
+func (m *mux) Ledger(c echo.Context) error {
+	data, bindErr := binder.BindRequest[ledgerRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	l, err := m.ledgerManager.Ledger(data.Context(), o.ID)
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot get the ledger: %s", err.Error()))
+	}
+
+	return c.JSON(200, newLedgerResponse(l))
+}
+
+// Pay records a payment (partial or full) of the debt between two players, returns the updated ledger.
+// Only the creditor or the admin of the organization can record the payment.
+func (m *mux) Pay(c echo.Context) error {
+	data, bindErr := binder.BindRequest[payRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.memberOrg(data, data.Request.OrgName)
+	if err != nil {
+		return c.String(errCode(err), err.Error())
+	}
+
+	l, err := m.ledgerManager.Pay(data.Context(), o, data.UserID(), data.TokenData().UserName,
+		data.Request.From, data.Request.To, data.Request.Amount)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot record the payment: %s", err.Error()))
+	}
+
+	return c.JSON(200, newLedgerResponse(l))
+}
+
+// org returns the organization by name
+func (m *mux) org(data binder.BaseContext, name string) (org.Org, error) {
+	o, err := m.orgAdapter.GetOrgByName(data.Context(), name)
+	if err != nil {
+		if errors.Is(err, org.ErrOrgNotExists) {
+			return org.Org{}, errOrgNotExists
+		}
+		return org.Org{}, fmt.Errorf("cannot find org: %w", err)
+	}
+	return o, nil
+}
+
+// memberOrg returns the organization if the caller is its member
+func (m *mux) memberOrg(data binder.BaseContext, name string) (org.Org, error) {
+	o, err := m.org(data, name)
+	if err != nil {
+		return org.Org{}, err
+	}
+	if !o.IsMember(data.UserID()) {
+		return org.Org{}, errNotMember
+	}
+	return o, nil
+}
+
+// orgAndUser returns the organization (the caller must be its member) and the user by name
+func (m *mux) orgAndUser(data binder.BaseContext, orgName, userName string) (org.Org, users.User, error) {
+	o, err := m.memberOrg(data, orgName)
+	if err != nil {
+		return org.Org{}, users.User{}, err
+	}
+
+	usr, err := m.userAdapter.GetUserByName(data.Context(), userName)
+	if err != nil {
+		if errors.Is(err, users.ErrUserNotExists) {
+			return org.Org{}, users.User{}, errUserNotExists
+		}
+		return org.Org{}, users.User{}, fmt.Errorf("cannot perform the query: %w", err)
+	}
+
+	return o, usr, nil
+}
+
+var (
+	errOrgNotExists  = errors.New("org not exists")
+	errUserNotExists = errors.New("user not exists")
+	errNotMember     = errors.New("a user is NOT a member of the organization")
+)
+
+// errCode returns http code for errors returned by org managers and mux.memberOrg
+func errCode(err error) int {
+	switch {
+	case errors.Is(err, errOrgNotExists), errors.Is(err, errUserNotExists), errors.Is(err, org.ErrNotMember),
+		errors.Is(err, org.ErrJoinRequestNotFound):
+		return 404
+	case errors.Is(err, errNotMember), errors.Is(err, org.ErrNotCreditor),
+		errors.Is(err, org.ErrInsufficientPermissions):
+		return 403
+	case errors.Is(err, org.ErrAlreadyMember), errors.Is(err, org.ErrOwnerCannotLeave),
+		errors.Is(err, org.ErrJoinRequestExists), errors.Is(err, org.ErrJoinRequestReviewed),
+		errors.Is(err, org.ErrPaymentConflict), errors.Is(err, org.ErrOrgChanged):
+		return 409
+	case errors.Is(err, org.ErrInviteExpired), errors.Is(err, org.ErrInviteUsedUp):
+		return 410
+	case errors.Is(err, org.ErrInvalidPayment), errors.Is(err, org.ErrOverpayment),
+		errors.Is(err, org.ErrInvalidRole), errors.Is(err, org.ErrInvalidInvite),
+		errors.Is(err, org.ErrInvalidSettings):
+		return 400
+	default:
+		return 500
+	}
+}
+
+func membersWithRoles(o org.Org, input map[id.ID]users.User) []memberResponse {
+	var res []memberResponse
 	for _, v := range input {
-		res = append(res, idWithName{
+		role, _ := o.RoleOf(v.ID)
+		res = append(res, memberResponse{
 			ID:   v.ID.Hex(),
 			Name: v.Username,
+			Role: string(role),
 		})
 	}
 	return res
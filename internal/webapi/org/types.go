@@ -1,6 +1,10 @@
 package org
 
-import "time"
+import (
+	"time"
+
+	"pokergo/internal/org"
+)
 
 type newOrgRequest struct {
 	Name string `json:"name" validate:"required"`
@@ -16,6 +20,72 @@ type addToOrgRequest struct {
 	Who     string `json:"who" validate:"required"`
 }
 
+type memberRequest struct {
+	OrgName string `json:"name" validate:"required"`
+	Who     string `json:"who" validate:"required"`
+}
+
+type leaveRequest struct {
+	OrgName string `json:"name" validate:"required"`
+}
+
+type setRoleRequest struct {
+	OrgName string `json:"name" validate:"required"`
+	Who     string `json:"who" validate:"required"`
+	Role    string `json:"role" validate:"required,oneof=admin organizer member"`
+}
+
+type inviteRequest struct {
+	OrgName string `json:"name" validate:"required"`
+	// ValidHours is the validity of the invite, default 7 days, max 30 days
+	ValidHours int `json:"valid_hours" validate:"gte=0,lte=720"`
+	// MaxUses limits the number of users who can join, 0 - no limit
+	MaxUses int `json:"max_uses" validate:"gte=0"`
+}
+
+type inviteResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	MaxUses   int       `json:"max_uses"`
+}
+
+type joinRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type requestJoinRequest struct {
+	OrgName string `json:"name" validate:"required"`
+	Message string `json:"message" validate:"max=500"`
+}
+
+type joinRequestsRequest struct {
+	OrgName string `query:"name" validate:"required"`
+}
+
+type joinRequestResponse struct {
+	org.JoinRequest
+	UserName string `json:"user_name"`
+}
+
+type joinRequestsResponse struct {
+	Requests []joinRequestResponse `json:"requests"`
+}
+
+type reviewJoinRequest struct {
+	OrgName   string `json:"name" validate:"required"`
+	RequestID string `json:"request_id" validate:"required,hexadecimal,len=24"`
+	Approve   *bool  `json:"approve" validate:"required"` // ptr to allow false value
+}
+
+type settingsRequest struct {
+	OrgName string `query:"name" validate:"required"`
+}
+
+type updateSettingsRequest struct {
+	OrgName string `json:"name" validate:"required"`
+	org.Settings
+}
+
 type listUserOrgRequest struct { // nolint:unused // used as generic param
 	// empty
 }
@@ -24,15 +94,41 @@ type listUserOrgResponse struct {
 	Orgs []orgResponse `json:"orgs"`
 }
 
-type idWithName struct {
+type memberResponse struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	Role string `json:"role"`
 }
 
 type orgResponse struct {
-	ID        string       `json:"id"`
-	Name      string       `json:"name"`
-	Admin     string       `json:"admin"`
-	Members   []idWithName `json:"members"`
-	CreatedAt time.Time    `json:"created_at"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Admin is the name of the owner
+	Admin     string           `json:"admin"`
+	Members   []memberResponse `json:"members"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type ledgerRequest struct {
+	OrgName string `query:"name" validate:"required"`
+}
+
+type payRequest struct {
+	OrgName string `json:"name" validate:"required"`
+	From    string `json:"from" validate:"required"`
+	To      string `json:"to" validate:"required"`
+	Amount  int64  `json:"amount" validate:"required,gt=0"`
+}
+
+type ledgerResponse struct {
+	Debts    []org.Debt    `json:"debts"`
+	Payments []org.Payment `json:"payments"`
+	// Balances are net debts between pairs of players
+	Balances []org.Balance `json:"balances"`
+	// Totals are net balances of players (positive - the player owes money, negative - gets money)
+	Totals map[string]int64 `json:"totals"`
+}
+
+func newLedgerResponse(l org.Ledger) ledgerResponse {
+	return ledgerResponse{Debts: l.Debts, Payments: l.Payments, Balances: l.Balances, Totals: l.Totals()}
 }
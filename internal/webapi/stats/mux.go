@@ -0,0 +1,119 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"pokergo/internal/org"
+	"pokergo/internal/stats"
+	"pokergo/internal/webapi/binder"
+	"pokergo/pkg/id"
+	"pokergo/pkg/iif"
+)
+
+type mux struct {
+	statsAdapter stats.Adapter
+	orgAdapter   org.Adapter
+}
+
+func NewMux(statsAdapter stats.Adapter, orgAdapter org.Adapter) *mux {
+	return &mux{statsAdapter: statsAdapter, orgAdapter: orgAdapter}
+}
+
+func (m *mux) Route(g *echo.Group) {
+	g.GET("/player/:id", m.PlayerStats)
+	g.GET("/org/:name/leaderboard", m.Leaderboard)
+}
+
+// PlayerStats returns statistics of the player from organizations shared with the caller
+// QueryParams:
+//	org = string, default empty (all organizations shared with the player)
+//	from = RFC3339 time, default empty (no limit)
+//	to = RFC3339 time, default empty (no limit)
+func (m *mux) PlayerStats(c echo.Context) error {
+	data, bindErr := binder.BindRequest[playerStatsRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	userID, err := id.FromString(data.Request.UserID)
+	if err != nil {
+		return c.String(400, "unparseable user id")
+	}
+	window, err := parseWindow(data.Request.WindowQuery)
+	if err != nil {
+		return c.String(400, err.Error())
+	}
+
+	callerOrgs, err := m.orgAdapter.ListUserOrg(data.Context(), data.UserID())
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot list organizations: %s", err.Error()))
+	}
+	var shared []id.ID
+	for _, o := range callerOrgs {
+		if o.IsMember(userID) && (data.Request.Org == "" || o.Name == data.Request.Org) {
+			shared = append(shared, o.ID)
+		}
+	}
+	if len(shared) == 0 {
+		return c.String(403, "the player is not a member of your organizations")
+	}
+
+	s, err := m.statsAdapter.PlayerStats(data.Context(), userID, shared, window)
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot compute stats: %s", err.Error()))
+	}
+
+	return c.JSON(200, playerStatsResponse(s))
+}
+
+// Leaderboard returns the best players of the organization (members only)
+// QueryParams:
+//	from = RFC3339 time, default empty (no limit)
+//	to = RFC3339 time, default empty (no limit)
+//	no = int, default 20, min 5, max 40
+func (m *mux) Leaderboard(c echo.Context) error {
+	data, bindErr := binder.BindRequest[leaderboardRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	window, err := parseWindow(data.Request.WindowQuery)
+	if err != nil {
+		return c.String(400, err.Error())
+	}
+
+	o, err := m.orgAdapter.GetOrgByName(data.Context(), data.Request.Org)
+	if err != nil {
+		if errors.Is(err, org.ErrOrgNotExists) {
+			return c.String(404, "org not exists")
+		}
+		return c.String(500, fmt.Sprintf("cannot find org: %s", err.Error()))
+	}
+	if !o.IsMember(data.UserID()) {
+		return c.String(403, "a user is NOT a member of the organization")
+	}
+
+	players, err := m.statsAdapter.Leaderboard(data.Context(), o.ID, window,
+		iif.IfElse(data.Request.NO == 0, 20, data.Request.NO))
+	if err != nil {
+		return c.String(500, fmt.Sprintf("cannot compute leaderboard: %s", err.Error()))
+	}
+
+	return c.JSON(200, leaderboardResponse{Players: players})
+}
+
+func parseWindow(req WindowQuery) (stats.Window, error) {
+	from, err := binder.OptionalTime(req.From)
+	if err != nil {
+		return stats.Window{}, fmt.Errorf("invalid from: %w", err)
+	}
+	to, err := binder.OptionalTime(req.To)
+	if err != nil {
+		return stats.Window{}, fmt.Errorf("invalid to: %w", err)
+	}
+	return stats.Window{From: from, To: to}, nil
+}
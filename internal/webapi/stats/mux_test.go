@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"pokergo/internal/org"
+	"pokergo/internal/stats"
+	"pokergo/pkg/id"
+	"pokergo/pkg/jwt"
+)
+
+// recordingStatsAdapter records windows passed to the stats pipeline
+type recordingStatsAdapter struct {
+	windows []stats.Window
+}
+
+func (r *recordingStatsAdapter) PlayerStats(
+	_ context.Context,
+	userID id.ID,
+	_ []id.ID,
+	window stats.Window,
+) (stats.PlayerStats, error) {
+	r.windows = append(r.windows, window)
+	return stats.PlayerStats{UserID: userID.Hex()}, nil
+}
+
+func (r *recordingStatsAdapter) Leaderboard(
+	_ context.Context,
+	_ id.ID,
+	window stats.Window,
+	_ int,
+) ([]stats.PlayerStats, error) {
+	r.windows = append(r.windows, window)
+	return nil, nil
+}
+
+// singleOrgAdapter serves a single organization, other methods are not used by the mux
+type singleOrgAdapter struct {
+	org.Adapter
+	o org.Org
+}
+
+func (s singleOrgAdapter) GetOrgByName(_ context.Context, name string) (org.Org, error) {
+	if name != s.o.Name {
+		return org.Org{}, org.ErrOrgNotExists
+	}
+	return s.o, nil
+}
+
+func (s singleOrgAdapter) ListUserOrg(_ context.Context, userID id.ID) ([]org.Org, error) {
+	if !s.o.IsMember(userID) {
+		return nil, nil
+	}
+	return []org.Org{s.o}, nil
+}
+
+type testValidator struct {
+	validator *validator.Validate
+}
+
+func (v testValidator) Validate(i any) error {
+	return v.validator.Struct(i) // nolint:wrapcheck // echo validator
+}
+
+func Test_Mux_WindowIsBound(t *testing.T) {
+	caller, player := id.NewID(), id.NewID()
+	o := org.Org{ID: id.NewID(), Name: "org", Owner: caller, Members: []id.ID{caller, player}}
+	recorder := &recordingStatsAdapter{}
+	m := NewMux(recorder, singleOrgAdapter{o: o})
+
+	e := echo.New()
+	e.Validator = testValidator{validator: validator.New()}
+	withCaller := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", jwt.SignedToken{ID: caller.Hex()})
+			return next(c)
+		}
+	}
+	m.Route(e.Group("/stats", withCaller))
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	query := "from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339)
+	for _, path := range []string{"/stats/player/" + player.Hex(), "/stats/org/org/leaderboard"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?"+query, nil))
+		if rec.Code != 200 {
+			t.Fatalf("%s: unexpected response %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	if len(recorder.windows) != 2 {
+		t.Fatalf("expected 2 calls of the stats pipeline, got %d", len(recorder.windows))
+	}
+	for _, w := range recorder.windows {
+		if w.From == nil || !w.From.Equal(from) || w.To == nil || !w.To.Equal(to) {
+			t.Errorf("the window should reach the stats pipeline, got %+v", w)
+		}
+	}
+}
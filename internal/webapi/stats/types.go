@@ -0,0 +1,29 @@
+package stats
+
+import "pokergo/internal/stats"
+
+// WindowQuery is the time window of statistics, it is exported
+// because echo does not bind query params of unexported embedded structs
+type WindowQuery struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+type playerStatsRequest struct {
+	WindowQuery
+	UserID string `param:"id" validate:"required,hexadecimal,len=24"`
+	// Org limits statistics to a single organization
+	Org string `query:"org"`
+}
+
+type playerStatsResponse = stats.PlayerStats
+
+type leaderboardRequest struct {
+	WindowQuery
+	Org string `param:"name" validate:"required"`
+	NO  int    `query:"no" validate:"omitempty,gte=5,lte=40"`
+}
+
+type leaderboardResponse struct {
+	Players []stats.PlayerStats `json:"players"`
+}
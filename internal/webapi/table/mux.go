@@ -0,0 +1,233 @@
+package table
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+	"pokergo/internal/org"
+	"pokergo/internal/table"
+	"pokergo/internal/webapi/binder"
+	"pokergo/pkg/id"
+)
+
+type mux struct {
+	tableManager table.Manager
+	orgAdapter   org.Adapter
+}
+
+func NewMux(tableManager table.Manager, orgAdapter org.Adapter) *mux {
+	return &mux{tableManager: tableManager, orgAdapter: orgAdapter}
+}
+
+func (m *mux) Route(g *echo.Group) {
+	g.POST("/create", m.CreateTable)
+	g.GET("/list", m.ListTables)
+	g.GET("/state", m.State)
+	g.GET("/ws", m.Socket)
+}
+
+// CreateTable opens a new no-limit hold'em table of the organization (members only)
+func (m *mux) CreateTable(c echo.Context) error {
+	data, bindErr := binder.BindRequest[createTableRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.orgAdapter.GetOrgByName(data.Context(), data.Request.Org)
+	if err != nil {
+		if errors.Is(err, org.ErrOrgNotExists) {
+			return c.String(404, "org not exists")
+		}
+		return c.String(500, fmt.Sprintf("cannot find org: %s", err.Error()))
+	}
+	if !o.IsMember(data.UserID()) {
+		return c.String(403, "a user is NOT a member of the organization")
+	}
+
+	room, err := m.tableManager.Create(o.ID, data.Request.Name, data.Request.config())
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot create the table: %s", err.Error()))
+	}
+
+	return c.JSON(200, room.Info())
+}
+
+// ListTables returns tables of the organization (members only)
+// QueryParams:
+//	org = string, required
+func (m *mux) ListTables(c echo.Context) error {
+	data, bindErr := binder.BindRequest[listTablesRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	o, err := m.orgAdapter.GetOrgByName(data.Context(), data.Request.Org)
+	if err != nil {
+		if errors.Is(err, org.ErrOrgNotExists) {
+			return c.String(404, "org not exists")
+		}
+		return c.String(500, fmt.Sprintf("cannot find org: %s", err.Error()))
+	}
+	if !o.IsMember(data.UserID()) {
+		return c.String(403, "a user is NOT a member of the organization")
+	}
+
+	return c.JSON(200, listTablesResponse{Tables: m.tableManager.List(o.ID)})
+}
+
+// State returns the table seen by the caller (hole cards of other players are hidden)
+// QueryParams:
+//	table_id = string, required
+func (m *mux) State(c echo.Context) error {
+	data, bindErr := binder.BindRequest[tableQueryRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	room, err := m.room(data, data.Request.TableID)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the table: %s", err.Error()))
+	}
+
+	return c.JSON(200, room.View(data.UserID()))
+}
+
+// Socket plays at the table over WebSocket.
+// The client sends commands: {"type": "sit", "seat": 0, "buy_in": 1000}, {"type": "leave"}, {"type": "start"}
+// and actions: {"type": "fold|check|call"}, {"type": "bet|raise", "amount": 60} (amount is the total bet in the street).
+// The server sends {"type": "state", "state": {...}} after every change at the table
+// and {"type": "error", "error": "..."} when a command is rejected.
+// Players leave the table (folding the hand) when the socket is closed, the socket is closed when the table is.
+// QueryParams:
+//	table_id = string, required
+func (m *mux) Socket(c echo.Context) error {
+	data, bindErr := binder.BindRequest[tableQueryRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel() // the socket uses the request context (data.Context times out)
+
+	room, err := m.room(data, data.Request.TableID)
+	if err != nil {
+		return c.String(errCode(err), fmt.Sprintf("cannot get the table: %s", err.Error()))
+	}
+
+	// the origin is not checked, clients are authenticated with the jwt token (not cookies)
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		m.play(c.Request().Context(), ws, room, data.UserID(), data.TokenData().UserName)
+	}}
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// play executes commands of the client and pushes states of the table until the client disconnects
+func (m *mux) play(ctx context.Context, ws *websocket.Conn, room *table.Room, userID id.ID, userName string) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes, unsubscribe := room.Subscribe()
+	defer unsubscribe()
+	// players who disconnect leave the table, so they do not stall the hand
+	defer room.Leave(userID) // nolint:errcheck // the player may not be seated
+
+	// commands are read in the background, only this goroutine writes to the socket
+	rejected := make(chan string, 1)
+	go func() {
+		defer cancel()
+		for {
+			var cmd command
+			if err := websocket.JSON.Receive(ws, &cmd); err != nil {
+				return
+			}
+			if err := execute(room, userID, userName, cmd); err != nil {
+				select {
+				case rejected <- err.Error():
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		view := room.View(userID)
+		if err := websocket.JSON.Send(ws, message{Type: messageState, State: &view}); err != nil {
+			return
+		}
+
+		for changed := false; !changed; {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-changes:
+				if !ok { // the table was closed
+					return
+				}
+				changed = true
+			case reason := <-rejected:
+				if err := websocket.JSON.Send(ws, message{Type: messageError, Error: reason}); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// execute performs the command of the client
+func execute(room *table.Room, userID id.ID, userName string, cmd command) error {
+	switch cmd.Type {
+	case commandSit:
+		return room.Sit(userID, userName, cmd.Seat, cmd.BuyIn) // nolint:wrapcheck // sent to the client
+	case commandLeave:
+		_, err := room.Leave(userID)
+		return err // nolint:wrapcheck // sent to the client
+	case commandStart:
+		return room.StartHand(userID) // nolint:wrapcheck // sent to the client
+	}
+	return room.Act(userID, table.Action{Type: table.ActionType(cmd.Type), Amount: cmd.Amount}) // nolint:wrapcheck // sent to the client
+}
+
+// room returns the table if the caller is a member of its organization
+func (m *mux) room(data binder.BaseContext, tableID string) (*table.Room, error) {
+	tID, err := id.FromString(tableID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid table id", table.ErrTableNotExists)
+	}
+	room, err := m.tableManager.Get(tID)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // errCode needs the original error
+	}
+
+	o, err := m.orgAdapter.GetOrgByID(data.Context(), room.Info().OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find org: %w", err)
+	}
+	if !o.IsMember(data.UserID()) {
+		return nil, errNotMember
+	}
+	return room, nil
+}
+
+var errNotMember = errors.New("a user is NOT a member of the organization")
+
+// errCode returns http code for errors returned by table.Manager and mux.room
+func errCode(err error) int {
+	switch {
+	case errors.Is(err, table.ErrTableNotExists):
+		return 404
+	case errors.Is(err, errNotMember):
+		return 403
+	case errors.Is(err, table.ErrInvalidConfig):
+		return 400
+	default:
+		return 500
+	}
+}
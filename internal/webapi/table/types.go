@@ -0,0 +1,82 @@
+package table
+
+import (
+	"time"
+
+	"pokergo/internal/table"
+)
+
+// defaultTurnTimeout is the time to act when the table does not set it
+const defaultTurnTimeout = 30 * time.Second
+
+type createTableRequest struct {
+	Org        string `json:"org" validate:"required"`
+	Name       string `json:"name" validate:"required"`
+	Seats      int    `json:"seats" validate:"required,gte=2,lte=10"`
+	SmallBlind int64  `json:"small_blind" validate:"required,gt=0"`
+	BigBlind   int64  `json:"big_blind" validate:"required,gtefield=SmallBlind"`
+	MinBuyIn   int64  `json:"min_buy_in" validate:"required,gtefield=BigBlind"`
+	MaxBuyIn   int64  `json:"max_buy_in" validate:"required,gtefield=MinBuyIn"`
+	// TurnTimeout is the time to act in seconds
+	TurnTimeout int `json:"turn_timeout" validate:"omitempty,gte=5,lte=600"`
+}
+
+func (r createTableRequest) config() table.Config {
+	turnTimeout := defaultTurnTimeout
+	if r.TurnTimeout > 0 {
+		turnTimeout = time.Duration(r.TurnTimeout) * time.Second
+	}
+	return table.Config{
+		Seats:       r.Seats,
+		SmallBlind:  r.SmallBlind,
+		BigBlind:    r.BigBlind,
+		MinBuyIn:    r.MinBuyIn,
+		MaxBuyIn:    r.MaxBuyIn,
+		TurnTimeout: turnTimeout,
+	}
+}
+
+type listTablesRequest struct {
+	Org string `query:"org" validate:"required"`
+}
+
+type listTablesResponse struct {
+	Tables []table.Info `json:"tables"`
+}
+
+type tableQueryRequest struct {
+	TableID string `query:"table_id" validate:"required,hexadecimal,len=24"`
+}
+
+// commandType is a command sent by the client over WebSocket
+type commandType string
+
+const (
+	commandSit   commandType = "sit"
+	commandLeave commandType = "leave"
+	commandStart commandType = "start"
+	// other commands are actions of the player (fold, check, call, bet, raise)
+)
+
+type command struct {
+	Type commandType `json:"type"`
+	// Seat and BuyIn are used by the sit command
+	Seat  int   `json:"seat"`
+	BuyIn int64 `json:"buy_in"`
+	// Amount is the total bet in the street after a bet or a raise
+	Amount int64 `json:"amount"`
+}
+
+// messageType is a message sent to the client over WebSocket
+type messageType string
+
+const (
+	messageState messageType = "state"
+	messageError messageType = "error"
+)
+
+type message struct {
+	Type  messageType `json:"type"`
+	State *table.View `json:"state,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"pokergo/internal/webapi/binder"
+	"pokergo/pkg/poker"
+)
+
+type mux struct{}
+
+func NewMux() *mux {
+	return &mux{}
+}
+
+func (m *mux) Route(g *echo.Group) {
+	g.POST("/equity", m.Equity)
+}
+
+// Equity returns chances of players to win the hand.
+// All the deals are enumerated if possible, Monte Carlo is used otherwise (repeatable for the same seed).
+func (m *mux) Equity(c echo.Context) error {
+	data, bindErr := binder.BindRequest[equityRequest](c, true)
+	if bindErr != nil {
+		return c.String(bindErr.Code, bindErr.Message)
+	}
+	defer data.Cancel()
+
+	ranges := make([][]poker.Hand, 0, len(data.Request.Hands))
+	for _, hands := range data.Request.Hands {
+		var r []poker.Hand
+		for _, h := range strings.Split(hands, ",") {
+			hand, err := poker.ParseHand(h)
+			if err != nil {
+				return c.String(400, fmt.Sprintf("invalid hand %s: %s", h, err))
+			}
+			r = append(r, hand)
+		}
+		ranges = append(ranges, r)
+	}
+	board, err := poker.ParseCards(data.Request.Board)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid board: %s", err))
+	}
+	dead, err := poker.ParseCards(data.Request.Dead)
+	if err != nil {
+		return c.String(400, fmt.Sprintf("invalid dead cards: %s", err))
+	}
+
+	res, err := poker.CalculateEquity(ranges, board, dead, poker.EquityConfig{
+		Samples: data.Request.Samples,
+		Seed:    data.Request.Seed,
+	})
+	if err != nil {
+		return c.String(400, fmt.Sprintf("cannot calculate equity: %s", err))
+	}
+
+	players := make([]playerEquity, 0, len(res.Players))
+	for i, e := range res.Players {
+		players = append(players, playerEquity{Hand: data.Request.Hands[i], Equity: e})
+	}
+
+	return c.JSON(200, equityResponse{Players: players, Deals: res.Deals, Exact: res.Exact})
+}
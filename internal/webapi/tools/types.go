@@ -0,0 +1,24 @@
+package tools
+
+import "pokergo/pkg/poker"
+
+type equityRequest struct {
+	// Hands are hole cards of players, a comma separated list of hands is a range, e.g. "AsAh,AdAc"
+	Hands []string `json:"hands" validate:"required,min=2,max=10,dive,required"`
+	Board string   `json:"board"`
+	Dead  string   `json:"dead"`
+	// Samples is the number of Monte Carlo samples (used when there are too many deals to enumerate)
+	Samples int   `json:"samples" validate:"omitempty,gte=1000,lte=1000000"`
+	Seed    int64 `json:"seed"`
+}
+
+type playerEquity struct {
+	Hand string `json:"hand"`
+	poker.Equity
+}
+
+type equityResponse struct {
+	Players []playerEquity `json:"players"`
+	Deals   int64          `json:"deals"`
+	Exact   bool           `json:"exact"`
+}
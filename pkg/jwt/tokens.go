@@ -11,13 +11,14 @@ import (
 )
 
 type JWT struct {
-	timer    timer.Timer
-	secret   []byte
-	validity time.Duration
+	timer           timer.Timer
+	secret          []byte
+	validity        time.Duration
+	refreshValidity time.Duration
 }
 
-func NewJWT(timer timer.Timer, secret []byte, validity time.Duration) *JWT {
-	return &JWT{timer: timer, secret: secret, validity: validity}
+func NewJWT(timer timer.Timer, secret []byte, validity, refreshValidity time.Duration) *JWT {
+	return &JWT{timer: timer, secret: secret, validity: validity, refreshValidity: refreshValidity}
 }
 
 type SignedToken struct {
@@ -28,20 +29,35 @@ type SignedToken struct {
 	jwt.StandardClaims
 }
 
-var ErrTokenExpired = errors.New("token is expired")
+// refreshAudience marks refresh tokens, they cannot be used as access tokens
+const refreshAudience = "refresh"
 
-func (j JWT) GenerateTokens(email, username string, id id.ID) (string, string, error) {
+var (
+	ErrTokenExpired = errors.New("token is expired")
+	ErrInvalidToken = errors.New("token is invalid")
+)
+
+// GenerateTokens returns a new access token and a refresh token of the user,
+// every token has a unique ID (jti), so it can be revoked
+func (j JWT) GenerateTokens(email, username string, userID id.ID) (string, string, error) {
+	now := j.timer.Now()
 	claims := SignedToken{
 		Email:    email,
 		UserName: username,
-		ID:       id.Hex(),
+		ID:       userID.Hex(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: j.timer.Now().Add(j.validity).Unix(),
+			Id:        id.NewID().Hex(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(j.validity).Unix(),
 		},
 	}
 
 	refresh := jwt.StandardClaims{
-		ExpiresAt: j.timer.Now().Add(j.validity).Unix(),
+		Audience:  refreshAudience,
+		Subject:   userID.Hex(),
+		Id:        id.NewID().Hex(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(j.refreshValidity).Unix(),
 	}
 
 	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
@@ -56,6 +72,8 @@ func (j JWT) GenerateTokens(email, username string, id id.ID) (string, string, e
 	return token, refreshToken, nil
 }
 
+// ValidateToken checks the access token and returns its claims,
+// tokens without an ID (issued before tokens could be revoked) are rejected
 func (j JWT) ValidateToken(signed string) (SignedToken, error) {
 	token, err := jwt.ParseWithClaims(
 		signed,
@@ -73,9 +91,46 @@ func (j JWT) ValidateToken(signed string) (SignedToken, error) {
 		return SignedToken{}, fmt.Errorf("token is invalid: %w", err)
 	}
 
+	if claims.Audience == refreshAudience {
+		return SignedToken{}, fmt.Errorf("%w: refresh tokens cannot be used for authorization", ErrInvalidToken)
+	}
+	if claims.Id == "" {
+		return SignedToken{}, fmt.Errorf("%w: the token has no id, it cannot be revoked", ErrInvalidToken)
+	}
+
 	if claims.ExpiresAt < j.timer.Now().Unix() {
 		return SignedToken{}, ErrTokenExpired
 	}
 
 	return *claims, nil
 }
+
+// ValidateRefreshToken checks the refresh token and returns the ID of its user
+func (j JWT) ValidateRefreshToken(signed string) (id.ID, error) {
+	token, err := jwt.ParseWithClaims(
+		signed,
+		&jwt.StandardClaims{},
+		func(token *jwt.Token) (any, error) {
+			return j.secret, nil
+		})
+
+	if err != nil {
+		return id.ZeroID, fmt.Errorf("cannot parse refresh token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*jwt.StandardClaims)
+	if !ok || claims.Audience != refreshAudience {
+		return id.ZeroID, fmt.Errorf("%w: not a refresh token", ErrInvalidToken)
+	}
+
+	if claims.ExpiresAt < j.timer.Now().Unix() {
+		return id.ZeroID, ErrTokenExpired
+	}
+
+	userID, err := id.FromString(claims.Subject)
+	if err != nil {
+		return id.ZeroID, fmt.Errorf("%w: invalid user id: %s", ErrInvalidToken, err.Error())
+	}
+
+	return userID, nil
+}
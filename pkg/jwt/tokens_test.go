@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"pokergo/pkg/id"
+	"pokergo/pkg/timer"
+)
+
+func Test_JWT_Tokens(t *testing.T) {
+	clock := timer.NewFakeTimer(time.Now())
+	j := NewJWT(clock, []byte("secret"), time.Hour, 24*time.Hour)
+	userID := id.NewID()
+
+	token, refresh, err := j.GenerateTokens("a@b.c", "user", userID)
+	if err != nil {
+		t.Fatalf("cannot generate tokens: %s", err)
+	}
+	claims, err := j.ValidateToken(token)
+	if err != nil || claims.ID != userID.Hex() || claims.Id == "" {
+		t.Fatalf("the access token should be valid and have an id, got %+v (%v)", claims, err)
+	}
+	if got, err := j.ValidateRefreshToken(refresh); err != nil || got != userID {
+		t.Fatalf("the refresh token should be valid, got %s (%v)", got, err)
+	}
+
+	if _, err := j.ValidateToken(refresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("the refresh token cannot be used as the access token, got %v", err)
+	}
+	if _, err := j.ValidateRefreshToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("the access token cannot be used as the refresh token, got %v", err)
+	}
+
+	nextToken, nextRefresh, _ := j.GenerateTokens("a@b.c", "user", userID)
+	if nextToken == token || nextRefresh == refresh {
+		t.Errorf("tokens generated at the same time should differ")
+	}
+
+	clock.Advance(2 * time.Hour)
+	if _, err := j.ValidateToken(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+	if _, err := j.ValidateRefreshToken(refresh); err != nil {
+		t.Errorf("the refresh token should outlive the access token, got %v", err)
+	}
+}
+
+func Test_JWT_TokenWithoutID(t *testing.T) {
+	clock := timer.NewFakeTimer(time.Now())
+	j := NewJWT(clock, []byte("secret"), time.Hour, 24*time.Hour)
+
+	// tokens issued before tokens had ids cannot be revoked by logging out
+	claims := SignedToken{
+		ID:             id.NewID().Hex(),
+		StandardClaims: jwt.StandardClaims{ExpiresAt: clock.Now().Add(time.Hour).Unix()},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("cannot sign the token: %s", err)
+	}
+	if _, err := j.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tokens without id should be rejected, got %v", err)
+	}
+}
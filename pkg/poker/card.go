@@ -0,0 +1,198 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidCard    = errors.New("invalid card")
+	ErrDuplicatedCard = errors.New("duplicated card")
+)
+
+// Rank is a rank of the card, Two is the lowest and Ace the highest
+type Rank uint8
+
+const (
+	Two Rank = iota
+	Three
+	Four
+	Five
+	Six
+	Seven
+	Eight
+	Nine
+	Ten
+	Jack
+	Queen
+	King
+	Ace
+)
+
+// NumRanks is the number of ranks in the deck
+const NumRanks = 13
+
+const rankChars = "23456789TJQKA"
+
+func (r Rank) String() string {
+	if r >= NumRanks {
+		return "?"
+	}
+	return string(rankChars[r])
+}
+
+// ParseRank parses a rank character (2-9, T, J, Q, K, A, lowercase letters are allowed)
+func ParseRank(c byte) (Rank, error) {
+	i := strings.IndexByte(rankChars, upper(c))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: unknown rank %q", ErrInvalidCard, c)
+	}
+	return Rank(i), nil
+}
+
+// Suit is a suit of the card
+type Suit uint8
+
+const (
+	Clubs Suit = iota
+	Diamonds
+	Hearts
+	Spades
+)
+
+// NumSuits is the number of suits in the deck
+const NumSuits = 4
+
+const suitChars = "cdhs"
+
+func (s Suit) String() string {
+	if s >= NumSuits {
+		return "?"
+	}
+	return string(suitChars[s])
+}
+
+// ParseSuit parses a suit character (c, d, h, s, uppercase letters are allowed)
+func ParseSuit(c byte) (Suit, error) {
+	i := strings.IndexByte(suitChars, lower(c))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: unknown suit %q", ErrInvalidCard, c)
+	}
+	return Suit(i), nil
+}
+
+// Card is one of 52 cards, the value is rank*4+suit
+type Card uint8
+
+// NumCards is the number of cards in the deck
+const NumCards = NumRanks * NumSuits
+
+// NewCard returns the card of given rank and suit
+func NewCard(r Rank, s Suit) Card {
+	return Card(uint8(r)*NumSuits + uint8(s))
+}
+
+func (c Card) Rank() Rank {
+	return Rank(c / NumSuits)
+}
+
+func (c Card) Suit() Suit {
+	return Suit(c % NumSuits)
+}
+
+// String returns the card in the standard notation, e.g. "As"
+func (c Card) String() string {
+	return c.Rank().String() + c.Suit().String()
+}
+
+// ParseCard parses a card in the standard notation, e.g. "As", "Td", "9c"
+func ParseCard(s string) (Card, error) {
+	if len(s) != 2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCard, s)
+	}
+	r, err := ParseRank(s[0])
+	if err != nil {
+		return 0, err
+	}
+	suit, err := ParseSuit(s[1])
+	if err != nil {
+		return 0, err
+	}
+	return NewCard(r, suit), nil
+}
+
+// ParseCards parses distinct cards written one after another, e.g. "AsKd" or "As Kd Qh"
+// (spaces and commas are ignored)
+func ParseCards(s string) ([]Card, error) {
+	s = strings.NewReplacer(" ", "", ",", "").Replace(s)
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("%w: %q is not a list of cards", ErrInvalidCard, s)
+	}
+
+	cards := make([]Card, 0, len(s)/2)
+	var seen CardSet
+	for i := 0; i < len(s); i += 2 {
+		c, err := ParseCard(s[i : i+2])
+		if err != nil {
+			return nil, err
+		}
+		if seen.Has(c) {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicatedCard, c)
+		}
+		seen = seen.Add(c)
+		cards = append(cards, c)
+	}
+
+	return cards, nil
+}
+
+// FormatCards returns cards in the standard notation, e.g. "AsKd"
+func FormatCards(cards []Card) string {
+	var b strings.Builder
+	for _, c := range cards {
+		b.WriteString(c.String())
+	}
+	return b.String()
+}
+
+// CardSet is a set of cards (a bit per card)
+type CardSet uint64
+
+// NewCardSet returns a set of given cards
+func NewCardSet(cards ...Card) CardSet {
+	var s CardSet
+	for _, c := range cards {
+		s = s.Add(c)
+	}
+	return s
+}
+
+// Add returns the set with the card added
+func (s CardSet) Add(c Card) CardSet {
+	return s | 1<<c
+}
+
+// Has tells if the card is in the set
+func (s CardSet) Has(c Card) bool {
+	return s&(1<<c) != 0
+}
+
+// Overlaps tells if sets have a common card
+func (s CardSet) Overlaps(other CardSet) bool {
+	return s&other != 0
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c - 'A' + 'a'
+	}
+	return c
+}
@@ -0,0 +1,53 @@
+package poker
+
+import (
+	"errors"
+	"math/rand"
+)
+
+var ErrNotEnoughCards = errors.New("not enough cards in the deck")
+
+// Deck is a deck of cards, cards are drawn from the end
+type Deck struct {
+	cards []Card
+}
+
+// NewDeck returns an ordered deck of 52 cards without dead cards
+func NewDeck(dead ...Card) *Deck {
+	deadSet := NewCardSet(dead...)
+	cards := make([]Card, 0, NumCards)
+	for c := Card(0); c < NumCards; c++ {
+		if !deadSet.Has(c) {
+			cards = append(cards, c)
+		}
+	}
+	return &Deck{cards: cards}
+}
+
+// Len returns the number of cards left
+func (d *Deck) Len() int {
+	return len(d.cards)
+}
+
+// Cards returns cards left in the deck
+func (d *Deck) Cards() []Card {
+	return append([]Card{}, d.cards...)
+}
+
+// Shuffle shuffles cards left in the deck
+func (d *Deck) Shuffle(rng *rand.Rand) {
+	rng.Shuffle(len(d.cards), func(i, j int) {
+		d.cards[i], d.cards[j] = d.cards[j], d.cards[i]
+	})
+}
+
+// Draw takes n cards from the deck
+func (d *Deck) Draw(n int) ([]Card, error) {
+	if n > len(d.cards) {
+		return nil, ErrNotEnoughCards
+	}
+	drawn := make([]Card, n)
+	copy(drawn, d.cards[len(d.cards)-n:])
+	d.cards = d.cards[:len(d.cards)-n]
+	return drawn, nil
+}
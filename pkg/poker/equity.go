@@ -0,0 +1,285 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+var (
+	ErrNotEnoughPlayers = errors.New("at least two players are needed")
+	ErrInvalidBoard     = errors.New("the board must have 0, 3, 4 or 5 cards")
+	ErrNoHands          = errors.New("no possible hands")
+)
+
+const (
+	// DefaultMaxEnumeration is the maximal number of deals enumerated exhaustively
+	DefaultMaxEnumeration = 2_000_000
+	// DefaultSamples is the number of Monte Carlo samples
+	DefaultSamples = 100_000
+	// maxDealAttempts limits drawing conflicting hands from ranges in a single Monte Carlo sample
+	maxDealAttempts = 1000
+)
+
+// EquityConfig configures CalculateEquity, zero values mean defaults
+type EquityConfig struct {
+	// MaxEnumeration is the maximal number of deals computed exactly, Monte Carlo is used above it
+	MaxEnumeration int64
+	// Samples is the number of Monte Carlo samples
+	Samples int
+	// Seed seeds the random number generator of Monte Carlo (results are repeatable for the same seed)
+	Seed int64
+}
+
+// Equity are chances of the player in percents
+type Equity struct {
+	Win  float64 `json:"win"`
+	Tie  float64 `json:"tie"`
+	Lose float64 `json:"lose"`
+	// Equity is the expected share of the pot (ties are split between winners)
+	Equity float64 `json:"equity"`
+}
+
+// EquityResult are chances of all the players
+type EquityResult struct {
+	Players []Equity `json:"players"`
+	// Deals is the number of evaluated deals
+	Deals int64 `json:"deals"`
+	// Exact is true if all the deals were enumerated (false for Monte Carlo)
+	Exact bool `json:"exact"`
+}
+
+// equityCounter counts results of deals
+type equityCounter struct {
+	wins   []int64
+	ties   []int64
+	shares []float64
+	deals  int64
+
+	ranks []HandRank
+	cards []Card
+}
+
+func newEquityCounter(players int) *equityCounter {
+	return &equityCounter{
+		wins:   make([]int64, players),
+		ties:   make([]int64, players),
+		shares: make([]float64, players),
+		ranks:  make([]HandRank, players),
+		cards:  make([]Card, 0, 7),
+	}
+}
+
+// showdown evaluates a single deal
+func (c *equityCounter) showdown(holes []Hand, board []Card) {
+	for i, hole := range holes {
+		c.cards = append(append(c.cards[:0], hole...), board...)
+		c.ranks[i] = Evaluate(c.cards)
+	}
+
+	winners := Winners(c.ranks)
+	for _, w := range winners {
+		if len(winners) == 1 {
+			c.wins[w]++
+		} else {
+			c.ties[w]++
+		}
+		c.shares[w] += 1 / float64(len(winners))
+	}
+	c.deals++
+}
+
+func (c *equityCounter) result(exact bool) EquityResult {
+	res := EquityResult{Players: make([]Equity, len(c.wins)), Deals: c.deals, Exact: exact}
+	if c.deals == 0 {
+		return res
+	}
+	for i := range c.wins {
+		deals := float64(c.deals)
+		res.Players[i] = Equity{
+			Win:    100 * float64(c.wins[i]) / deals,
+			Tie:    100 * float64(c.ties[i]) / deals,
+			Lose:   100 * float64(c.deals-c.wins[i]-c.ties[i]) / deals,
+			Equity: 100 * c.shares[i] / deals,
+		}
+	}
+	return res
+}
+
+// CalculateEquity computes chances of players holding one of given hole cards (each player has a range
+// of possible 2-card hands, a single hand for known cards) with the known board and dead cards.
+// All the deals are enumerated if there are at most config.MaxEnumeration of them, Monte Carlo is used otherwise.
+func CalculateEquity(ranges [][]Hand, board, dead []Card, config EquityConfig) (EquityResult, error) {
+	if len(ranges) < 2 {
+		return EquityResult{}, ErrNotEnoughPlayers
+	}
+	if len(board) == 1 || len(board) == 2 || len(board) > 5 {
+		return EquityResult{}, ErrInvalidBoard
+	}
+	if config.MaxEnumeration == 0 {
+		config.MaxEnumeration = DefaultMaxEnumeration
+	}
+	if config.Samples == 0 {
+		config.Samples = DefaultSamples
+	}
+
+	known := NewCardSet(board...)
+	for _, c := range dead {
+		if known.Has(c) {
+			return EquityResult{}, fmt.Errorf("%w: %s", ErrDuplicatedCard, c)
+		}
+		known = known.Add(c)
+	}
+
+	// hands blocked by the board and dead cards are removed
+	possible := make([][]Hand, len(ranges))
+	for i, r := range ranges {
+		for _, h := range r {
+			if len(h) != 2 || h[0] == h[1] {
+				return EquityResult{}, fmt.Errorf("%w: %s is not a hold'em hand", ErrInvalidCard, h)
+			}
+			if !NewCardSet(h...).Overlaps(known) {
+				possible[i] = append(possible[i], h)
+			}
+		}
+		if len(possible[i]) == 0 {
+			return EquityResult{}, fmt.Errorf("%w: player %d", ErrNoHands, i+1)
+		}
+	}
+
+	missing := 5 - len(board)
+	deals := int64(1)
+	for _, p := range possible {
+		deals = saturatingMul(deals, int64(len(p)))
+	}
+	deals = saturatingMul(deals, binomial(NumCards-len(board)-len(dead)-2*len(ranges), missing))
+
+	if deals <= config.MaxEnumeration {
+		return enumerate(possible, board, known, missing)
+	}
+	return monteCarlo(possible, board, known, missing, config)
+}
+
+// enumerate evaluates all the deals, ErrNoHands is returned if hands of players always share cards
+func enumerate(possible [][]Hand, board []Card, known CardSet, missing int) (EquityResult, error) {
+	counter := newEquityCounter(len(possible))
+	holes := make([]Hand, len(possible))
+	fullBoard := make([]Card, len(board), 5)
+	copy(fullBoard, board)
+
+	var dealBoard func(from Card, used CardSet, left int)
+	dealBoard = func(from Card, used CardSet, left int) {
+		if left == 0 {
+			counter.showdown(holes, fullBoard)
+			return
+		}
+		for c := from; c < NumCards; c++ {
+			if used.Has(c) {
+				continue
+			}
+			fullBoard = append(fullBoard, c)
+			dealBoard(c+1, used.Add(c), left-1)
+			fullBoard = fullBoard[:len(fullBoard)-1]
+		}
+	}
+
+	var dealHoles func(player int, used CardSet)
+	dealHoles = func(player int, used CardSet) {
+		if player == len(possible) {
+			dealBoard(0, used, missing)
+			return
+		}
+		for _, h := range possible[player] {
+			hs := NewCardSet(h...)
+			if hs.Overlaps(used) {
+				continue
+			}
+			holes[player] = h
+			dealHoles(player+1, used|hs)
+		}
+	}
+
+	dealHoles(0, known)
+	if counter.deals == 0 {
+		return EquityResult{}, fmt.Errorf("%w: ranges block each other", ErrNoHands)
+	}
+	return counter.result(true), nil
+}
+
+// monteCarlo evaluates random deals
+func monteCarlo(possible [][]Hand, board []Card, known CardSet, missing int, config EquityConfig) (EquityResult, error) {
+	rng := rand.New(rand.NewSource(config.Seed)) // nolint:gosec // no need for crypto rand
+	counter := newEquityCounter(len(possible))
+	holes := make([]Hand, len(possible))
+	fullBoard := make([]Card, len(board), 5)
+	copy(fullBoard, board)
+	left := make([]Card, 0, NumCards)
+
+	for sample := 0; sample < config.Samples; sample++ {
+		used, ok := dealRandomHoles(rng, possible, holes, known)
+		if !ok {
+			return EquityResult{}, fmt.Errorf("%w: ranges block each other", ErrNoHands)
+		}
+
+		left = left[:0]
+		for c := Card(0); c < NumCards; c++ {
+			if !used.Has(c) {
+				left = append(left, c)
+			}
+		}
+		// partial Fisher-Yates shuffle of missing board cards
+		fullBoard = fullBoard[:len(board)]
+		for i := 0; i < missing; i++ {
+			j := i + rng.Intn(len(left)-i)
+			left[i], left[j] = left[j], left[i]
+			fullBoard = append(fullBoard, left[i])
+		}
+
+		counter.showdown(holes, fullBoard)
+	}
+
+	return counter.result(false), nil
+}
+
+// dealRandomHoles picks a hand from every range, hands cannot share cards
+func dealRandomHoles(rng *rand.Rand, possible [][]Hand, holes []Hand, known CardSet) (CardSet, bool) {
+	for attempt := 0; attempt < maxDealAttempts; attempt++ {
+		used := known
+		ok := true
+		for i, p := range possible {
+			h := p[rng.Intn(len(p))]
+			hs := NewCardSet(h...)
+			if hs.Overlaps(used) {
+				ok = false
+				break
+			}
+			holes[i] = h
+			used |= hs
+		}
+		if ok {
+			return used, true
+		}
+	}
+	return 0, false
+}
+
+// binomial returns n choose k
+func binomial(n, k int) int64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	res := int64(1)
+	for i := 1; i <= k; i++ {
+		res = res * int64(n-k+i) / int64(i)
+	}
+	return res
+}
+
+// saturatingMul multiplies positive numbers without overflowing
+func saturatingMul(a, b int64) int64 {
+	const maxInt64 = 1<<63 - 1
+	if a != 0 && b > maxInt64/a {
+		return maxInt64
+	}
+	return a * b
+}
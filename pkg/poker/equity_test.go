@@ -0,0 +1,115 @@
+package poker
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func single(t testing.TB, hands ...string) [][]Hand {
+	t.Helper()
+	ranges := make([][]Hand, 0, len(hands))
+	for _, h := range hands {
+		ranges = append(ranges, []Hand{mustHand(t, h)})
+	}
+	return ranges
+}
+
+func assertEquity(t *testing.T, got Equity, win, tie float64, precision float64) {
+	t.Helper()
+	if math.Abs(got.Win-win) > precision || math.Abs(got.Tie-tie) > precision {
+		t.Fatalf("expected win %.2f%% tie %.2f%%, got: %+v", win, tie, got)
+	}
+	if math.Abs(got.Win+got.Tie+got.Lose-100) > 1e-9 {
+		t.Fatalf("percentages should sum up to 100, got: %+v", got)
+	}
+}
+
+func Test_CalculateEquity_Exact(t *testing.T) {
+	// the well known preflop match-up: AA vs KK (kings share suits with aces, so they have fewer flushes)
+	res, err := CalculateEquity(single(t, "AsAh", "KsKh"), nil, nil, EquityConfig{})
+	if err != nil {
+		t.Fatalf("cannot calculate equity: %s", err)
+	}
+	if !res.Exact || res.Deals != 1712304 {
+		t.Fatalf("all the boards should be enumerated, got: %d deals", res.Deals)
+	}
+	assertEquity(t, res.Players[0], 82.36, 0.54, 0.01)
+	assertEquity(t, res.Players[1], 17.09, 0.54, 0.01)
+}
+
+func Test_CalculateEquity_Board(t *testing.T) {
+	// a flush draw against top pair on the turn, 9 outs of 44 cards
+	board := mustHand(t, "Ah7h2c3d")
+	res, err := CalculateEquity(single(t, "KhQh", "AsKd"), board, nil, EquityConfig{})
+	if err != nil {
+		t.Fatalf("cannot calculate equity: %s", err)
+	}
+	assertEquity(t, res.Players[0], 100*9.0/44, 0, 1e-9)
+
+	// dead cards are not dealt: two hearts are gone
+	dead := mustHand(t, "9h8h")
+	res, _ = CalculateEquity(single(t, "KhQh", "AsKd"), board, dead, EquityConfig{})
+	assertEquity(t, res.Players[0], 100*7.0/42, 0, 1e-9)
+}
+
+func Test_CalculateEquity_MonteCarlo(t *testing.T) {
+	config := EquityConfig{MaxEnumeration: 1, Samples: 200_000, Seed: 42}
+	res, err := CalculateEquity(single(t, "AsAh", "KsKh"), nil, nil, config)
+	if err != nil {
+		t.Fatalf("cannot calculate equity: %s", err)
+	}
+	if res.Exact || res.Deals != 200_000 {
+		t.Fatalf("Monte Carlo should be used, got: %+v", res)
+	}
+	assertEquity(t, res.Players[0], 82.36, 0.54, 0.5)
+
+	again, _ := CalculateEquity(single(t, "AsAh", "KsKh"), nil, nil, config)
+	if again.Players[0] != res.Players[0] {
+		t.Fatalf("the same seed should give the same result, got: %+v and %+v", res.Players[0], again.Players[0])
+	}
+}
+
+func Test_CalculateEquity_Ranges(t *testing.T) {
+	// AA (the player holds one of two combos) vs a pair of kings on the AK board
+	aces := []Hand{mustHand(t, "AsAh"), mustHand(t, "AdAc")}
+	kings := []Hand{mustHand(t, "KsKh")}
+	board := mustHand(t, "AcKd2h")
+
+	res, err := CalculateEquity([][]Hand{aces, kings}, board, nil, EquityConfig{})
+	if err != nil {
+		t.Fatalf("cannot calculate equity: %s", err)
+	}
+	// AdAc is blocked by the board, so AsAh (set of aces) vs KsKh (set of kings) is the only match-up
+	if res.Deals != binomial(52-3-4, 2) {
+		t.Fatalf("only one match-up should be enumerated, got: %d deals", res.Deals)
+	}
+	// kings need the last king (44 of 990 turn and river pairs), but not with the last ace
+	assertEquity(t, res.Players[1], 100*43.0/990, 0, 1e-9)
+}
+
+func Test_CalculateEquity_Errors(t *testing.T) {
+	if _, err := CalculateEquity(single(t, "AsAh"), nil, nil, EquityConfig{}); !errors.Is(err, ErrNotEnoughPlayers) {
+		t.Fatalf("one player is not enough, err: %v", err)
+	}
+	if _, err := CalculateEquity(single(t, "AsAh", "KsKh"), mustHand(t, "2c3c"), nil, EquityConfig{}); !errors.Is(err, ErrInvalidBoard) {
+		t.Fatalf("two cards are not a board, err: %v", err)
+	}
+	if _, err := CalculateEquity(single(t, "AsAh", "KsKh"), mustHand(t, "As2c3c"), nil, EquityConfig{}); !errors.Is(err, ErrNoHands) {
+		t.Fatalf("the hand is blocked by the board, err: %v", err)
+	}
+	// both enumeration and Monte Carlo reject hands sharing a card
+	for _, maxEnumeration := range []int64{DefaultMaxEnumeration, 1} {
+		config := EquityConfig{MaxEnumeration: maxEnumeration, Samples: 10}
+		if _, err := CalculateEquity(single(t, "AsKs", "AsKd"), nil, nil, config); !errors.Is(err, ErrNoHands) {
+			t.Errorf("max enumeration %d: hands share a card, err: %v", maxEnumeration, err)
+		}
+	}
+}
+
+func BenchmarkCalculateEquity_Preflop(b *testing.B) {
+	ranges := single(b, "AsAh", "KsKh")
+	for i := 0; i < b.N; i++ {
+		_, _ = CalculateEquity(ranges, nil, nil, EquityConfig{MaxEnumeration: 1, Samples: 10_000, Seed: int64(i)})
+	}
+}
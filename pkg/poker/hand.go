@@ -0,0 +1,233 @@
+package poker
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Category is a category of the poker hand
+type Category uint8
+
+const (
+	// Invalid is a category of hands which cannot be evaluated
+	Invalid Category = iota
+	HighCard
+	Pair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+)
+
+func (c Category) String() string {
+	switch c {
+	case HighCard:
+		return "high card"
+	case Pair:
+		return "pair"
+	case TwoPair:
+		return "two pair"
+	case ThreeOfAKind:
+		return "three of a kind"
+	case Straight:
+		return "straight"
+	case Flush:
+		return "flush"
+	case FullHouse:
+		return "full house"
+	case FourOfAKind:
+		return "four of a kind"
+	case StraightFlush:
+		return "straight flush"
+	case Invalid:
+	}
+	return "invalid"
+}
+
+// HandRank is the strength of the best 5-card hand, stronger hands have greater ranks
+// and equal hands (a split pot) have equal ranks.
+// The category is stored in bits 20-23, ranks of cards deciding about the hand in following 4-bit groups.
+type HandRank uint32
+
+const categoryShift = 20
+
+// Category returns the category of the hand
+func (r HandRank) Category() Category {
+	return Category(r >> categoryShift)
+}
+
+func (r HandRank) String() string {
+	return r.Category().String()
+}
+
+// newHandRank encodes the category and ranks of deciding cards (the most important first, at most 5)
+func newHandRank(c Category, ranks ...Rank) HandRank {
+	r := HandRank(c) << categoryShift
+	for i, rank := range ranks {
+		r |= HandRank(rank) << (16 - 4*i)
+	}
+	return r
+}
+
+// Hand is a set of cards (hole cards, a board or both)
+type Hand []Card
+
+// ParseHand parses cards of the hand, e.g. "AsKd"
+func ParseHand(s string) (Hand, error) {
+	cards, err := ParseCards(s)
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (h Hand) String() string {
+	return FormatCards(h)
+}
+
+// Rank evaluates the hand, see Evaluate
+func (h Hand) Rank() HandRank {
+	return Evaluate(h)
+}
+
+const (
+	wheelMask = 1<<Ace | 1<<Five | 1<<Four | 1<<Three | 1<<Two
+	fiveMask  = 0x1f
+)
+
+// Evaluate returns the rank of the best 5-card hand made from 5 to 7 distinct cards
+// (the rank of Invalid category is returned for other number of cards).
+// It does not allocate, so it can be used in hot loops.
+func Evaluate(cards []Card) HandRank {
+	if len(cards) < 5 || len(cards) > 7 {
+		return 0
+	}
+
+	var (
+		suits  [NumSuits]uint16
+		counts [NumRanks]uint8
+		ranks  uint16
+	)
+	for _, c := range cards {
+		suits[c.Suit()] |= 1 << c.Rank()
+		counts[c.Rank()]++
+		ranks |= 1 << c.Rank()
+	}
+
+	// there is no room for four of a kind nor full house next to a flush in 7 cards,
+	// so the flush can be checked first
+	for _, s := range suits {
+		if bits.OnesCount16(s) < 5 {
+			continue
+		}
+		if top, ok := straightTop(s); ok {
+			return newHandRank(StraightFlush, top)
+		}
+		return withKickers(newHandRank(Flush), s, 5, 0)
+	}
+
+	var quads, trips, pair, secondPair int8 = -1, -1, -1, -1
+	for r := int8(Ace); r >= 0; r-- {
+		switch counts[r] {
+		case 4:
+			quads = r
+		case 3:
+			if trips < 0 {
+				trips = r
+			} else if pair < 0 {
+				// the lower three of a kind is the pair of the full house
+				pair = r
+			}
+		case 2:
+			if pair < 0 {
+				pair = r
+			} else if secondPair < 0 {
+				secondPair = r
+			}
+		}
+	}
+
+	switch {
+	case quads >= 0:
+		return withKickers(newHandRank(FourOfAKind, Rank(quads)), ranks&^(1<<quads), 1, 1)
+	case trips >= 0 && pair >= 0:
+		return newHandRank(FullHouse, Rank(trips), Rank(pair))
+	}
+	if top, ok := straightTop(ranks); ok {
+		return newHandRank(Straight, top)
+	}
+
+	switch {
+	case trips >= 0:
+		return withKickers(newHandRank(ThreeOfAKind, Rank(trips)), ranks&^(1<<trips), 2, 1)
+	case secondPair >= 0:
+		return withKickers(newHandRank(TwoPair, Rank(pair), Rank(secondPair)), ranks&^(1<<pair|1<<secondPair), 1, 2)
+	case pair >= 0:
+		return withKickers(newHandRank(Pair, Rank(pair)), ranks&^(1<<pair), 3, 1)
+	}
+	return withKickers(newHandRank(HighCard), ranks, 5, 0)
+}
+
+// straightTop returns the highest rank of the best straight in the mask of ranks
+func straightTop(mask uint16) (Rank, bool) {
+	for top := Ace; top >= Six; top-- {
+		if mask>>(top-4)&fiveMask == fiveMask {
+			return top, true
+		}
+	}
+	if mask&wheelMask == wheelMask {
+		return Five, true
+	}
+	return 0, false
+}
+
+// withKickers adds n highest ranks of the mask to the hand rank starting from the position pos
+func withKickers(r HandRank, mask uint16, n, pos int) HandRank {
+	for ; n > 0 && mask != 0; n, pos = n-1, pos+1 {
+		top := bits.Len16(mask) - 1
+		r |= HandRank(top) << (16 - 4*pos)
+		mask &^= 1 << top
+	}
+	return r
+}
+
+// Winners returns indexes of the strongest hands (more than one for a split pot)
+func Winners(ranks []HandRank) []int {
+	var best HandRank
+	var winners []int
+	for i, r := range ranks {
+		switch {
+		case r > best:
+			best = r
+			winners = append(winners[:0], i)
+		case r == best && r != 0:
+			winners = append(winners, i)
+		}
+	}
+	return winners
+}
+
+// Showdown evaluates hole cards of players with the board and returns indexes of winners
+func Showdown(board Hand, holes ...Hand) ([]int, error) {
+	used := NewCardSet(board...)
+	ranks := make([]HandRank, len(holes))
+	cards := make([]Card, 0, 7)
+	for i, hole := range holes {
+		for _, c := range hole {
+			if used.Has(c) {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicatedCard, c)
+			}
+			used = used.Add(c)
+		}
+
+		cards = append(append(cards[:0], hole...), board...)
+		if ranks[i] = Evaluate(cards); ranks[i] == 0 {
+			return nil, fmt.Errorf("%w: hand %d has %d cards with the board", ErrInvalidCard, i, len(cards))
+		}
+	}
+
+	return Winners(ranks), nil
+}
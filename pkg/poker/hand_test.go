@@ -0,0 +1,263 @@
+package poker
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// forEachCombination calls f with every k-card combination of the deck (the slice is reused)
+func forEachCombination(k int, f func(cards []Card)) {
+	cards := make([]Card, k)
+	var rec func(start Card, depth int)
+	rec = func(start Card, depth int) {
+		if depth == k {
+			f(cards)
+			return
+		}
+		for c := start; c <= NumCards-Card(k-depth); c++ {
+			cards[depth] = c
+			rec(c+1, depth+1)
+		}
+	}
+	rec(0, 0)
+}
+
+// categoryCounts returns the number of k-card hands in each category
+func categoryCounts(k int) map[Category]int {
+	counts := make(map[Category]int)
+	forEachCombination(k, func(cards []Card) {
+		counts[Evaluate(cards).Category()]++
+	})
+	return counts
+}
+
+func Test_Evaluate_FiveCards(t *testing.T) {
+	expected := map[Category]int{
+		StraightFlush: 40,
+		FourOfAKind:   624,
+		FullHouse:     3744,
+		Flush:         5108,
+		Straight:      10200,
+		ThreeOfAKind:  54912,
+		TwoPair:       123552,
+		Pair:          1098240,
+		HighCard:      1302540,
+	}
+
+	counts := make(map[Category]int)
+	distinct := make(map[HandRank]struct{})
+	forEachCombination(5, func(cards []Card) {
+		r := Evaluate(cards)
+		counts[r.Category()]++
+		distinct[r] = struct{}{}
+	})
+
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("invalid category counts:\n got: %v\nwant: %v", counts, expected)
+	}
+	if len(distinct) != 7462 {
+		t.Fatalf("there are 7462 distinct 5-card hands, got: %d", len(distinct))
+	}
+}
+
+func Test_Evaluate_SixCards(t *testing.T) {
+	if testing.Short() {
+		t.Skip("20 million hands")
+	}
+
+	expected := map[Category]int{
+		StraightFlush: 1844,
+		FourOfAKind:   14664,
+		FullHouse:     165984,
+		Flush:         205792,
+		Straight:      361620,
+		ThreeOfAKind:  732160,
+		TwoPair:       2532816,
+		Pair:          9730740,
+		HighCard:      6612900,
+	}
+
+	if counts := categoryCounts(6); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("invalid category counts:\n got: %v\nwant: %v", counts, expected)
+	}
+}
+
+func Test_Evaluate_SevenCards(t *testing.T) {
+	if testing.Short() {
+		t.Skip("133 million hands")
+	}
+
+	expected := map[Category]int{
+		StraightFlush: 41584,
+		FourOfAKind:   224848,
+		FullHouse:     3473184,
+		Flush:         4047644,
+		Straight:      6180020,
+		ThreeOfAKind:  6461620,
+		TwoPair:       31433400,
+		Pair:          58627800,
+		HighCard:      23294460,
+	}
+
+	if counts := categoryCounts(7); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("invalid category counts:\n got: %v\nwant: %v", counts, expected)
+	}
+}
+
+func mustHand(t testing.TB, s string) Hand {
+	t.Helper()
+	h, err := ParseHand(s)
+	if err != nil {
+		t.Fatalf("cannot parse %s: %s", s, err)
+	}
+	return h
+}
+
+func Test_Evaluate_Ordering(t *testing.T) {
+	// every hand is stronger than the next one
+	hands := []string{
+		"AsKsQsJsTs",
+		"5h4h3h2hAh", // the wheel straight flush
+		"AcAdAhAsKd",
+		"KcKdKhAsAd",
+		"KcKdKhQsQd",
+		"AsQs9s5s3s",
+		"AsQs9s5s2s",
+		"AsKdQhJcTc",
+		"6s5d4h3c2c",
+		"5s4d3h2cAc", // the wheel
+		"7s7d7hAcKd",
+		"7s7d7hAcQd",
+		"AsAdKhKcQc",
+		"AsAdKhKcJc",
+		"AsAdQhQcKc",
+		"AsAdKhQcJc",
+		"KsKdAhQcJc",
+		"AsKdQhJc9c",
+		"AsKdQhJc8c",
+		"7s5d4h3c2c",
+	}
+
+	for i := 0; i+1 < len(hands); i++ {
+		stronger, weaker := Evaluate(mustHand(t, hands[i])), Evaluate(mustHand(t, hands[i+1]))
+		if stronger <= weaker {
+			t.Fatalf("%s (%s) should beat %s (%s)", hands[i], stronger, hands[i+1], weaker)
+		}
+	}
+}
+
+func Test_Evaluate_BestFiveOfSeven(t *testing.T) {
+	type tc struct {
+		cards    string
+		same     string // the best 5 cards
+		category Category
+	}
+
+	tcs := []tc{
+		{"AsKs2s3s4s5s6d", "5s4s3s2sAs", StraightFlush},
+		{"AsAdAhKsKdKhQc", "AsAdAhKsKd", FullHouse},
+		{"AsAdKhKcQhQc2d", "AsAdKhKcQh", TwoPair},
+		{"9s9d9h9c2d3dAh", "9s9d9h9cAh", FourOfAKind},
+		{"2s3d4h5c6d7hAs", "3d4h5c6d7h", Straight},
+	}
+
+	for _, test := range tcs {
+		seven, five := Evaluate(mustHand(t, test.cards)), Evaluate(mustHand(t, test.same))
+		if seven != five || seven.Category() != test.category {
+			t.Fatalf("%s should be %s (%s), got: %s", test.cards, test.same, test.category, seven)
+		}
+	}
+
+	if r := Evaluate(mustHand(t, "AsKs")); r.Category() != Invalid {
+		t.Fatalf("two cards cannot be evaluated, got: %s", r)
+	}
+}
+
+func Test_Showdown(t *testing.T) {
+	board := mustHand(t, "AhKhQd7c2s")
+
+	winners, err := Showdown(board, mustHand(t, "JsTs"), mustHand(t, "AsAd"), mustHand(t, "7d7s"))
+	if err != nil {
+		t.Fatalf("cannot showdown: %s", err)
+	}
+	if !reflect.DeepEqual(winners, []int{0}) {
+		t.Fatalf("the straight should win, got: %v", winners)
+	}
+
+	// both play the board straight
+	board = mustHand(t, "AhKhQdJcTs")
+	winners, _ = Showdown(board, mustHand(t, "2s3s"), mustHand(t, "4d5d"), mustHand(t, "AsKd"))
+	if !reflect.DeepEqual(winners, []int{0, 1, 2}) {
+		t.Fatalf("the pot should be split, got: %v", winners)
+	}
+
+	if _, err := Showdown(board, mustHand(t, "AhKs"), mustHand(t, "2s3s")); !errors.Is(err, ErrDuplicatedCard) {
+		t.Fatalf("cards cannot be used twice, err: %v", err)
+	}
+}
+
+func Test_ParseCards(t *testing.T) {
+	cards, err := ParseCards("As kd, Th")
+	if err != nil {
+		t.Fatalf("cannot parse cards: %s", err)
+	}
+	if FormatCards(cards) != "AsKdTh" {
+		t.Fatalf("invalid cards: %s", FormatCards(cards))
+	}
+
+	for _, invalid := range []string{"A", "Ax", "1s", "AsAs"} {
+		if _, err := ParseCards(invalid); err == nil {
+			t.Fatalf("%s should be invalid", invalid)
+		}
+	}
+}
+
+func Test_Deck(t *testing.T) {
+	dead := mustHand(t, "AsKs")
+	d := NewDeck(dead...)
+	if d.Len() != 50 {
+		t.Fatalf("dead cards should be removed, left: %d", d.Len())
+	}
+
+	d.Shuffle(rand.New(rand.NewSource(1)))
+	drawn, err := d.Draw(5)
+	if err != nil || len(drawn) != 5 || d.Len() != 45 {
+		t.Fatalf("cannot draw cards: %v, %v", drawn, err)
+	}
+	if NewCardSet(drawn...).Overlaps(NewCardSet(dead...)) {
+		t.Fatalf("dead cards drawn: %v", drawn)
+	}
+	if _, err := d.Draw(46); !errors.Is(err, ErrNotEnoughCards) {
+		t.Fatalf("cannot draw more cards than left, err: %v", err)
+	}
+}
+
+func benchmarkEvaluate(b *testing.B, k int) {
+	rng := rand.New(rand.NewSource(1))
+	hands := make([][]Card, 1024)
+	for i := range hands {
+		d := NewDeck()
+		d.Shuffle(rng)
+		hands[i], _ = d.Draw(k)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Evaluate(hands[i%len(hands)])
+	}
+}
+
+func BenchmarkEvaluate5(b *testing.B) {
+	benchmarkEvaluate(b, 5)
+}
+
+func BenchmarkEvaluate6(b *testing.B) {
+	benchmarkEvaluate(b, 6)
+}
+
+func BenchmarkEvaluate7(b *testing.B) {
+	benchmarkEvaluate(b, 7)
+}
@@ -0,0 +1,309 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRange = errors.New("invalid range")
+
+// NumCombos is the number of distinct 2-card hands
+const NumCombos = NumCards * (NumCards - 1) / 2
+
+// Combo is a 2-card hand of the range, Weight is a fraction of the combo played (from 0 exclusive to 1)
+type Combo struct {
+	Hand   Hand
+	Weight float64
+}
+
+// Range is a set of distinct combos
+type Range []Combo
+
+// ParseRange expands the range notation into combos, e.g. "22+, A2s+, KTo+, 76s-54s, AsKs, QJ:0.5".
+// Parts are separated by commas or spaces:
+//
+//	"77" - a pair, "AKs" - suited, "AKo" - offsuit, "AK" - both suited and offsuit, "AsKs" - a single combo
+//	"77+" - the pair and higher pairs, "A2s+" - the kicker increased up to one below the high card
+//	"77-TT", "A5s-A2s" - pairs or kickers between given hands
+//	"76s-54s" - connectors (or gappers) with the same gap between given hands
+//	":0.5" after any part sets the weight of its combos (1 by default)
+//
+// A combo given twice appears once with the weight of the last occurrence.
+func ParseRange(s string) (Range, error) {
+	var r Range
+	index := make(map[CardSet]int)
+	for _, part := range strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' }) {
+		hands, weight, err := parseRangePart(part)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hands {
+			set := NewCardSet(h...)
+			if i, ok := index[set]; ok {
+				r[i].Weight = weight
+				continue
+			}
+			index[set] = len(r)
+			r = append(r, Combo{Hand: h, Weight: weight})
+		}
+	}
+	if len(r) == 0 {
+		return nil, fmt.Errorf("%w: %q has no combos", ErrInvalidRange, s)
+	}
+	return r, nil
+}
+
+// Remove returns the range without combos blocked by dead cards
+func (r Range) Remove(dead ...Card) Range {
+	deadSet := NewCardSet(dead...)
+	res := make(Range, 0, len(r))
+	for _, c := range r {
+		if !NewCardSet(c.Hand...).Overlaps(deadSet) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Count returns the number of combos
+func (r Range) Count() int {
+	return len(r)
+}
+
+// WeightedCount returns the number of combos multiplied by their weights
+func (r Range) WeightedCount() float64 {
+	var count float64
+	for _, c := range r {
+		count += c.Weight
+	}
+	return count
+}
+
+// Hands returns hands of the range (weights are skipped)
+func (r Range) Hands() []Hand {
+	hands := make([]Hand, 0, len(r))
+	for _, c := range r {
+		hands = append(hands, c.Hand)
+	}
+	return hands
+}
+
+// Grid returns the weighted fraction of combos of every starting hand in the 13x13 grid,
+// see GridLabel for the layout
+func (r Range) Grid() [NumRanks][NumRanks]float64 {
+	var grid [NumRanks][NumRanks]float64
+	for _, c := range r {
+		row, col := gridCell(c.Hand)
+		grid[row][col] += c.Weight
+	}
+	for row := range grid {
+		for col := range grid[row] {
+			grid[row][col] /= float64(classCombos(row, col))
+		}
+	}
+	return grid
+}
+
+// GridLabel returns the starting hand of the grid cell. Aces are in the first row and column,
+// pairs are on the diagonal, suited hands above and offsuit hands below it, e.g. (0, 1) is "AKs" and (1, 0) is "AKo".
+func GridLabel(row, col int) string {
+	high, low := Rank(Ace-Rank(row)), Rank(Ace-Rank(col))
+	switch {
+	case row == col:
+		return high.String() + low.String()
+	case row < col:
+		return high.String() + low.String() + "s"
+	}
+	return low.String() + high.String() + "o"
+}
+
+// gridCell returns the grid cell of the 2-card hand
+func gridCell(h Hand) (int, int) {
+	high, low := h[0], h[1]
+	if high.Rank() < low.Rank() {
+		high, low = low, high
+	}
+	row, col := int(Ace-high.Rank()), int(Ace-low.Rank())
+	if high.Suit() != low.Suit() {
+		// offsuit hands are below the diagonal
+		return col, row
+	}
+	return row, col
+}
+
+// classCombos returns the number of combos of the starting hand in the grid cell
+func classCombos(row, col int) int {
+	switch {
+	case row == col:
+		return 6
+	case row < col:
+		return 4
+	}
+	return 12
+}
+
+// suitedness tells which combos of non-paired hands are included
+type suitedness uint8
+
+const (
+	anySuits suitedness = iota
+	suited
+	offsuit
+)
+
+// handClass is a starting hand, e.g. "AKs" or "77" (high >= low)
+type handClass struct {
+	high, low Rank
+	suits     suitedness
+}
+
+func (h handClass) pair() bool {
+	return h.high == h.low
+}
+
+// combos returns all the combos of the starting hand
+func (h handClass) combos() []Hand {
+	var hands []Hand
+	for s1 := Suit(0); s1 < NumSuits; s1++ {
+		for s2 := Suit(0); s2 < NumSuits; s2++ {
+			switch {
+			case h.pair() && s1 >= s2:
+			case !h.pair() && h.suits == suited && s1 != s2:
+			case !h.pair() && h.suits == offsuit && s1 == s2:
+			default:
+				hands = append(hands, Hand{NewCard(h.high, s1), NewCard(h.low, s2)})
+			}
+		}
+	}
+	return hands
+}
+
+// parseRangePart parses a single part of the range notation with an optional weight
+func parseRangePart(part string) ([]Hand, float64, error) {
+	weight := 1.0
+	if i := strings.IndexByte(part, ':'); i >= 0 {
+		w, err := strconv.ParseFloat(part[i+1:], 64)
+		if err != nil || w <= 0 || w > 1 {
+			return nil, 0, fmt.Errorf("%w: the weight of %q must be a number from 0 (exclusive) to 1", ErrInvalidRange, part)
+		}
+		part, weight = part[:i], w
+	}
+
+	if len(part) == 4 {
+		if hand, err := ParseHand(part); err == nil {
+			return []Hand{hand}, weight, nil
+		}
+	}
+
+	classes, err := parseClasses(part)
+	if err != nil {
+		return nil, 0, err
+	}
+	var hands []Hand
+	for _, c := range classes {
+		hands = append(hands, c.combos()...)
+	}
+	return hands, weight, nil
+}
+
+// parseClasses expands "77+", "A2s+", "77-TT", "A5s-A2s" or "76s-54s" into starting hands
+func parseClasses(part string) ([]handClass, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidRange, part)
+
+	if from, to, ok := strings.Cut(part, "-"); ok {
+		first, err := parseClass(from)
+		if err != nil {
+			return nil, err
+		}
+		last, err := parseClass(to)
+		if err != nil {
+			return nil, err
+		}
+		if first.suits != last.suits || first.pair() != last.pair() {
+			return nil, invalid
+		}
+		if first.high < last.high || first.high == last.high && first.low < last.low {
+			first, last = last, first
+		}
+
+		var classes []handClass
+		switch {
+		case first.pair():
+			for r := last.high; r <= first.high; r++ {
+				classes = append(classes, handClass{high: r, low: r})
+			}
+		case first.high == last.high:
+			for r := last.low; r <= first.low; r++ {
+				classes = append(classes, handClass{high: first.high, low: r, suits: first.suits})
+			}
+		case first.high-first.low == last.high-last.low:
+			for shift := Rank(0); shift <= first.high-last.high; shift++ {
+				classes = append(classes, handClass{high: last.high + shift, low: last.low + shift, suits: first.suits})
+			}
+		default:
+			return nil, invalid
+		}
+		return classes, nil
+	}
+
+	if strings.HasSuffix(part, "+") {
+		c, err := parseClass(strings.TrimSuffix(part, "+"))
+		if err != nil {
+			return nil, err
+		}
+		var classes []handClass
+		if c.pair() {
+			for r := c.high; r <= Ace; r++ {
+				classes = append(classes, handClass{high: r, low: r})
+			}
+			return classes, nil
+		}
+		for r := c.low; r < c.high; r++ {
+			classes = append(classes, handClass{high: c.high, low: r, suits: c.suits})
+		}
+		return classes, nil
+	}
+
+	c, err := parseClass(part)
+	if err != nil {
+		return nil, err
+	}
+	return []handClass{c}, nil
+}
+
+// parseClass parses a starting hand, e.g. "77", "AKs", "AKo" or "AK"
+func parseClass(s string) (handClass, error) {
+	invalid := fmt.Errorf("%w: %q is not a starting hand", ErrInvalidRange, s)
+	if len(s) != 2 && len(s) != 3 {
+		return handClass{}, invalid
+	}
+	high, err := ParseRank(s[0])
+	if err != nil {
+		return handClass{}, invalid
+	}
+	low, err := ParseRank(s[1])
+	if err != nil {
+		return handClass{}, invalid
+	}
+	if high < low {
+		high, low = low, high
+	}
+
+	c := handClass{high: high, low: low}
+	if len(s) == 3 {
+		switch lower(s[2]) {
+		case 's':
+			c.suits = suited
+		case 'o':
+			c.suits = offsuit
+		default:
+			return handClass{}, invalid
+		}
+		if c.pair() {
+			return handClass{}, invalid
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,87 @@
+package poker
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func mustRange(t testing.TB, s string) Range {
+	t.Helper()
+	r, err := ParseRange(s)
+	if err != nil {
+		t.Fatalf("cannot parse range %s: %s", s, err)
+	}
+	return r
+}
+
+func Test_ParseRange_Counts(t *testing.T) {
+	tcs := map[string]int{
+		"AA":                       6,
+		"AKs":                      4,
+		"AKo":                      12,
+		"ak":                       16,
+		"AsKs":                     1,
+		"22+":                      78,
+		"77-TT":                    24,
+		"TT-77":                    24,
+		"A2s+":                     48,
+		"KTo+":                     36,
+		"A5s-A2s":                  16,
+		"76s-54s":                  12,
+		"T8o-64o":                  60,
+		"22+, A2s+, KTo+, 76s-54s": 174,
+		"AKs, AsKs, AK":            16,
+		"22+ A2+ K2+ Q2+ J2+ T2+ 92+ 82+ 72+ 62+ 52+ 42+ 32": NumCombos,
+	}
+
+	for notation, expected := range tcs {
+		if count := mustRange(t, notation).Count(); count != expected {
+			t.Fatalf("%s should have %d combos, got: %d", notation, expected, count)
+		}
+	}
+}
+
+func Test_ParseRange_Weights(t *testing.T) {
+	r := mustRange(t, "AA, KK:0.5, AsKs:0.25, AKs")
+	if r.Count() != 16 {
+		t.Fatalf("invalid number of combos: %d", r.Count())
+	}
+	// AsKs is overwritten by AKs
+	if w := r.WeightedCount(); w != 13 {
+		t.Fatalf("invalid weighted count: %v", w)
+	}
+
+	grid := r.Grid()
+	if grid[0][0] != 1 || grid[1][1] != 0.5 || grid[0][1] != 1 || grid[1][0] != 0 {
+		t.Fatalf("invalid grid: AA %v, KK %v, AKs %v, AKo %v", grid[0][0], grid[1][1], grid[0][1], grid[1][0])
+	}
+	if GridLabel(0, 1) != "AKs" || GridLabel(1, 0) != "AKo" || GridLabel(12, 12) != "22" || GridLabel(12, 11) != "32o" {
+		t.Fatalf("invalid grid labels")
+	}
+}
+
+func Test_ParseRange_Invalid(t *testing.T) {
+	for _, invalid := range []string{"", "A", "AAs", "AKx", "77+s", "AKs-QJo", "A5s-K3s", "77-AKs", "AA:0", "AA:2", "AA:x", "AKs++"} {
+		if _, err := ParseRange(invalid); !errors.Is(err, ErrInvalidRange) {
+			t.Fatalf("%q should be invalid, err: %v", invalid, err)
+		}
+	}
+}
+
+func Test_Range_Remove(t *testing.T) {
+	r := mustRange(t, "AA, AKs, KQo:0.5")
+	r = r.Remove(mustHand(t, "AsQd")...)
+	// AA: 3 left, AKs: 3 left, KQo: 12 - 3 with Qd
+	if r.Count() != 15 {
+		t.Fatalf("invalid number of combos: %d", r.Count())
+	}
+	if w := r.WeightedCount(); math.Abs(w-10.5) > 1e-9 {
+		t.Fatalf("invalid weighted count: %v", w)
+	}
+	for _, h := range r.Hands() {
+		if NewCardSet(h...).Overlaps(NewCardSet(mustHand(t, "AsQd")...)) {
+			t.Fatalf("%s is blocked by dead cards", h)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package timer
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeTimer is a Timer which time is changed manually (useful in tests)
+type FakeTimer struct {
+	mux sync.Mutex
+	now time.Time
+}
+
+func NewFakeTimer(now time.Time) *FakeTimer {
+	return &FakeTimer{now: now}
+}
+
+func (f *FakeTimer) Now() time.Time {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	return f.now
+}
+
+// Advance moves the time forward by d
+func (f *FakeTimer) Advance(d time.Duration) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// Set sets the current time
+func (f *FakeTimer) Set(now time.Time) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.now = now
+}